@@ -3,6 +3,8 @@ package gen
 import (
 	"bufio"
 	"bytes"
+	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -124,3 +126,104 @@ func addFields(reg rdl.TypeRegistry, dst []*rdl.StructFieldDef, t *rdl.Type) []*
 func FlattenedFields(reg rdl.TypeRegistry, t *rdl.Type) []*rdl.StructFieldDef {
 	return addFields(reg, make([]*rdl.StructFieldDef, 0), t)
 }
+
+// JSONString returns the JSON of a value, i.e. a default value, or its Go format if it has none.
+func JSONString(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+// StatusText returns a status symbol as its code, formatted by the code function, and its message.
+func StatusText(sym string, code func(string) string) string {
+	return code(rdl.StatusCode(sym)) + " " + rdl.StatusMessage(sym)
+}
+
+// InputLocation returns where a resource input is in the request. Query param and header names are
+// formatted by the code function.
+func InputLocation(in *rdl.ResourceInput, code func(string) string) string {
+	switch {
+	case in.PathParam:
+		return "path"
+	case in.QueryParam != "":
+		return "query " + code(in.QueryParam)
+	case in.Header != "":
+		return "header " + code(in.Header)
+	case in.Flag:
+		return "flag"
+	}
+	return "body"
+}
+
+// Constraint is a named constraint of a type, as documented.
+type Constraint struct {
+	Name  string
+	Value string
+}
+
+// TypeConstraints returns the constraints a type declares, in documentation order. Literal values are
+// formatted by the code function, and type references by the link function.
+func TypeConstraints(t *rdl.Type, code func(string) string, link func(rdl.TypeRef) string) []Constraint {
+	var constraints []Constraint
+	add := func(name, value string) {
+		constraints = append(constraints, Constraint{name, value})
+	}
+	size := func(size, minSize, maxSize *int32) {
+		if size != nil {
+			add("Size", fmt.Sprint(*size))
+		}
+		if minSize != nil {
+			add("Minimum size", fmt.Sprint(*minSize))
+		}
+		if maxSize != nil {
+			add("Maximum size", fmt.Sprint(*maxSize))
+		}
+	}
+	switch t.Variant {
+	case rdl.TypeVariantStringTypeDef:
+		td := t.StringTypeDef
+		if td.Pattern != "" {
+			add("Pattern", code(td.Pattern))
+		}
+		if len(td.Values) > 0 {
+			values := make([]string, 0, len(td.Values))
+			for _, v := range td.Values {
+				values = append(values, code(v))
+			}
+			add("Values", strings.Join(values, ", "))
+		}
+		size(nil, td.MinSize, td.MaxSize)
+	case rdl.TypeVariantNumberTypeDef:
+		if td := t.NumberTypeDef; td.Min != nil {
+			add("Minimum", rdl.NumberString(td.Min))
+		}
+		if td := t.NumberTypeDef; td.Max != nil {
+			add("Maximum", rdl.NumberString(td.Max))
+		}
+	case rdl.TypeVariantArrayTypeDef:
+		td := t.ArrayTypeDef
+		if td.Items != "" {
+			add("Items", link(td.Items))
+		}
+		size(td.Size, td.MinSize, td.MaxSize)
+	case rdl.TypeVariantMapTypeDef:
+		td := t.MapTypeDef
+		if td.Keys != "" {
+			add("Keys", link(td.Keys))
+		}
+		if td.Items != "" {
+			add("Items", link(td.Items))
+		}
+		size(td.Size, td.MinSize, td.MaxSize)
+	case rdl.TypeVariantBytesTypeDef:
+		td := t.BytesTypeDef
+		size(td.Size, td.MinSize, td.MaxSize)
+	case rdl.TypeVariantStructTypeDef:
+		if t.StructTypeDef.Closed {
+			add("Closed", "no fields other than those declared are allowed")
+		}
+	}
+	return constraints
+}
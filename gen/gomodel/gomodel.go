@@ -23,6 +23,11 @@ import (
 var FullValidation bool = true
 var DefaultLibRdl = "github.com/ardielle/ardielle-go/rdl"
 
+//
+// DefaultLibTBin - the import path of the tbin package used by the generated TBin methods
+//
+var DefaultLibTBin = "github.com/ardielle/ardielle-go/tbin"
+
 type GeneratorParams struct {
 	Outdir         string
 	Banner         string
@@ -32,6 +37,7 @@ type GeneratorParams struct {
 	PrefixEnums    bool
 	PreciseTypes   bool
 	GenerateSchema bool
+	GenerateTBin   bool
 }
 
 type modelGenerator struct {
@@ -45,6 +51,8 @@ type modelGenerator struct {
 	untaggedUnions []string
 	ns             string
 	rdl            bool
+	tbin           bool
+	recursive      map[rdl.TypeName]bool
 }
 
 // GenerateGoModel generates the model code for the types defined in the RDL schema.
@@ -89,6 +97,13 @@ func Generate(schema *rdl.Schema, params *GeneratorParams) error {
 		untaggedUnions: params.UntaggedUnions,
 		ns:             params.Namespace,
 		rdl:            schema.Name == "rdl",
+		tbin:           params.GenerateTBin,
+	}
+	if gen.tbin {
+		if gen.rdl {
+			return fmt.Errorf("Cannot generate TBin methods for the rdl schema itself")
+		}
+		gen.recursive = recursiveTypes(schema)
 	}
 	gen.emitHeader(params.Banner)
 	if gen.err == nil {
@@ -237,6 +252,10 @@ func (gen *modelGenerator) emitHeader(banner string) {
 	visited := make(map[rdl.TypeName]rdl.TypeName, 0)
 	for _, t := range gen.schema.Types {
 		gen.requiredImports(t, imports, visited)
+		if gen.tbin && gen.hasTBinMethods(t) {
+			imports[DefaultLibTBin] = ""
+			imports["fmt"] = ""
+		}
 	}
 	gen.emit(GenerationHeader(banner))
 	gen.emit("\n\npackage " + GenerationPackage(gen.schema, gen.ns) + "\n")
@@ -469,19 +488,24 @@ func (gen *modelGenerator) emitType(t *rdl.Type) {
 		case rdl.BaseTypeStruct:
 			gen.emit("\n")
 			gen.emitStruct(t)
+			gen.emitTBin(t)
 		case rdl.BaseTypeUnion:
 			gen.emit("\n")
 			gen.emitUnion(t)
+			gen.emitTBin(t)
 		case rdl.BaseTypeArray:
 			gen.emit("\n")
 			gen.emitArray(t)
+			gen.emitTBin(t)
 		case rdl.BaseTypeMap:
 			gen.emit("\n")
 			gen.emitMap(t)
+			gen.emitTBin(t)
 		case rdl.BaseTypeEnum:
 			gen.emit("\n")
 			gen.emitTypeComment(t)
 			gen.emitEnum(t)
+			gen.emitTBin(t)
 		}
 	}
 }
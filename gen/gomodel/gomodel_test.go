@@ -3,6 +3,9 @@ package gomodel
 import (
 	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/ardielle/ardielle-go/rdl"
@@ -49,3 +52,45 @@ func TestModelGen(test *testing.T) {
 		return
 	}
 }
+
+func TestModelGenTBinRejectsRdlSchema(test *testing.T) {
+	schema, err := rdl.ParseRDLFile("../../testdata/rdl.rdl", false, false, true)
+	if err != nil {
+		test.Fatalf("Cannot parse schema: %v", err)
+	}
+	err = Generate(schema, &GeneratorParams{Outdir: test.TempDir(), LibRdl: DefaultLibRdl, GenerateTBin: true})
+	if err == nil {
+		test.Errorf("Expected an error generating TBin methods into the rdl package itself")
+	}
+}
+
+func TestModelGenTBinRecursive(test *testing.T) {
+	dir := test.TempDir()
+	src := `name rdltest;
+type Action String;
+type RouteRule Struct { Action action (optional); Array<RouteRule> rules (optional); }
+type RouteTable Struct { Array<RouteRule> rules; }
+type Point Struct { Int32 x; Int32 y; }
+`
+	if err := os.WriteFile(filepath.Join(dir, "recursive.rdl"), []byte(src), 0644); err != nil {
+		test.Fatal(err)
+	}
+	schema, err := rdl.ParseRDLFile(filepath.Join(dir, "recursive.rdl"), false, false, true)
+	if err != nil {
+		test.Fatalf("Cannot parse schema: %v", err)
+	}
+	out := filepath.Join(dir, "model.go")
+	if err := Generate(schema, &GeneratorParams{Outdir: out, LibRdl: DefaultLibRdl, GenerateTBin: true}); err != nil {
+		test.Fatalf("Expected recursive types to be skipped, got %v", err)
+	}
+	b, _ := os.ReadFile(out)
+	code := string(b)
+	for _, name := range []string{"RouteRule", "RouteTable"} {
+		if strings.Contains(code, "func (self *"+name+") MarshalTBin") || !strings.Contains(code, "// "+name+" is recursive") {
+			test.Errorf("Expected %s to have no TBin methods, as a recursive type", name)
+		}
+	}
+	if !strings.Contains(code, "func (self *Point) MarshalTBin") {
+		test.Errorf("Expected TBin methods for a type that is not recursive")
+	}
+}
@@ -0,0 +1,661 @@
+// Copyright 2015 Yahoo Inc.
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package gomodel
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	genutil "github.com/ardielle/ardielle-go/gen"
+	"github.com/ardielle/ardielle-go/rdl"
+)
+
+// The TBin methods generated here produce exactly what the reflective tbin encoder does for the same
+// types: a type tag (and its definition, on first use) followed by the packed value, where optional
+// struct fields are tagged values, null if absent. The packed values of structs and unions are written
+// and read by unexported writeTBin and readTBin methods, everything else is inlined.
+
+// hasTBinMethods is true if MarshalTBin and UnmarshalTBin are generated for the type. Maps of Any are
+// left to the reflective encoder, which encodes them as generic maps rather than typed ones. Recursive types
+// and the types that refer to them have no TBin signature, and fail to encode.
+func (gen *modelGenerator) hasTBinMethods(t *rdl.Type) bool {
+	if !gen.tbinEncodable(t) {
+		return false
+	}
+	tName, _, _ := rdl.TypeInfo(t)
+	return !gen.recursive[tName]
+}
+
+// tbinEncodable is true if the kind of the type can have TBin methods.
+func (gen *modelGenerator) tbinEncodable(t *rdl.Type) bool {
+	if t == nil {
+		return false
+	}
+	tName, _, _ := rdl.TypeInfo(t)
+	if strings.HasPrefix(string(tName), "rdl.") {
+		return false
+	}
+	switch t.Variant {
+	case rdl.TypeVariantStructTypeDef, rdl.TypeVariantUnionTypeDef, rdl.TypeVariantEnumTypeDef, rdl.TypeVariantArrayTypeDef:
+		return true
+	case rdl.TypeVariantMapTypeDef:
+		return gen.registry.FindBaseType(t.MapTypeDef.Items) != rdl.BaseTypeAny
+	}
+	return false
+}
+
+// isUserStruct is true for structs with generated Go types, as opposed to rdl.Struct and its aliases.
+func (gen *modelGenerator) isUserStruct(ref rdl.TypeRef) bool {
+	t := gen.registry.FindType(ref)
+	return t != nil && t.Variant == rdl.TypeVariantStructTypeDef && gen.hasTBinMethods(t)
+}
+
+// isNamedType is true if the Go type is a defined type rather than a slice or map literal.
+func isNamedType(gtype string) bool {
+	return !strings.HasPrefix(gtype, "[") && !strings.HasPrefix(gtype, "map[")
+}
+
+func tbinSignatureName(name string) string {
+	return "tbin" + name + "Signature"
+}
+
+// containerTypes returns the items and keys of an array or map, resolved the way goType does it.
+func (gen *modelGenerator) containerTypes(ref, items, keys rdl.TypeRef) (rdl.TypeRef, rdl.TypeRef) {
+	t := gen.registry.FindType(ref)
+	switch gen.registry.BaseType(t) {
+	case rdl.BaseTypeArray:
+		i := rdl.TypeRef("Any")
+		if t.Variant == rdl.TypeVariantArrayTypeDef {
+			i = t.ArrayTypeDef.Items
+		} else if items != "" {
+			i = items
+		}
+		return i, ""
+	case rdl.BaseTypeMap:
+		k := rdl.TypeRef("Any")
+		i := rdl.TypeRef("Any")
+		if t.Variant == rdl.TypeVariantMapTypeDef {
+			k = t.MapTypeDef.Keys
+			i = t.MapTypeDef.Items
+		} else {
+			if keys != "" {
+				k = keys
+			}
+			if items != "" {
+				i = items
+			}
+		}
+		return i, k
+	}
+	return items, keys
+}
+
+// isTBinSymbol is true if the Go type is encoded as a symbol. Precise types derived from Symbol are plain
+// strings to the encoder.
+func (gen *modelGenerator) isTBinSymbol(gtype string) bool {
+	if gen.rdl {
+		return strings.TrimPrefix(gtype, "*") == "Symbol"
+	}
+	return strings.TrimPrefix(gtype, "*") == "rdl.Symbol"
+}
+
+// tbinPrimitive returns the tbin name (as used by the Signature vars, and the Read/Write/Encode methods)
+// and the Go type for the scalar base types.
+func (gen *modelGenerator) tbinPrimitive(bt rdl.BaseType, gtype string) (string, string) {
+	switch bt {
+	case rdl.BaseTypeBool:
+		return "Bool", "bool"
+	case rdl.BaseTypeInt8:
+		return "Int8", "int8"
+	case rdl.BaseTypeInt16:
+		return "Int16", "int16"
+	case rdl.BaseTypeInt32:
+		return "Int32", "int32"
+	case rdl.BaseTypeInt64:
+		return "Int64", "int64"
+	case rdl.BaseTypeFloat32:
+		return "Float32", "float32"
+	case rdl.BaseTypeFloat64:
+		return "Float64", "float64"
+	case rdl.BaseTypeString:
+		return "String", "string"
+	case rdl.BaseTypeSymbol:
+		if gen.isTBinSymbol(gtype) {
+			return "Symbol", "string"
+		}
+		return "String", "string"
+	}
+	return "", ""
+}
+
+// indexable parenthesizes a dereferenced target, so that it can be indexed.
+func indexable(target string) string {
+	if strings.HasPrefix(target, "*") {
+		return "(" + target + ")"
+	}
+	return target
+}
+
+// isElementVar reports whether the target is one of the variables declared for the keys and items
+// of slices and maps.
+func isElementVar(target string) bool {
+	if len(target) < 2 || (target[0] != 'v' && target[0] != 'k') {
+		return false
+	}
+	_, err := strconv.Atoi(target[1:])
+	return err == nil
+}
+
+func convert(gtype, expr, to string) string {
+	if gtype == to {
+		return expr
+	}
+	return to + "(" + expr + ")"
+}
+
+// tbinSignature returns a Go expression for the tbin signature of the type, as tbin.TypeSignature
+// would compute it for the generated Go type.
+func (gen *modelGenerator) tbinSignature(ref, items, keys rdl.TypeRef, optional, reference bool) string {
+	gtype := goType(gen.registry, ref, optional, items, keys, gen.precise, reference)
+	t := gen.registry.FindType(ref)
+	bt := gen.registry.BaseType(t)
+	if name, _ := gen.tbinPrimitive(bt, gtype); name != "" {
+		return "tbin." + name
+	}
+	switch bt {
+	case rdl.BaseTypeTimestamp:
+		return "tbin.Timestamp"
+	case rdl.BaseTypeUUID:
+		return "tbin.UUID"
+	case rdl.BaseTypeBytes:
+		return "tbin.Array(tbin.Int8)"
+	case rdl.BaseTypeStruct:
+		if !gen.isUserStruct(ref) {
+			if strings.HasSuffix(gtype, "rdl.Struct") {
+				return "&tbin.Signature{Tag: tbin.StructTag}"
+			}
+			return "tbin.Map(tbin.Symbol, tbin.Any)"
+		}
+		return tbinSignatureName(strings.TrimPrefix(gtype, "*"))
+	case rdl.BaseTypeUnion, rdl.BaseTypeEnum:
+		return tbinSignatureName(strings.TrimPrefix(gtype, "*"))
+	case rdl.BaseTypeArray, rdl.BaseTypeMap:
+		if isNamedType(gtype) && gen.hasTBinMethods(t) {
+			return tbinSignatureName(gtype)
+		}
+		if isNamedType(gtype) {
+			reference = false
+		}
+		i, k := gen.containerTypes(ref, items, keys)
+		if bt == rdl.BaseTypeArray {
+			return "tbin.Array(" + gen.tbinSignature(i, "", "", false, reference) + ")"
+		}
+		return "tbin.Map(" + gen.tbinSignature(k, "", "", false, reference) + ", " + gen.tbinSignature(i, "", "", false, reference) + ")"
+	}
+	return "tbin.Any"
+}
+
+// emitTBinWrite emits the code to write the packed value of expr.
+func (gen *modelGenerator) emitTBinWrite(indent, expr string, ref, items, keys rdl.TypeRef, reference bool, depth int) {
+	gtype := goType(gen.registry, ref, false, items, keys, gen.precise, reference)
+	t := gen.registry.FindType(ref)
+	bt := gen.registry.BaseType(t)
+	if name, base := gen.tbinPrimitive(bt, gtype); name != "" {
+		gen.emit(fmt.Sprintf("%senc.Write%s(%s)\n", indent, name, convert(gtype, expr, base)))
+		return
+	}
+	d := fmt.Sprint(depth)
+	switch bt {
+	case rdl.BaseTypeTimestamp, rdl.BaseTypeUUID:
+		gen.emit(fmt.Sprintf("%senc.Write%s(%s)\n", indent, bt, expr))
+		return
+	case rdl.BaseTypeEnum:
+		gen.emit(fmt.Sprintf("%senc.WriteInt32(int32(%s))\n", indent, expr))
+		return
+	case rdl.BaseTypeBytes:
+		gen.emit(fmt.Sprintf("%senc.WriteSize(len(%s))\n", indent, expr))
+		gen.emit(fmt.Sprintf("%sfor _, b%s := range %s {\n", indent, d, expr))
+		gen.emit(fmt.Sprintf("%s\tenc.WriteInt8(int8(b%s))\n", indent, d))
+		gen.emit(indent + "}\n")
+		return
+	case rdl.BaseTypeStruct, rdl.BaseTypeUnion:
+		if bt == rdl.BaseTypeUnion || gen.isUserStruct(ref) {
+			gen.emit(fmt.Sprintf("%sif err := %s.writeTBin(enc); err != nil {\n", indent, expr))
+			gen.emit(indent + "\treturn err\n")
+			gen.emit(indent + "}\n")
+			return
+		}
+	case rdl.BaseTypeArray, rdl.BaseTypeMap:
+		if isNamedType(gtype) {
+			reference = false
+		}
+		i, k := gen.containerTypes(ref, items, keys)
+		gen.emit(fmt.Sprintf("%senc.WriteSize(len(%s))\n", indent, expr))
+		if bt == rdl.BaseTypeArray {
+			gen.emit(fmt.Sprintf("%sfor _, item%s := range %s {\n", indent, d, expr))
+			gen.emitTBinWrite(indent+"\t", "item"+d, i, "", "", reference, depth+1)
+		} else {
+			gen.emit(fmt.Sprintf("%sfor k%s, v%s := range %s {\n", indent, d, d, expr))
+			gen.emitTBinWrite(indent+"\t", "k"+d, k, "", "", reference, depth+1)
+			gen.emitTBinWrite(indent+"\t", "v"+d, i, "", "", reference, depth+1)
+		}
+		gen.emit(indent + "}\n")
+		return
+	}
+	gen.emit(fmt.Sprintf("%sif err := enc.Encode(%s); err != nil {\n", indent, expr))
+	gen.emit(indent + "\treturn err\n")
+	gen.emit(indent + "}\n")
+}
+
+// emitTBinRead emits the code to read a packed value into target, which must be addressable.
+func (gen *modelGenerator) emitTBinRead(indent, target string, ref, items, keys rdl.TypeRef, reference bool, depth int) {
+	gtype := goType(gen.registry, ref, false, items, keys, gen.precise, reference)
+	t := gen.registry.FindType(ref)
+	bt := gen.registry.BaseType(t)
+	if name, base := gen.tbinPrimitive(bt, gtype); name != "" {
+		gen.emit(fmt.Sprintf("%s%s = %s\n", indent, target, convert(base, "dec.Read"+name+"()", gtype)))
+		return
+	}
+	d := fmt.Sprint(depth)
+	switch bt {
+	case rdl.BaseTypeTimestamp, rdl.BaseTypeUUID:
+		gen.emit(fmt.Sprintf("%s%s = dec.Read%s()\n", indent, target, bt))
+		return
+	case rdl.BaseTypeEnum:
+		gen.emit(fmt.Sprintf("%s%s = %s(dec.ReadInt32())\n", indent, target, gtype))
+		return
+	case rdl.BaseTypeBytes:
+		gen.emitTBinReuse(indent, target, "make([]byte, 0)", indexable(target)+"[:0]")
+		gen.emit(fmt.Sprintf("%sfor i%s, n%s := 0, dec.ReadSize(); i%s < n%s && dec.Error() == nil; i%s++ {\n", indent, d, d, d, d, d))
+		gen.emit(fmt.Sprintf("%s\t%s = append(%s, byte(dec.ReadInt8()))\n", indent, target, target))
+		gen.emit(indent + "}\n")
+		return
+	case rdl.BaseTypeStruct, rdl.BaseTypeUnion:
+		if bt == rdl.BaseTypeUnion || gen.isUserStruct(ref) {
+			gen.emit(fmt.Sprintf("%s%s = new(%s)\n", indent, target, strings.TrimPrefix(gtype, "*")))
+			gen.emit(fmt.Sprintf("%sif err := %s.readTBin(dec); err != nil {\n", indent, target))
+			gen.emit(indent + "\treturn err\n")
+			gen.emit(indent + "}\n")
+			return
+		}
+	case rdl.BaseTypeArray:
+		if isNamedType(gtype) {
+			reference = false
+		}
+		i, _ := gen.containerTypes(ref, items, keys)
+		gen.emitTBinReuse(indent, target, fmt.Sprintf("make(%s, 0)", gtype), indexable(target)+"[:0]")
+		gen.emit(fmt.Sprintf("%sfor i%s, n%s := 0, dec.ReadSize(); i%s < n%s && dec.Error() == nil; i%s++ {\n", indent, d, d, d, d, d))
+		gen.emit(fmt.Sprintf("%s\tvar v%s %s\n", indent, d, goType(gen.registry, i, false, "", "", gen.precise, reference)))
+		gen.emitTBinRead(indent+"\t", "v"+d, i, "", "", reference, depth+1)
+		gen.emit(fmt.Sprintf("%s\t%s = append(%s, v%s)\n", indent, target, target, d))
+		gen.emit(indent + "}\n")
+		return
+	case rdl.BaseTypeMap:
+		if isNamedType(gtype) {
+			reference = false
+		}
+		i, k := gen.containerTypes(ref, items, keys)
+		gen.emitTBinReuse(indent, target, fmt.Sprintf("make(%s)", gtype), "")
+		gen.emit(fmt.Sprintf("%sfor i%s, n%s := 0, dec.ReadSize(); i%s < n%s && dec.Error() == nil; i%s++ {\n", indent, d, d, d, d, d))
+		gen.emit(fmt.Sprintf("%s\tvar k%s %s\n", indent, d, goType(gen.registry, k, false, "", "", gen.precise, reference)))
+		gen.emitTBinRead(indent+"\t", "k"+d, k, "", "", reference, depth+1)
+		gen.emit(fmt.Sprintf("%s\tvar v%s %s\n", indent, d, goType(gen.registry, i, false, "", "", gen.precise, reference)))
+		gen.emitTBinRead(indent+"\t", "v"+d, i, "", "", reference, depth+1)
+		gen.emit(fmt.Sprintf("%s\t%s[k%s] = v%s\n", indent, indexable(target), d, d))
+		gen.emit(indent + "}\n")
+		return
+	}
+	gen.emit(fmt.Sprintf("%sif err := dec.Decode(&%s); err != nil {\n", indent, target))
+	gen.emit(indent + "\treturn err\n")
+	gen.emit(indent + "}\n")
+}
+
+// optionalSignatureVar is the name of the signature var for an optional array or map field that is not of
+// a named type, or "" if the field doesn't need one.
+func (gen *modelGenerator) optionalSignatureVar(structName rdl.TypeName, f *rdl.StructFieldDef) string {
+	if !f.Optional {
+		return ""
+	}
+	switch gen.registry.FindBaseType(f.Type) {
+	case rdl.BaseTypeArray, rdl.BaseTypeMap:
+		gtype := goType(gen.registry, f.Type, true, f.Items, f.Keys, gen.precise, true)
+		i, _ := gen.containerTypes(f.Type, f.Items, f.Keys)
+		if !isNamedType(gtype) && gen.registry.FindBaseType(i) != rdl.BaseTypeAny {
+			return tbinSignatureName(string(structName) + capitalize(string(f.Name)))
+		}
+	}
+	return ""
+}
+
+// emitTBinWriteOptional emits the code to write an optional field, as a tagged value or null.
+func (gen *modelGenerator) emitTBinWriteOptional(indent, expr string, f *rdl.StructFieldDef, sigVar string) {
+	gtype := goType(gen.registry, f.Type, true, f.Items, f.Keys, gen.precise, true)
+	t := gen.registry.FindType(f.Type)
+	bt := gen.registry.BaseType(t)
+	null := fmt.Sprintf("%sif %s == nil {\n%s\tenc.EncodeNull()\n%s} else ", indent, expr, indent, indent)
+	if name, base := gen.tbinPrimitive(bt, gtype); name != "" {
+		if strings.HasPrefix(gtype, "*") {
+			gen.emit(null + "{\n")
+			gen.emit(fmt.Sprintf("%s\tenc.Encode%s(%s)\n", indent, name, convert(gtype[1:], "*"+expr, base)))
+		} else {
+			gen.emit(fmt.Sprintf("%sif %s == \"\" {\n%s\tenc.EncodeNull()\n%s} else {\n", indent, expr, indent, indent))
+			if name == "Symbol" {
+				gen.emit(fmt.Sprintf("%s\tenc.EncodeSymbol(%s)\n", indent, convert(gtype, expr, "rdl.Symbol")))
+			} else {
+				gen.emit(fmt.Sprintf("%s\tenc.EncodeString(%s)\n", indent, convert(gtype, expr, base)))
+			}
+		}
+		gen.emit(indent + "}\n")
+		return
+	}
+	switch bt {
+	case rdl.BaseTypeTimestamp, rdl.BaseTypeUUID:
+		gen.emit(null + "{\n")
+		gen.emit(fmt.Sprintf("%s\tenc.Encode%s(*%s)\n", indent, bt, expr))
+		gen.emit(indent + "}\n")
+		return
+	case rdl.BaseTypeBytes:
+		gen.emit(null + "{\n")
+		gen.emit(fmt.Sprintf("%s\tenc.EncodeBytes(%s)\n", indent, expr))
+		gen.emit(indent + "}\n")
+		return
+	case rdl.BaseTypeArray, rdl.BaseTypeMap:
+		if sigVar != "" {
+			gen.emit(null + "{\n")
+			gen.emit(fmt.Sprintf("%s\tenc.WriteType(%s)\n", indent, sigVar))
+			gen.emitTBinWrite(indent+"\t", expr, f.Type, f.Items, f.Keys, true, 1)
+			gen.emit(indent + "}\n")
+			return
+		}
+	}
+	if gen.hasTBinMethods(t) && (bt != rdl.BaseTypeStruct || gen.isUserStruct(f.Type)) {
+		gen.emit(null + fmt.Sprintf("if err := %s.MarshalTBin(enc); err != nil {\n", expr))
+	} else {
+		gen.emit(null + fmt.Sprintf("if err := enc.Encode(%s); err != nil {\n", expr))
+	}
+	gen.emit(indent + "\treturn err\n")
+	gen.emit(indent + "}\n")
+}
+
+// emitTBinReadOptional emits the code to read an optional field, leaving it unset if the value is null.
+func (gen *modelGenerator) emitTBinReadOptional(indent, target string, f *rdl.StructFieldDef, sigVar string) {
+	gtype := goType(gen.registry, f.Type, true, f.Items, f.Keys, gen.precise, true)
+	t := gen.registry.FindType(f.Type)
+	bt := gen.registry.BaseType(t)
+	if name, base := gen.tbinPrimitive(bt, gtype); name != "" {
+		if !strings.HasPrefix(gtype, "*") {
+			if name == "Symbol" {
+				gen.emit(fmt.Sprintf("%s%s = %s\n", indent, target, convert("rdl.Symbol", "dec.ReadOptionalSymbol()", gtype)))
+			} else {
+				gen.emit(fmt.Sprintf("%s%s = %s\n", indent, target, convert("string", "dec.ReadOptionalString()", gtype)))
+			}
+		} else if gtype[1:] == base {
+			gen.emit(fmt.Sprintf("%s%s = dec.ReadOptional%s()\n", indent, target, name))
+		} else {
+			gen.emit(fmt.Sprintf("%sif v := dec.ReadOptional%s(); v != nil {\n", indent, name))
+			gen.emit(fmt.Sprintf("%s\tx := %s(*v)\n", indent, gtype[1:]))
+			gen.emit(fmt.Sprintf("%s\t%s = &x\n", indent, target))
+			gen.emit(indent + "}\n")
+		}
+		return
+	}
+	switch bt {
+	case rdl.BaseTypeTimestamp, rdl.BaseTypeUUID, rdl.BaseTypeBytes:
+		gen.emit(fmt.Sprintf("%s%s = dec.ReadOptional%s()\n", indent, target, bt))
+		return
+	case rdl.BaseTypeArray, rdl.BaseTypeMap:
+		if sigVar != "" {
+			gen.emit(fmt.Sprintf("%sif !dec.ReadNull() {\n", indent))
+			gen.emit(fmt.Sprintf("%s\tok, err := dec.ExpectType(%s, &%s)\n", indent, sigVar, target))
+			gen.emit(indent + "\tif err != nil {\n")
+			gen.emit(indent + "\t\treturn err\n")
+			gen.emit(indent + "\t}\n")
+			gen.emit(indent + "\tif ok {\n")
+			gen.emitTBinRead(indent+"\t\t", target, f.Type, f.Items, f.Keys, true, 1)
+			gen.emit(indent + "\t}\n")
+			gen.emit(indent + "}\n")
+			return
+		}
+	}
+	if bt == rdl.BaseTypeAny || (bt == rdl.BaseTypeStruct && !gen.isUserStruct(f.Type)) || !gen.hasTBinMethods(t) {
+		gen.emit(fmt.Sprintf("%sif err := dec.Decode(&%s); err != nil {\n", indent, target))
+		gen.emit(indent + "\treturn err\n")
+		gen.emit(indent + "}\n")
+		return
+	}
+	gen.emit(fmt.Sprintf("%sif !dec.ReadNull() {\n", indent))
+	if strings.HasPrefix(gtype, "*") {
+		gen.emit(fmt.Sprintf("%s\t%s = new(%s)\n", indent, target, gtype[1:]))
+	}
+	gen.emit(fmt.Sprintf("%s\tif err := %s.UnmarshalTBin(dec); err != nil {\n", indent, target))
+	gen.emit(indent + "\t\treturn err\n")
+	gen.emit(indent + "\t}\n")
+	gen.emit(indent + "}\n")
+}
+
+// recursiveTypes returns the types of the schema that are recursive, or refer to a recursive type. Their
+// signatures would be infinite.
+func recursiveTypes(schema *rdl.Schema) map[rdl.TypeName]bool {
+	graph := rdl.NewTypeGraph(schema)
+	recursive := make(map[rdl.TypeName]bool)
+	var mark func(name rdl.TypeName)
+	mark = func(name rdl.TypeName) {
+		if !recursive[name] {
+			recursive[name] = true
+			for _, n := range graph.Dependents(name) {
+				mark(n)
+			}
+		}
+	}
+	for _, t := range schema.Types {
+		name, _, _ := rdl.TypeInfo(t)
+		if recursive[name] {
+			continue
+		}
+		var deps []rdl.TypeName
+		for _, dep := range graph.Dependencies(name) {
+			deps = append(deps, dep.Type)
+		}
+		for _, n := range graph.Reachable(deps...) {
+			if n == name {
+				mark(name)
+				break
+			}
+		}
+	}
+	return recursive
+}
+
+// emitTBinReuse emits the initialization of a slice or map being decoded: an existing one is reused,
+// a slice truncated to the value given, a map cleared.
+func (gen *modelGenerator) emitTBinReuse(indent, target, create, truncated string) {
+	if isElementVar(target) {
+		//a new variable for an element of a slice or map, there is nothing to reuse
+		gen.emit(fmt.Sprintf("%s%s = %s\n", indent, target, create))
+		return
+	}
+	gen.emit(fmt.Sprintf("%sif %s == nil {\n", indent, target))
+	gen.emit(fmt.Sprintf("%s\t%s = %s\n", indent, target, create))
+	gen.emit(indent + "} else {\n")
+	if truncated != "" {
+		gen.emit(fmt.Sprintf("%s\t%s = %s\n", indent, target, truncated))
+	} else {
+		gen.emit(fmt.Sprintf("%s\tclear(%s)\n", indent, target))
+	}
+	gen.emit(indent + "}\n")
+}
+
+func (gen *modelGenerator) emitTBinMarshaller(name, receiver, sig string) {
+	gen.emit(fmt.Sprintf("\nvar %s = %s\n", tbinSignatureName(name), sig))
+	gen.emit(fmt.Sprintf("\n//\n// MarshalTBin is defined for TBin encoding of a %s without reflection\n//\n", name))
+	gen.emit(fmt.Sprintf("func (%s *%s) MarshalTBin(enc *tbin.Encoder) error {\n", receiver, name))
+	gen.emit(fmt.Sprintf("\tenc.WriteType(%s)\n", tbinSignatureName(name)))
+}
+
+func (gen *modelGenerator) emitTBinUnmarshaller(name, receiver string) {
+	gen.emit(fmt.Sprintf("\n//\n// UnmarshalTBin is defined for TBin decoding of a %s without reflection\n//\n", name))
+	gen.emit(fmt.Sprintf("func (%s *%s) UnmarshalTBin(dec *tbin.Decoder) error {\n", receiver, name))
+	gen.emit(fmt.Sprintf("\tif ok, err := dec.ExpectType(%s, %s); !ok {\n", tbinSignatureName(name), receiver))
+	gen.emit("\t\treturn err\n")
+	gen.emit("\t}\n")
+}
+
+// emitTBin emits the signature var and the MarshalTBin and UnmarshalTBin methods for the type.
+func (gen *modelGenerator) emitTBin(t *rdl.Type) {
+	if !gen.tbin || gen.err != nil || !gen.tbinEncodable(t) {
+		return
+	}
+	tName, _, _ := rdl.TypeInfo(t)
+	name := string(goTypeName(tName))
+	if gen.recursive[tName] {
+		gen.emit(fmt.Sprintf("\n//\n// %s is recursive, or refers to a recursive type, which has no finite TBin signature.\n// It has no MarshalTBin and UnmarshalTBin methods, and the TBin encoder fails to encode it.\n//\n", name))
+		return
+	}
+	switch t.Variant {
+	case rdl.TypeVariantStructTypeDef:
+		gen.emitStructTBin(t, name)
+	case rdl.TypeVariantUnionTypeDef:
+		gen.emitUnionTBin(t.UnionTypeDef, name)
+	case rdl.TypeVariantEnumTypeDef:
+		var syms []string
+		for _, elem := range t.EnumTypeDef.Elements {
+			syms = append(syms, fmt.Sprintf("%q", elem.Symbol))
+		}
+		gen.emitTBinMarshaller(name, "e", "tbin.Enum("+strings.Join(syms, ", ")+")")
+		gen.emit("\treturn enc.WriteInt32(int32(*e))\n")
+		gen.emit("}\n")
+		gen.emitTBinUnmarshaller(name, "e")
+		gen.emit(fmt.Sprintf("\t*e = %s(dec.ReadInt32())\n", name))
+		gen.emit("\treturn dec.Error()\n")
+		gen.emit("}\n")
+	case rdl.TypeVariantArrayTypeDef, rdl.TypeVariantMapTypeDef:
+		gen.emitTBinMarshaller(name, "self", gen.tbinSignature(rdl.TypeRef(tName), "", "", false, false))
+		gen.emitTBinWrite("\t", "*self", rdl.TypeRef(tName), "", "", false, 1)
+		gen.emit("\treturn enc.Error()\n")
+		gen.emit("}\n")
+		gen.emitTBinUnmarshaller(name, "self")
+		gen.emitTBinRead("\t", "*self", rdl.TypeRef(tName), "", "", false, 1)
+		gen.emit("\treturn dec.Error()\n")
+		gen.emit("}\n")
+	}
+}
+
+func (gen *modelGenerator) emitStructTBin(t *rdl.Type, name string) {
+	flattened := genutil.FlattenedFields(gen.registry, t)
+	var fields []string
+	for _, f := range flattened {
+		jsonName := string(f.Name)
+		if ext, ok := f.Annotations["x_json_name"]; ok {
+			jsonName = ext
+		}
+		fsig := gen.tbinSignature(f.Type, f.Items, f.Keys, f.Optional, true)
+		fields = append(fields, fmt.Sprintf("\ttbin.Field(%q, %s, %v),\n", jsonName, fsig, f.Optional))
+		if sigVar := gen.optionalSignatureVar(rdl.TypeName(name), f); sigVar != "" {
+			gen.emit(fmt.Sprintf("\nvar %s = %s\n", sigVar, gen.tbinSignature(f.Type, f.Items, f.Keys, false, true)))
+		}
+	}
+	gen.emitTBinMarshaller(name, "self", "tbin.Struct(\n"+strings.Join(fields, "")+")")
+	gen.emit("\treturn self.writeTBin(enc)\n")
+	gen.emit("}\n\n")
+	gen.emit(fmt.Sprintf("func (self *%s) writeTBin(enc *tbin.Encoder) error {\n", name))
+	gen.emit("\tif self == nil {\n")
+	gen.emit(fmt.Sprintf("\t\treturn fmt.Errorf(\"Cannot marshal null %s\")\n", name))
+	gen.emit("\t}\n")
+	for _, f := range flattened {
+		expr := "self." + capitalize(string(f.Name))
+		if f.Optional {
+			gen.emitTBinWriteOptional("\t", expr, f, gen.optionalSignatureVar(rdl.TypeName(name), f))
+		} else {
+			gen.emitTBinWrite("\t", expr, f.Type, f.Items, f.Keys, true, 1)
+		}
+	}
+	gen.emit("\treturn enc.Error()\n")
+	gen.emit("}\n")
+	gen.emitTBinUnmarshaller(name, "self")
+	gen.emit("\treturn self.readTBin(dec)\n")
+	gen.emit("}\n\n")
+	gen.emit(fmt.Sprintf("func (self *%s) readTBin(dec *tbin.Decoder) error {\n", name))
+	for _, f := range flattened {
+		target := "self." + capitalize(string(f.Name))
+		if f.Optional {
+			gen.emitTBinReadOptional("\t", target, f, gen.optionalSignatureVar(rdl.TypeName(name), f))
+		} else {
+			gen.emitTBinRead("\t", target, f.Type, f.Items, f.Keys, true, 1)
+		}
+	}
+	gen.emit("\treturn dec.Error()\n")
+	gen.emit("}\n")
+}
+
+func (gen *modelGenerator) emitUnionTBin(ut *rdl.UnionTypeDef, name string) {
+	var variants []string
+	for _, v := range ut.Variants {
+		variants = append(variants, gen.tbinSignature(v, "", "", true, true))
+	}
+	gen.emitTBinMarshaller(name, "p", "tbin.Union("+strings.Join(variants, ", ")+")")
+	gen.emit("\treturn p.writeTBin(enc)\n")
+	gen.emit("}\n\n")
+	gen.emit(fmt.Sprintf("func (p *%s) writeTBin(enc *tbin.Encoder) error {\n", name))
+	gen.emit("\tif p == nil {\n")
+	gen.emit(fmt.Sprintf("\t\treturn fmt.Errorf(\"Cannot marshal null %s\")\n", name))
+	gen.emit("\t}\n")
+	gen.emit("\tenc.WriteUnsigned(int(p.Variant))\n")
+	gen.emit("\tswitch p.Variant {\n")
+	for _, v := range ut.Variants {
+		uV := capitalize(string(v))
+		gen.emit(fmt.Sprintf("\tcase %sVariant%s:\n", name, uV))
+		expr := "p." + uV
+		if gen.isPointerVariant(v) {
+			gen.emit(fmt.Sprintf("\t\tif %s == nil {\n", expr))
+			gen.emit(fmt.Sprintf("\t\t\treturn fmt.Errorf(\"Cannot marshal null variant %s of %s\")\n", uV, name))
+			gen.emit("\t\t}\n")
+			expr = "*" + expr
+		}
+		gen.emitTBinWrite("\t\t", expr, v, "", "", true, 1)
+	}
+	gen.emit("\tdefault:\n")
+	gen.emit(fmt.Sprintf("\t\treturn fmt.Errorf(\"Cannot marshal uninitialized union type %s\")\n", name))
+	gen.emit("\t}\n")
+	gen.emit("\treturn enc.Error()\n")
+	gen.emit("}\n")
+	gen.emitTBinUnmarshaller(name, "p")
+	gen.emit("\treturn p.readTBin(dec)\n")
+	gen.emit("}\n\n")
+	gen.emit(fmt.Sprintf("func (p *%s) readTBin(dec *tbin.Decoder) error {\n", name))
+	gen.emit(fmt.Sprintf("\tp.Variant = %sVariantTag(dec.ReadUnsigned())\n", name))
+	gen.emit("\tswitch p.Variant {\n")
+	for _, v := range ut.Variants {
+		uV := capitalize(string(v))
+		gen.emit(fmt.Sprintf("\tcase %sVariant%s:\n", name, uV))
+		if gen.isPointerVariant(v) {
+			gen.emit(fmt.Sprintf("\t\tvar v %s\n", goType(gen.registry, v, false, "", "", gen.precise, true)))
+			gen.emitTBinRead("\t\t", "v", v, "", "", true, 1)
+			gen.emit(fmt.Sprintf("\t\tp.%s = &v\n", uV))
+		} else {
+			gen.emitTBinRead("\t\t", "p."+uV, v, "", "", true, 1)
+		}
+	}
+	gen.emit("\tdefault:\n")
+	gen.emit("\t\tif dec.Error() == nil {\n")
+	gen.emit(fmt.Sprintf("\t\t\treturn fmt.Errorf(\"Bad variant %%d for union type %s\", p.Variant)\n", name))
+	gen.emit("\t\t}\n")
+	gen.emit("\t}\n")
+	gen.emit("\treturn dec.Error()\n")
+	gen.emit("}\n")
+}
+
+// isPointerVariant is true if the union variant is a pointer to a value that is not itself a pointer, i.e.
+// anything but a struct or union.
+func (gen *modelGenerator) isPointerVariant(v rdl.TypeRef) bool {
+	gtype := goType(gen.registry, v, true, "", "", gen.precise, true)
+	if !strings.HasPrefix(gtype, "*") {
+		return false
+	}
+	switch gen.registry.FindBaseType(v) {
+	case rdl.BaseTypeStruct, rdl.BaseTypeUnion:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,87 @@
+// Copyright 2015 Yahoo Inc.
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package graph
+
+import (
+	"strings"
+)
+
+// DOT renders the graph in the Graphviz DOT language. Types are drawn as records listing their fields or
+// enum elements, and resources as rounded boxes. Inheritance edges have a hollow arrowhead, union
+// membership edges are dashed, and edges from resources are bold.
+func (g *Graph) DOT() string {
+	var buf strings.Builder
+	buf.WriteString("digraph " + dotQuote(g.Name) + " {\n")
+	buf.WriteString("  rankdir=LR;\n")
+	buf.WriteString("  node [shape=record, fontname=\"Helvetica\", fontsize=10];\n")
+	buf.WriteString("  edge [fontname=\"Helvetica\", fontsize=9];\n")
+	for _, n := range g.Nodes {
+		buf.WriteString("  " + dotQuote(n.ID) + " [" + dotNodeAttributes(n) + "];\n")
+	}
+	for _, e := range g.Edges {
+		buf.WriteString("  " + dotQuote(e.From) + " -> " + dotQuote(e.To))
+		if attrs := dotEdgeAttributes(e); attrs != "" {
+			buf.WriteString(" [" + attrs + "]")
+		}
+		buf.WriteString(";\n")
+	}
+	buf.WriteString("}\n")
+	return buf.String()
+}
+
+func dotNodeAttributes(n *Node) string {
+	switch n.Kind {
+	case NodeResource:
+		return "shape=box, style=rounded, label=" + dotQuote(n.Label)
+	case NodeBaseType:
+		return "shape=plaintext, label=" + dotQuote(n.Label)
+	}
+	label := dotRecordEscape(n.Label) + "\\n«" + n.BaseType.String() + "»"
+	if len(n.Members) > 0 {
+		label += "|"
+		for _, m := range n.Members {
+			s := m.Name
+			if m.Type != "" {
+				s += " : " + m.Type + optionalMark(m)
+			}
+			label += dotRecordEscape(s) + "\\l"
+		}
+	}
+	return "label=" + dotQuote("{"+label+"}")
+}
+
+func dotEdgeAttributes(e *Edge) string {
+	var attrs []string
+	switch e.Kind {
+	case EdgeSupertype:
+		attrs = append(attrs, "arrowhead=empty")
+	case EdgeVariant:
+		attrs = append(attrs, "style=dashed")
+	case EdgeResourceType, EdgeResourceInput, EdgeResourceOutput, EdgeResourceException:
+		attrs = append(attrs, "style=bold")
+	}
+	if label := edgeLabel(e); label != "" {
+		attrs = append(attrs, "label="+dotQuote(label))
+	}
+	return strings.Join(attrs, ", ")
+}
+
+// dotRecordEscape escapes the characters that are structural in a record label.
+func dotRecordEscape(s string) string {
+	var buf strings.Builder
+	for _, c := range s {
+		switch c {
+		case '{', '}', '|', '<', '>', '\\':
+			buf.WriteRune('\\')
+		}
+		buf.WriteRune(c)
+	}
+	return buf.String()
+}
+
+// dotQuote makes a DOT quoted string. Unlike Go, DOT only escapes the quote, leaving backslash sequences
+// such as \l to Graphviz.
+func dotQuote(s string) string {
+	return "\"" + strings.ReplaceAll(s, "\"", "\\\"") + "\""
+}
@@ -0,0 +1,322 @@
+// Copyright 2015 Yahoo Inc.
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+// Package graph renders the types and resources of an RDL schema as a Graphviz DOT or Mermaid class diagram.
+package graph
+
+import (
+	"fmt"
+	"sort"
+
+	genutil "github.com/ardielle/ardielle-go/gen"
+	"github.com/ardielle/ardielle-go/rdl"
+)
+
+type NodeKind int
+
+const (
+	_ NodeKind = iota
+	NodeType
+	NodeBaseType
+	NodeResource
+)
+
+type EdgeKind int
+
+const (
+	_ EdgeKind = iota
+	EdgeSupertype
+	EdgeField
+	EdgeItems
+	EdgeKeys
+	EdgeVariant
+	EdgeResourceType
+	EdgeResourceInput
+	EdgeResourceOutput
+	EdgeResourceException
+)
+
+// Member is a line in the body of a node: a struct field or an enum element.
+type Member struct {
+	Name     string
+	Type     string
+	Optional bool
+}
+
+// Node is a user type, a base type (unless collapsed), or a resource.
+type Node struct {
+	ID       string
+	Label    string
+	Kind     NodeKind
+	BaseType rdl.BaseType
+	Comment  string
+	Members  []*Member
+}
+
+// Edge connects two nodes by ID. The Label is the field, param or status the edge stands for, if any.
+type Edge struct {
+	From  string
+	To    string
+	Kind  EdgeKind
+	Label string
+}
+
+// Graph is the diagram model, independent of the output format.
+type Graph struct {
+	Name  string
+	Nodes []*Node
+	Edges []*Edge
+	nodes map[string]*Node
+}
+
+// Options control what goes into the graph. Resources selects resources by name, or by "METHOD /path"
+// when unnamed; only the selected resources and the types they need are included. An empty list selects all.
+type Options struct {
+	CollapseBaseTypes bool
+	Resources         []string
+}
+
+type GeneratorParams struct {
+	Outdir  string
+	Banner  string
+	Format  string // "dot" (the default) or "mermaid"
+	Options Options
+}
+
+// Generate writes the diagram for the schema to the output directory, as <name>.dot or <name>.mmd.
+func Generate(schema *rdl.Schema, params *GeneratorParams) error {
+	g, err := Build(schema, &params.Options)
+	if err != nil {
+		return err
+	}
+	ext := ".dot"
+	render := g.DOT
+	switch params.Format {
+	case "", "dot":
+	case "mermaid":
+		ext = ".mmd"
+		render = g.Mermaid
+	default:
+		return fmt.Errorf("unsupported graph format: %q", params.Format)
+	}
+	out, file, _, err := genutil.OutputWriter(params.Outdir, string(schema.Name), ext)
+	if err != nil {
+		return err
+	}
+	if file != nil {
+		defer file.Close()
+	}
+	if params.Banner != "" {
+		comment := "// "
+		if ext == ".mmd" {
+			comment = "%% "
+		}
+		out.WriteString(comment + "Code generated by " + params.Banner + ". DO NOT EDIT.\n")
+	}
+	_, err = out.WriteString(render())
+	if err != nil {
+		return err
+	}
+	return out.Flush()
+}
+
+// ResourceID returns the node ID of a resource, which is also the name the Resources option matches.
+func ResourceID(r *rdl.Resource) string {
+	if r.Name != "" {
+		return string(r.Name)
+	}
+	return r.Method + " " + r.Path
+}
+
+// Build creates the graph model for the schema.
+func Build(schema *rdl.Schema, opts *Options) (*Graph, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	b := &builder{
+		registry: rdl.NewTypeRegistry(schema),
+		opts:     opts,
+		graph:    &Graph{Name: string(schema.Name), nodes: make(map[string]*Node)},
+	}
+	resources := schema.Resources
+	types := schema.Types
+	if len(opts.Resources) > 0 {
+		selected := make(map[string]bool)
+		for _, name := range opts.Resources {
+			selected[name] = true
+		}
+		resources = nil
+		for _, r := range schema.Resources {
+			if selected[ResourceID(r)] {
+				resources = append(resources, r)
+				delete(selected, ResourceID(r))
+			}
+		}
+		for name := range selected {
+			return nil, fmt.Errorf("no such resource: %q", name)
+		}
+		needed := make(map[rdl.TypeName]bool)
+		for _, n := range rdl.NewTypeGraph(schema).ResourceClosure(resources...) {
+			needed[n] = true
+		}
+		types = nil
+		for _, t := range schema.Types {
+			if tName, _, _ := rdl.TypeInfo(t); needed[tName] {
+				types = append(types, t)
+			}
+		}
+	}
+	for _, t := range types {
+		b.addType(t)
+	}
+	for _, t := range types {
+		b.addTypeEdges(t)
+	}
+	for _, r := range resources {
+		b.addResource(r)
+	}
+	return b.graph, nil
+}
+
+type builder struct {
+	registry rdl.TypeRegistry
+	opts     *Options
+	graph    *Graph
+}
+
+func (b *builder) addNode(n *Node) {
+	b.graph.nodes[n.ID] = n
+	b.graph.Nodes = append(b.graph.Nodes, n)
+}
+
+func (b *builder) addType(t *rdl.Type) {
+	tName, _, comment := rdl.TypeInfo(t)
+	n := &Node{ID: string(tName), Label: string(tName), Kind: NodeType, BaseType: b.registry.BaseType(t), Comment: comment}
+	switch t.Variant {
+	case rdl.TypeVariantStructTypeDef:
+		for _, f := range t.StructTypeDef.Fields {
+			n.Members = append(n.Members, &Member{Name: string(f.Name), Type: fieldTypeName(f), Optional: f.Optional})
+		}
+	case rdl.TypeVariantEnumTypeDef:
+		for _, el := range t.EnumTypeDef.Elements {
+			n.Members = append(n.Members, &Member{Name: string(el.Symbol)})
+		}
+	}
+	b.addNode(n)
+}
+
+func fieldTypeName(f *rdl.StructFieldDef) string {
+	switch {
+	case f.Keys != "":
+		return string(f.Type) + "<" + string(f.Keys) + "," + string(f.Items) + ">"
+	case f.Items != "":
+		return string(f.Type) + "<" + string(f.Items) + ">"
+	}
+	return string(f.Type)
+}
+
+// target returns the ID of the node a type reference resolves to, adding a base type node if needed. An
+// empty ID means the reference is to a collapsed base type, or to a type that is not in the graph.
+func (b *builder) target(ref rdl.TypeRef) string {
+	if ref == "" {
+		return ""
+	}
+	t := b.registry.FindType(ref)
+	if t == nil {
+		return ""
+	}
+	tName, _, _ := rdl.TypeInfo(t)
+	if t.Variant != rdl.TypeVariantBaseType {
+		if _, ok := b.graph.nodes[string(tName)]; ok {
+			return string(tName)
+		}
+		return ""
+	}
+	if b.opts.CollapseBaseTypes {
+		return ""
+	}
+	if _, ok := b.graph.nodes[string(tName)]; !ok {
+		b.addNode(&Node{ID: string(tName), Label: string(tName), Kind: NodeBaseType, BaseType: *t.BaseType})
+	}
+	return string(tName)
+}
+
+func (b *builder) addEdge(from string, ref rdl.TypeRef, kind EdgeKind, label string) {
+	if to := b.target(ref); to != "" && to != from {
+		b.graph.Edges = append(b.graph.Edges, &Edge{From: from, To: to, Kind: kind, Label: label})
+	}
+}
+
+func (b *builder) addTypeEdges(t *rdl.Type) {
+	tName, tSuper, _ := rdl.TypeInfo(t)
+	from := string(tName)
+	b.addEdge(from, tSuper, EdgeSupertype, "")
+	switch t.Variant {
+	case rdl.TypeVariantStructTypeDef:
+		for _, f := range t.StructTypeDef.Fields {
+			if f.Items == "" && f.Keys == "" {
+				b.addEdge(from, f.Type, EdgeField, string(f.Name))
+			}
+			b.addEdge(from, f.Keys, EdgeKeys, string(f.Name))
+			b.addEdge(from, f.Items, EdgeItems, string(f.Name))
+		}
+	case rdl.TypeVariantArrayTypeDef:
+		b.addEdge(from, t.ArrayTypeDef.Items, EdgeItems, "")
+	case rdl.TypeVariantMapTypeDef:
+		b.addEdge(from, t.MapTypeDef.Keys, EdgeKeys, "")
+		b.addEdge(from, t.MapTypeDef.Items, EdgeItems, "")
+	case rdl.TypeVariantUnionTypeDef:
+		for _, v := range t.UnionTypeDef.Variants {
+			b.addEdge(from, v, EdgeVariant, "")
+		}
+	}
+}
+
+func (b *builder) addResource(r *rdl.Resource) {
+	id := ResourceID(r)
+	b.addNode(&Node{ID: id, Label: r.Method + " " + r.Path, Kind: NodeResource, Comment: r.Comment})
+	b.addEdge(id, r.Type, EdgeResourceType, "")
+	for _, in := range r.Inputs {
+		b.addEdge(id, in.Type, EdgeResourceInput, string(in.Name))
+	}
+	for _, out := range r.Outputs {
+		b.addEdge(id, out.Type, EdgeResourceOutput, out.Header)
+	}
+	for _, sym := range sortedKeys(r.Exceptions) {
+		b.addEdge(id, rdl.TypeRef(r.Exceptions[sym].Type), EdgeResourceException, sym)
+	}
+}
+
+func sortedKeys(m map[string]*rdl.ExceptionDef) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// edgeLabel is the text shown on an edge: the field, param or status it stands for.
+func edgeLabel(e *Edge) string {
+	switch e.Kind {
+	case EdgeItems:
+		if e.Label == "" {
+			return "items"
+		}
+	case EdgeKeys:
+		if e.Label == "" {
+			return "keys"
+		}
+		return e.Label + " (keys)"
+	case EdgeResourceOutput:
+		return "out " + e.Label
+	}
+	return e.Label
+}
+
+func optionalMark(m *Member) string {
+	if m.Optional {
+		return " (optional)"
+	}
+	return ""
+}
@@ -0,0 +1,124 @@
+// Copyright 2015 Yahoo Inc.
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package graph
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ardielle/ardielle-go/rdl"
+)
+
+const graphTestRDL = `name shapes;
+type Id String (pattern="[a-z]+");
+type Base Struct { Id id; }
+type Circle Base { Float64 radius; }
+type Square Base { Float64 side; Map<String,Id> refs (optional); }
+type Shape Union<Circle,Square>;
+type Shapes Array<Shape>;
+resource Shapes GET "/shapes" {}
+resource Circle GET "/circles/{id}" (name=getCircle) {
+    Id id;
+}
+`
+
+func loadTestSchema(test *testing.T) *rdl.Schema {
+	path := filepath.Join(test.TempDir(), "shapes.rdl")
+	if err := os.WriteFile(path, []byte(graphTestRDL), 0644); err != nil {
+		test.Fatalf("Cannot write test schema: %v", err)
+	}
+	schema, err := rdl.ParseRDLFile(path, false, false, true)
+	if err != nil {
+		test.Fatalf("Cannot parse test schema: %v", err)
+	}
+	return schema
+}
+
+func hasEdge(g *Graph, from, to string, kind EdgeKind) bool {
+	for _, e := range g.Edges {
+		if e.From == from && e.To == to && e.Kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+func TestBuild(test *testing.T) {
+	schema := loadTestSchema(test)
+	g, err := Build(schema, &Options{CollapseBaseTypes: true})
+	if err != nil {
+		test.Fatalf("Cannot build graph: %v", err)
+	}
+	if len(g.Nodes) != 8 {
+		test.Errorf("Expected 8 nodes, found %d", len(g.Nodes))
+	}
+	for _, e := range []struct {
+		from, to string
+		kind     EdgeKind
+	}{
+		{"Circle", "Base", EdgeSupertype},
+		{"Base", "Id", EdgeField},
+		{"Square", "Id", EdgeItems},
+		{"Shape", "Square", EdgeVariant},
+		{"Shapes", "Shape", EdgeItems},
+		{"GET /shapes", "Shapes", EdgeResourceType},
+		{"getCircle", "Id", EdgeResourceInput},
+	} {
+		if !hasEdge(g, e.from, e.to, e.kind) {
+			test.Errorf("Missing edge %s -> %s", e.from, e.to)
+		}
+	}
+	if hasEdge(g, "Circle", "Float64", EdgeField) {
+		test.Errorf("Base type edges should be collapsed")
+	}
+
+	g, err = Build(schema, &Options{Resources: []string{"getCircle"}})
+	if err != nil {
+		test.Fatalf("Cannot build filtered graph: %v", err)
+	}
+	for _, n := range g.Nodes {
+		if n.ID == "Shapes" || n.ID == "Square" || n.ID == "GET /shapes" {
+			test.Errorf("Filtered graph should not contain %s", n.ID)
+		}
+	}
+	if !hasEdge(g, "Circle", "Float64", EdgeField) {
+		test.Errorf("Base type edges should be present when not collapsed")
+	}
+	if _, err = Build(schema, &Options{Resources: []string{"nonesuch"}}); err == nil {
+		test.Errorf("Expected an error for an unknown resource")
+	}
+}
+
+func TestRender(test *testing.T) {
+	g, err := Build(loadTestSchema(test), &Options{CollapseBaseTypes: true})
+	if err != nil {
+		test.Fatalf("Cannot build graph: %v", err)
+	}
+	dot := g.DOT()
+	for _, s := range []string{
+		`digraph "shapes" {`,
+		`"Circle" -> "Base" [arrowhead=empty];`,
+		`"Shape" -> "Circle" [style=dashed];`,
+		`"Square" [label="{Square\n«Struct»|side : Float64\lrefs : Map\<String,Id\> (optional)\l}"];`,
+	} {
+		if !strings.Contains(dot, s) {
+			test.Errorf("DOT output does not contain %s:\n%s", s, dot)
+		}
+	}
+	mermaid := g.Mermaid()
+	for _, s := range []string{
+		"classDiagram\n",
+		"  Base <|-- Circle\n",
+		"    +Map~String,Id~? refs\n",
+		"  Shape ..> Circle\n",
+		"  class GET__shapes[\"GET /shapes\"]\n  <<resource>> GET__shapes\n",
+		"  getCircle --> Id : id\n",
+	} {
+		if !strings.Contains(mermaid, s) {
+			test.Errorf("Mermaid output does not contain %q:\n%s", s, mermaid)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright 2015 Yahoo Inc.
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package graph
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Mermaid renders the graph as a Mermaid class diagram. Inheritance uses the <|-- relation, fields and
+// collection items use composition (*--), union membership uses dependency (..>), and resources, marked
+// with the <<resource>> stereotype, use association (-->). Optional fields have a "?" after their type.
+func (g *Graph) Mermaid() string {
+	ids := mermaidIDs(g)
+	var buf strings.Builder
+	buf.WriteString("classDiagram\n")
+	for _, n := range g.Nodes {
+		id := ids[n.ID]
+		if id != n.Label {
+			buf.WriteString("  class " + id + "[\"" + mermaidEscape(n.Label) + "\"]")
+		} else {
+			buf.WriteString("  class " + id)
+		}
+		if len(n.Members) > 0 {
+			buf.WriteString(" {\n")
+			for _, m := range n.Members {
+				if m.Type != "" {
+					typ := mermaidGenerics(m.Type)
+					if m.Optional {
+						typ += "?"
+					}
+					buf.WriteString("    +" + mermaidEscape(typ) + " " + m.Name + "\n")
+				} else {
+					buf.WriteString("    " + m.Name + "\n")
+				}
+			}
+			buf.WriteString("  }")
+		}
+		buf.WriteString("\n")
+		switch n.Kind {
+		case NodeResource:
+			buf.WriteString("  <<resource>> " + id + "\n")
+		case NodeType:
+			buf.WriteString("  <<" + n.BaseType.String() + ">> " + id + "\n")
+		}
+	}
+	for _, e := range g.Edges {
+		from, to := ids[e.From], ids[e.To]
+		var rel string
+		switch e.Kind {
+		case EdgeSupertype:
+			rel = to + " <|-- " + from
+		case EdgeField, EdgeItems, EdgeKeys:
+			rel = from + " *-- " + to
+		case EdgeVariant:
+			rel = from + " ..> " + to
+		default:
+			rel = from + " --> " + to
+		}
+		if label := edgeLabel(e); label != "" {
+			rel += " : " + mermaidEscape(label)
+		}
+		buf.WriteString("  " + rel + "\n")
+	}
+	return buf.String()
+}
+
+// mermaidIDs maps node IDs to Mermaid class names, which are limited to letters, digits and underscores.
+func mermaidIDs(g *Graph) map[string]string {
+	ids := make(map[string]string)
+	used := make(map[string]bool)
+	for _, n := range g.Nodes {
+		id := strings.Map(func(c rune) rune {
+			if c == '_' || unicode.IsLetter(c) || unicode.IsDigit(c) {
+				return c
+			}
+			return '_'
+		}, n.ID)
+		for used[id] {
+			id += "_"
+		}
+		used[id] = true
+		ids[n.ID] = id
+	}
+	return ids
+}
+
+// mermaidGenerics converts Array<Foo> to Mermaid's Array~Foo~ notation.
+func mermaidGenerics(s string) string {
+	return strings.NewReplacer("<", "~", ">", "~").Replace(s)
+}
+
+func mermaidEscape(s string) string {
+	return strings.NewReplacer("\"", "#quot;", "{", "#123;", "}", "#125;").Replace(s)
+}
@@ -0,0 +1,463 @@
+// Copyright 2015 Yahoo Inc.
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+// Package htmldoc generates a static, cross-linked HTML documentation site for an RDL schema.
+package htmldoc
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	genutil "github.com/ardielle/ardielle-go/gen"
+	"github.com/ardielle/ardielle-go/rdl"
+)
+
+type GeneratorParams struct {
+	Outdir string
+	Banner string
+}
+
+// Generate writes the site for the schema into the output directory: index.html, resources.html, style.css,
+// and a page for each type in the types subdirectory.
+func Generate(schema *rdl.Schema, params *GeneratorParams) error {
+	if params.Outdir == "" {
+		return fmt.Errorf("htmldoc: an output directory is required")
+	}
+	err := os.MkdirAll(filepath.Join(params.Outdir, "types"), 0755)
+	if err != nil {
+		return err
+	}
+	gen := &docGenerator{
+		registry: rdl.NewTypeRegistry(schema),
+		graph:    rdl.NewTypeGraph(schema),
+		schema:   schema,
+		banner:   params.Banner,
+		usedBy:   make(map[rdl.TypeName][]*rdl.Resource),
+	}
+	for _, r := range schema.Resources {
+		for _, n := range gen.graph.ResourceClosure(r) {
+			gen.usedBy[n] = append(gen.usedBy[n], r)
+		}
+	}
+	err = writeFile(params.Outdir, "style", ".css", func(out *bufio.Writer) error {
+		_, err := out.WriteString(styleSheet)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	err = gen.writePage(params.Outdir, "index", "", gen.emitIndex)
+	if err != nil {
+		return err
+	}
+	err = gen.writePage(params.Outdir, "resources", "", gen.emitResources)
+	if err != nil {
+		return err
+	}
+	typesDir := filepath.Join(params.Outdir, "types")
+	for _, t := range schema.Types {
+		t := t
+		tName, _, _ := rdl.TypeInfo(t)
+		err = gen.writePage(typesDir, string(tName), "../", func() { gen.emitType(t) })
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+const styleSheet = `body { font-family: sans-serif; margin: 2em; color: #222; }
+nav { margin-bottom: 1em; }
+table { border-collapse: collapse; margin: 0.5em 0 1em 0; }
+th, td { border: 1px solid #ccc; padding: 0.25em 0.5em; text-align: left; vertical-align: top; }
+th { background: #f0f0f0; }
+code, pre { font-family: monospace; }
+pre { background: #f6f6f6; padding: 0.5em; }
+.method { font-weight: bold; }
+.resource { border-top: 1px solid #ccc; padding-top: 0.5em; }
+`
+
+func writeFile(dir, name, ext string, emit func(out *bufio.Writer) error) error {
+	out, file, _, err := genutil.OutputWriter(dir, name, ext)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	err = emit(out)
+	if err != nil {
+		return err
+	}
+	return out.Flush()
+}
+
+type docGenerator struct {
+	registry rdl.TypeRegistry
+	graph    *rdl.TypeGraph
+	schema   *rdl.Schema
+	banner   string
+	usedBy   map[rdl.TypeName][]*rdl.Resource
+	writer   *bufio.Writer
+	root     string
+	err      error
+}
+
+func (gen *docGenerator) emit(s string) {
+	if gen.err == nil {
+		_, gen.err = gen.writer.WriteString(s)
+	}
+}
+
+func (gen *docGenerator) emitf(format string, args ...interface{}) {
+	gen.emit(fmt.Sprintf(format, args...))
+}
+
+// writePage writes one HTML page. The root is the relative path from the page back to the top of the site.
+func (gen *docGenerator) writePage(dir, name, root string, body func()) error {
+	return writeFile(dir, name, ".html", func(out *bufio.Writer) error {
+		gen.writer = out
+		gen.root = root
+		gen.err = nil
+		title := gen.schemaTitle()
+		if name != "index" {
+			title = name + " - " + title
+		}
+		gen.emit("<!DOCTYPE html>\n")
+		if gen.banner != "" {
+			gen.emitf("<!-- Code generated by %s. DO NOT EDIT. -->\n", esc(gen.banner))
+		}
+		gen.emitf("<html>\n<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n", esc(title))
+		gen.emitf("<link rel=\"stylesheet\" href=\"%sstyle.css\">\n</head>\n<body>\n", root)
+		gen.emitf("<nav><a href=\"%sindex.html\">%s</a> | <a href=\"%sresources.html\">Resources</a></nav>\n", root, esc(gen.schemaTitle()), root)
+		body()
+		gen.emit("</body>\n</html>\n")
+		return gen.err
+	})
+}
+
+func (gen *docGenerator) schemaTitle() string {
+	if gen.schema.Name != "" {
+		return string(gen.schema.Name)
+	}
+	return "schema"
+}
+
+func esc(s string) string {
+	return html.EscapeString(s)
+}
+
+// codeTag formats literal code.
+func codeTag(s string) string {
+	return "<code>" + esc(s) + "</code>"
+}
+
+// emitComment renders a comment as paragraphs, separated by blank lines in the source.
+func (gen *docGenerator) emitComment(comment string) {
+	for _, para := range strings.Split(strings.TrimSpace(comment), "\n\n") {
+		if para = strings.TrimSpace(para); para != "" {
+			gen.emitf("<p>%s</p>\n", esc(para))
+		}
+	}
+}
+
+func summary(comment string) string {
+	comment = strings.TrimSpace(comment)
+	if i := strings.Index(comment, "\n"); i >= 0 {
+		comment = comment[:i]
+	}
+	return esc(comment)
+}
+
+func (gen *docGenerator) typeHref(name rdl.TypeName) string {
+	return gen.root + "types/" + esc(string(name)) + ".html"
+}
+
+// resourceAnchor is the resource name, or else its method and path as dash-separated words, i.e. "get-contacts-id".
+func resourceAnchor(r *rdl.Resource) string {
+	if r.Name != "" {
+		return string(r.Name)
+	}
+	words := strings.FieldsFunc(strings.ToLower(r.Method)+" "+r.Path, func(ch rune) bool {
+		return !(ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch >= '0' && ch <= '9')
+	})
+	return strings.Join(words, "-")
+}
+
+func (gen *docGenerator) resourceHref(r *rdl.Resource) string {
+	return gen.root + "resources.html#" + esc(resourceAnchor(r))
+}
+
+// typeLink links a type reference to the page of the user type it resolves to. Base types are not linked.
+func (gen *docGenerator) typeLink(ref rdl.TypeRef) string {
+	t := gen.registry.FindType(ref)
+	if t == nil || t.Variant == rdl.TypeVariantBaseType {
+		return "<code>" + esc(string(ref)) + "</code>"
+	}
+	tName, _, _ := rdl.TypeInfo(t)
+	return fmt.Sprintf("<a href=\"%s\"><code>%s</code></a>", gen.typeHref(tName), esc(string(ref)))
+}
+
+func (gen *docGenerator) fieldTypeLink(f *rdl.StructFieldDef) string {
+	switch {
+	case f.Keys != "":
+		return gen.typeLink(f.Type) + "&lt;" + gen.typeLink(f.Keys) + "," + gen.typeLink(f.Items) + "&gt;"
+	case f.Items != "":
+		return gen.typeLink(f.Type) + "&lt;" + gen.typeLink(f.Items) + "&gt;"
+	}
+	return gen.typeLink(f.Type)
+}
+
+func (gen *docGenerator) resourceLink(r *rdl.Resource) string {
+	return fmt.Sprintf("<a href=\"%s\"><span class=\"method\">%s</span> <code>%s</code></a>", gen.resourceHref(r), esc(r.Method), esc(r.Path))
+}
+
+func (gen *docGenerator) emitIndex() {
+	gen.emitf("<h1>%s</h1>\n", esc(gen.schemaTitle()))
+	if gen.schema.Namespace != "" || gen.schema.Version != nil {
+		gen.emit("<dl>\n")
+		if gen.schema.Namespace != "" {
+			gen.emitf("<dt>Namespace</dt><dd><code>%s</code></dd>\n", esc(string(gen.schema.Namespace)))
+		}
+		if gen.schema.Version != nil {
+			gen.emitf("<dt>Version</dt><dd>%d</dd>\n", *gen.schema.Version)
+		}
+		gen.emit("</dl>\n")
+	}
+	gen.emitComment(gen.schema.Comment)
+	if len(gen.schema.Resources) > 0 {
+		gen.emit("<h2>Resources</h2>\n<table>\n<tr><th>Resource</th><th>Type</th><th>Description</th></tr>\n")
+		for _, r := range gen.schema.Resources {
+			gen.emitf("<tr><td>%s</td><td>%s</td><td>%s</td></tr>\n", gen.resourceLink(r), gen.typeLink(r.Type), summary(r.Comment))
+		}
+		gen.emit("</table>\n")
+	}
+	if len(gen.schema.Types) > 0 {
+		gen.emit("<h2>Types</h2>\n<table>\n<tr><th>Type</th><th>Kind</th><th>Description</th></tr>\n")
+		for _, t := range gen.schema.Types {
+			tName, _, comment := rdl.TypeInfo(t)
+			gen.emitf("<tr><td>%s</td><td>%s</td><td>%s</td></tr>\n", gen.typeLink(rdl.TypeRef(tName)), gen.registry.BaseType(t), summary(comment))
+		}
+		gen.emit("</table>\n")
+	}
+}
+
+func (gen *docGenerator) emitType(t *rdl.Type) {
+	tName, tSuper, comment := rdl.TypeInfo(t)
+	gen.emitf("<h1>%s</h1>\n", esc(string(tName)))
+	gen.emit("<dl>\n")
+	gen.emitf("<dt>Kind</dt><dd>%s</dd>\n", gen.registry.BaseType(t))
+	if tSuper != "" && t.Variant != rdl.TypeVariantBaseType && rdl.TypeName(tSuper) != tName {
+		gen.emitf("<dt>Extends</dt><dd>%s</dd>\n", gen.typeLink(tSuper))
+	}
+	annotations := rdl.TypeAnnotations(t)
+	if from, ok := annotations["x_included_from"]; ok {
+		gen.emitf("<dt>Included from</dt><dd><code>%s</code></dd>\n", esc(from))
+	}
+	gen.emit("</dl>\n")
+	gen.emitComment(comment)
+	gen.emitConstraints(t)
+	switch t.Variant {
+	case rdl.TypeVariantStructTypeDef:
+		gen.emitFields(t)
+	case rdl.TypeVariantEnumTypeDef:
+		gen.emit("<h2>Elements</h2>\n<table>\n<tr><th>Symbol</th><th>Description</th></tr>\n")
+		for _, el := range t.EnumTypeDef.Elements {
+			gen.emitf("<tr><td><code>%s</code></td><td>%s</td></tr>\n", esc(string(el.Symbol)), esc(el.Comment))
+		}
+		gen.emit("</table>\n")
+	case rdl.TypeVariantUnionTypeDef:
+		gen.emit("<h2>Variants</h2>\n<ul>\n")
+		for _, v := range t.UnionTypeDef.Variants {
+			gen.emitf("<li>%s</li>\n", gen.typeLink(v))
+		}
+		gen.emit("</ul>\n")
+	}
+	gen.emitAnnotations(annotations)
+	if resources := gen.usedBy[tName]; len(resources) > 0 {
+		gen.emit("<h2>Used by resources</h2>\n<ul>\n")
+		for _, r := range resources {
+			gen.emitf("<li>%s</li>\n", gen.resourceLink(r))
+		}
+		gen.emit("</ul>\n")
+	}
+	if dependents := gen.graph.Dependents(tName); len(dependents) > 0 {
+		gen.emit("<h2>Referenced by</h2>\n<ul>\n")
+		for _, n := range dependents {
+			gen.emitf("<li>%s</li>\n", gen.typeLink(rdl.TypeRef(n)))
+		}
+		gen.emit("</ul>\n")
+	}
+	if example, err := rdl.ExampleValue(gen.registry, rdl.TypeRef(tName)); err == nil && example != nil {
+		if b, err := json.MarshalIndent(example, "", "  "); err == nil {
+			gen.emitf("<h2>Example</h2>\n<pre>%s</pre>\n", esc(string(b)))
+		}
+	}
+}
+
+func (gen *docGenerator) emitFields(t *rdl.Type) {
+	tName, _, _ := rdl.TypeInfo(t)
+	owner := make(map[*rdl.StructFieldDef]rdl.TypeName)
+	for st := t; st != nil && st.Variant == rdl.TypeVariantStructTypeDef; {
+		for _, f := range st.StructTypeDef.Fields {
+			owner[f] = st.StructTypeDef.Name
+		}
+		if st.StructTypeDef.Type == "Struct" {
+			break
+		}
+		st = gen.registry.FindType(st.StructTypeDef.Type)
+	}
+	fields := genutil.FlattenedFields(gen.registry, t)
+	if len(fields) == 0 {
+		return
+	}
+	gen.emit("<h2>Fields</h2>\n<table>\n<tr><th>Name</th><th>Type</th><th>Optional</th><th>Default</th><th>Description</th><th>Inherited from</th></tr>\n")
+	for _, f := range fields {
+		optional := ""
+		if f.Optional {
+			optional = "yes"
+		}
+		def := ""
+		if f.Default != nil {
+			def = "<code>" + esc(genutil.JSONString(f.Default)) + "</code>"
+		}
+		inherited := ""
+		if n := owner[f]; n != "" && n != tName {
+			inherited = gen.typeLink(rdl.TypeRef(n))
+		}
+		gen.emitf("<tr><td><code>%s</code></td><td>%s</td><td>%s</td><td>%s</td><td>%s</td><td>%s</td></tr>\n",
+			esc(string(f.Name)), gen.fieldTypeLink(f), optional, def, esc(f.Comment), inherited)
+	}
+	gen.emit("</table>\n")
+}
+
+func (gen *docGenerator) emitConstraints(t *rdl.Type) {
+	constraints := genutil.TypeConstraints(t, codeTag, gen.typeLink)
+	if len(constraints) == 0 {
+		return
+	}
+	gen.emit("<h2>Constraints</h2>\n<dl>\n")
+	for _, c := range constraints {
+		gen.emitf("<dt>%s</dt><dd>%s</dd>\n", c.Name, c.Value)
+	}
+	gen.emit("</dl>\n")
+}
+
+func (gen *docGenerator) emitAnnotations(annotations map[rdl.ExtendedAnnotation]string) {
+	var names []string
+	for name := range annotations {
+		if name != "x_included_from" {
+			names = append(names, string(name))
+		}
+	}
+	if len(names) == 0 {
+		return
+	}
+	sort.Strings(names)
+	gen.emit("<h2>Annotations</h2>\n<table>\n<tr><th>Name</th><th>Value</th></tr>\n")
+	for _, name := range names {
+		gen.emitf("<tr><td><code>%s</code></td><td><code>%s</code></td></tr>\n", esc(name), esc(annotations[rdl.ExtendedAnnotation(name)]))
+	}
+	gen.emit("</table>\n")
+}
+
+func (gen *docGenerator) emitResources() {
+	gen.emit("<h1>Resources</h1>\n")
+	if len(gen.schema.Resources) == 0 {
+		gen.emit("<p>This schema defines no resources.</p>\n")
+	}
+	for _, r := range gen.schema.Resources {
+		gen.emitResource(r)
+	}
+}
+
+func (gen *docGenerator) emitResource(r *rdl.Resource) {
+	gen.emitf("<div class=\"resource\" id=\"%s\">\n", esc(resourceAnchor(r)))
+	gen.emitf("<h2><span class=\"method\">%s</span> <code>%s</code></h2>\n", esc(r.Method), esc(r.Path))
+	gen.emitComment(r.Comment)
+	gen.emit("<dl>\n")
+	if r.Name != "" {
+		gen.emitf("<dt>Name</dt><dd><code>%s</code></dd>\n", esc(string(r.Name)))
+	}
+	gen.emitf("<dt>Type</dt><dd>%s</dd>\n", gen.typeLink(r.Type))
+	expected := r.Expected
+	if expected == "" {
+		expected = "OK"
+	}
+	statuses := []string{genutil.StatusText(expected, esc)}
+	for _, alt := range r.Alternatives {
+		statuses = append(statuses, genutil.StatusText(alt, esc))
+	}
+	gen.emitf("<dt>Expected</dt><dd>%s</dd>\n", strings.Join(statuses, ", "))
+	if r.Auth != nil {
+		switch {
+		case r.Auth.Action != "":
+			gen.emitf("<dt>Authorization</dt><dd>action <code>%s</code> on resource <code>%s</code>", esc(r.Auth.Action), esc(r.Auth.Resource))
+			if r.Auth.Domain != "" {
+				gen.emitf(" in domain <code>%s</code>", esc(r.Auth.Domain))
+			}
+			gen.emit("</dd>\n")
+		case r.Auth.Authenticate:
+			gen.emit("<dt>Authentication</dt><dd>required</dd>\n")
+		}
+	}
+	if r.Async != nil && *r.Async {
+		gen.emit("<dt>Async</dt><dd>yes</dd>\n")
+	}
+	if len(r.Consumes) > 0 {
+		gen.emitf("<dt>Consumes</dt><dd><code>%s</code></dd>\n", esc(strings.Join(r.Consumes, ", ")))
+	}
+	if len(r.Produces) > 0 {
+		gen.emitf("<dt>Produces</dt><dd><code>%s</code></dd>\n", esc(strings.Join(r.Produces, ", ")))
+	}
+	gen.emit("</dl>\n")
+	if len(r.Inputs) > 0 {
+		gen.emit("<h3>Inputs</h3>\n<table>\n<tr><th>Name</th><th>Type</th><th>In</th><th>Optional</th><th>Default</th><th>Description</th></tr>\n")
+		for _, in := range r.Inputs {
+			optional := ""
+			if in.Optional || in.Default != nil {
+				optional = "yes"
+			}
+			def := ""
+			if in.Default != nil {
+				def = "<code>" + esc(genutil.JSONString(in.Default)) + "</code>"
+			}
+			typ := gen.typeLink(in.Type)
+			if in.Pattern != "" {
+				typ += " <code>" + esc(in.Pattern) + "</code>"
+			}
+			gen.emitf("<tr><td><code>%s</code></td><td>%s</td><td>%s</td><td>%s</td><td>%s</td><td>%s</td></tr>\n",
+				esc(string(in.Name)), typ, genutil.InputLocation(in, codeTag), optional, def, esc(in.Comment))
+		}
+		gen.emit("</table>\n")
+	}
+	if len(r.Outputs) > 0 {
+		gen.emit("<h3>Outputs</h3>\n<table>\n<tr><th>Name</th><th>Header</th><th>Type</th><th>Description</th></tr>\n")
+		for _, out := range r.Outputs {
+			gen.emitf("<tr><td><code>%s</code></td><td><code>%s</code></td><td>%s</td><td>%s</td></tr>\n",
+				esc(string(out.Name)), esc(out.Header), gen.typeLink(out.Type), esc(out.Comment))
+		}
+		gen.emit("</table>\n")
+	}
+	if len(r.Exceptions) > 0 {
+		syms := make([]string, 0, len(r.Exceptions))
+		for sym := range r.Exceptions {
+			syms = append(syms, sym)
+		}
+		sort.Slice(syms, func(i, j int) bool {
+			return rdl.StatusCode(syms[i]) < rdl.StatusCode(syms[j])
+		})
+		gen.emit("<h3>Exceptions</h3>\n<table>\n<tr><th>Status</th><th>Symbol</th><th>Type</th><th>Description</th></tr>\n")
+		for _, sym := range syms {
+			e := r.Exceptions[sym]
+			gen.emitf("<tr><td>%s</td><td><code>%s</code></td><td>%s</td><td>%s</td></tr>\n",
+				genutil.StatusText(sym, esc), esc(sym), gen.typeLink(rdl.TypeRef(e.Type)), esc(e.Comment))
+		}
+		gen.emit("</table>\n")
+	}
+	gen.emit("</div>\n")
+}
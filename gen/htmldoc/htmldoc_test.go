@@ -0,0 +1,139 @@
+// Copyright 2015 Yahoo Inc.
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package htmldoc
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ardielle/ardielle-go/rdl"
+)
+
+const docTestRDL = `name contacts;
+version 2;
+
+// A short identifier
+type Id String (pattern="[a-z][a-z0-9]*", maxSize=32);
+type Age Int32 (min=0, max=150);
+type Kind Enum {
+    PERSON // an individual
+    COMPANY
+}
+// The common fields of every entry
+type Entry Struct {
+    Id id;
+    String note (optional, x_sensitive="true");
+}
+// A contact in the address book
+type Contact Entry {
+    Kind kind (default=PERSON);
+    Age age (optional);
+    Array<Id> friends (optional);
+}
+type Contacts Array<Contact>;
+
+// Fetch a single contact
+resource Contact GET "/contacts/{id}" (name=getContact) {
+    Id id; // the contact id
+    String tag (header="X-Tag", optional);
+    authenticate;
+    exceptions {
+        ResourceError NOT_FOUND;
+        ResourceError BAD_REQUEST;
+    }
+}
+resource Contacts GET "/contacts?limit={limit}" {
+    Int32 limit (default=10);
+    expected OK;
+}
+`
+
+func generateSite(test *testing.T) string {
+	dir := test.TempDir()
+	path := filepath.Join(dir, "contacts.rdl")
+	if err := os.WriteFile(path, []byte(docTestRDL), 0644); err != nil {
+		test.Fatalf("Cannot write test schema: %v", err)
+	}
+	schema, err := rdl.ParseRDLFile(path, false, false, true)
+	if err != nil {
+		test.Fatalf("Cannot parse test schema: %v", err)
+	}
+	outdir := filepath.Join(dir, "site")
+	if err := Generate(schema, &GeneratorParams{Outdir: outdir, Banner: "rdl test"}); err != nil {
+		test.Fatalf("Cannot generate docs: %v", err)
+	}
+	return outdir
+}
+
+func readPage(test *testing.T, path string) string {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		test.Fatalf("Missing page: %v", err)
+	}
+	return string(b)
+}
+
+func expectContains(test *testing.T, page, content string, expected ...string) {
+	for _, s := range expected {
+		if !strings.Contains(content, s) {
+			test.Errorf("Page %s does not contain %q", page, s)
+		}
+	}
+}
+
+func TestGenerate(test *testing.T) {
+	outdir := generateSite(test)
+	for _, name := range []string{"style.css", "index.html", "resources.html", "types/Id.html", "types/Contacts.html"} {
+		if _, err := os.Stat(filepath.Join(outdir, name)); err != nil {
+			test.Errorf("Expected file %s: %v", name, err)
+		}
+	}
+	expectContains(test, "index.html", readPage(test, filepath.Join(outdir, "index.html")),
+		"<!-- Code generated by rdl test. DO NOT EDIT. -->",
+		"<dt>Version</dt><dd>2</dd>",
+		`<a href="resources.html#getContact">`,
+		`<a href="types/Contact.html"><code>Contact</code></a>`,
+		"A contact in the address book")
+	expectContains(test, "types/Contact.html", readPage(test, filepath.Join(outdir, "types", "Contact.html")),
+		`<link rel="stylesheet" href="../style.css">`,
+		`<dt>Extends</dt><dd><a href="../types/Entry.html"><code>Entry</code></a></dd>`,
+		`<td><code>note</code></td><td><code>String</code></td><td>yes</td>`,
+		`<td><a href="../types/Entry.html"><code>Entry</code></a></td></tr>`,
+		`<td><code>&#34;PERSON&#34;</code></td>`,
+		`<code>Array</code>&lt;<a href="../types/Id.html"><code>Id</code></a>&gt;`,
+		`<a href="../resources.html#getContact">`,
+		`<a href="../types/Contacts.html"><code>Contacts</code></a>`,
+		"<h2>Example</h2>",
+		`&#34;kind&#34;: &#34;PERSON&#34;`)
+	expectContains(test, "types/Id.html", readPage(test, filepath.Join(outdir, "types", "Id.html")),
+		"<dt>Pattern</dt><dd><code>[a-z][a-z0-9]*</code></dd>",
+		"<dt>Maximum size</dt><dd>32</dd>")
+	expectContains(test, "types/Age.html", readPage(test, filepath.Join(outdir, "types", "Age.html")),
+		"<dt>Minimum</dt><dd>0</dd>", "<dt>Maximum</dt><dd>150</dd>")
+	expectContains(test, "types/Kind.html", readPage(test, filepath.Join(outdir, "types", "Kind.html")),
+		"<td><code>PERSON</code></td><td>an individual</td>")
+	expectContains(test, "types/Entry.html", readPage(test, filepath.Join(outdir, "types", "Entry.html")),
+		"<h2>Referenced by</h2>")
+	resources := readPage(test, filepath.Join(outdir, "resources.html"))
+	expectContains(test, "resources.html", resources,
+		`<div class="resource" id="getContact">`,
+		`<div class="resource" id="get-contacts">`,
+		"<dt>Authentication</dt><dd>required</dd>",
+		`<td><code>id</code></td><td><a href="types/Id.html"><code>Id</code></a></td><td>path</td>`,
+		"<td>header <code>X-Tag</code></td><td>yes</td>",
+		"<td>query <code>limit</code></td><td>yes</td><td><code>10</code></td>",
+		"<dt>Expected</dt><dd>200 OK</dd>",
+		"<td>400 Bad Request</td><td><code>BAD_REQUEST</code></td>")
+	if strings.Index(resources, "400 Bad Request") > strings.Index(resources, "404 Not Found") {
+		test.Errorf("Exceptions should be ordered by status code")
+	}
+}
+
+func TestGenerateRequiresOutdir(test *testing.T) {
+	if err := Generate(&rdl.Schema{}, &GeneratorParams{}); err == nil {
+		test.Errorf("Expected an error without an output directory")
+	}
+}
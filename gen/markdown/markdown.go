@@ -0,0 +1,283 @@
+// Copyright 2015 Yahoo Inc.
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+// Package markdown generates a README-style Markdown reference for an RDL schema.
+package markdown
+
+import (
+	"bufio"
+	"fmt"
+	"sort"
+	"strings"
+
+	genutil "github.com/ardielle/ardielle-go/gen"
+	"github.com/ardielle/ardielle-go/rdl"
+)
+
+type GeneratorParams struct {
+	Outdir string
+	Banner string
+}
+
+// Generate writes the reference for the schema as <name>.md in the output directory, or to stdout if no
+// output directory is given.
+func Generate(schema *rdl.Schema, params *GeneratorParams) error {
+	name := string(schema.Name)
+	if name == "" {
+		name = "README"
+	}
+	out, file, _, err := genutil.OutputWriter(params.Outdir, name, ".md")
+	if err != nil {
+		return err
+	}
+	if file != nil {
+		defer file.Close()
+	}
+	gen := &mdGenerator{
+		registry: rdl.NewTypeRegistry(schema),
+		schema:   schema,
+		writer:   out,
+	}
+	gen.emitSchema(params.Banner)
+	if gen.err != nil {
+		return gen.err
+	}
+	return out.Flush()
+}
+
+type mdGenerator struct {
+	registry rdl.TypeRegistry
+	schema   *rdl.Schema
+	writer   *bufio.Writer
+	err      error
+}
+
+func (gen *mdGenerator) emit(s string) {
+	if gen.err == nil {
+		_, gen.err = gen.writer.WriteString(s)
+	}
+}
+
+func (gen *mdGenerator) emitf(format string, args ...interface{}) {
+	gen.emit(fmt.Sprintf(format, args...))
+}
+
+// cell makes text safe for use in a table cell, where pipes and newlines would break the row.
+func cell(s string) string {
+	s = strings.Replace(strings.TrimSpace(s), "|", "\\|", -1)
+	return strings.Replace(s, "\n", "<br>", -1)
+}
+
+func code(s string) string {
+	if s == "" {
+		return ""
+	}
+	return "`" + s + "`"
+}
+
+// anchor is the heading anchor a code host generates for a type heading.
+func anchor(name rdl.TypeName) string {
+	return strings.ToLower(strings.Replace(string(name), ".", "", -1))
+}
+
+// typeLink links a type reference to its section. Base types are not linked.
+func (gen *mdGenerator) typeLink(ref rdl.TypeRef) string {
+	t := gen.registry.FindType(ref)
+	if t == nil || t.Variant == rdl.TypeVariantBaseType {
+		return code(string(ref))
+	}
+	tName, _, _ := rdl.TypeInfo(t)
+	return "[" + string(ref) + "](#" + anchor(tName) + ")"
+}
+
+func (gen *mdGenerator) fieldTypeLink(f *rdl.StructFieldDef) string {
+	switch {
+	case f.Keys != "":
+		return gen.typeLink(f.Type) + "&lt;" + gen.typeLink(f.Keys) + "," + gen.typeLink(f.Items) + "&gt;"
+	case f.Items != "":
+		return gen.typeLink(f.Type) + "&lt;" + gen.typeLink(f.Items) + "&gt;"
+	}
+	return gen.typeLink(f.Type)
+}
+
+func (gen *mdGenerator) emitSchema(banner string) {
+	if banner != "" {
+		gen.emitf("<!-- Code generated by %s. DO NOT EDIT. -->\n\n", banner)
+	}
+	title := string(gen.schema.Name)
+	if title == "" {
+		title = "Schema"
+	}
+	gen.emitf("# %s\n\n", title)
+	if gen.schema.Namespace != "" {
+		gen.emitf("Namespace: %s\n\n", code(string(gen.schema.Namespace)))
+	}
+	if gen.schema.Version != nil {
+		gen.emitf("Version: %d\n\n", *gen.schema.Version)
+	}
+	if gen.schema.Comment != "" {
+		gen.emitf("%s\n\n", strings.TrimSpace(gen.schema.Comment))
+	}
+	if len(gen.schema.Resources) > 0 {
+		gen.emit("## Resources\n\n")
+		for _, r := range gen.schema.Resources {
+			gen.emitResource(r)
+		}
+	}
+	origins, types := groupByOrigin(gen.schema.Types)
+	for _, origin := range origins {
+		if origin == "" {
+			gen.emit("## Types\n\n")
+		} else {
+			gen.emitf("## Types included from %s\n\n", code(origin))
+		}
+		for _, t := range types[origin] {
+			gen.emitType(t)
+		}
+	}
+}
+
+// groupByOrigin groups the types by the file they were included from, the types of the schema itself first.
+func groupByOrigin(types []*rdl.Type) ([]string, map[string][]*rdl.Type) {
+	var origins []string
+	grouped := make(map[string][]*rdl.Type)
+	for _, t := range types {
+		origin := rdl.TypeAnnotations(t)["x_included_from"]
+		if _, ok := grouped[origin]; !ok {
+			origins = append(origins, origin)
+		}
+		grouped[origin] = append(grouped[origin], t)
+	}
+	sort.SliceStable(origins, func(i, j int) bool {
+		return origins[i] == "" && origins[j] != ""
+	})
+	return origins, grouped
+}
+
+func (gen *mdGenerator) emitType(t *rdl.Type) {
+	tName, tSuper, comment := rdl.TypeInfo(t)
+	gen.emitf("### %s\n\n", tName)
+	if comment != "" {
+		gen.emitf("%s\n\n", strings.TrimSpace(comment))
+	}
+	kind := code(gen.registry.BaseType(t).String())
+	if super := gen.registry.FindType(tSuper); super != nil && super.Variant != rdl.TypeVariantBaseType && rdl.TypeName(tSuper) != tName {
+		kind += ", extends " + gen.typeLink(tSuper)
+	}
+	gen.emitf("Kind: %s\n\n", kind)
+	if constraints := genutil.TypeConstraints(t, code, gen.typeLink); len(constraints) > 0 {
+		for _, c := range constraints {
+			gen.emitf("- %s: %s\n", strings.ToLower(c.Name), c.Value)
+		}
+		gen.emit("\n")
+	}
+	switch t.Variant {
+	case rdl.TypeVariantStructTypeDef:
+		fields := genutil.FlattenedFields(gen.registry, t)
+		if len(fields) == 0 {
+			break
+		}
+		gen.emit("| Field | Type | Optional | Default | Description |\n|---|---|---|---|---|\n")
+		for _, f := range fields {
+			optional := ""
+			if f.Optional {
+				optional = "yes"
+			}
+			def := ""
+			if f.Default != nil {
+				def = code(genutil.JSONString(f.Default))
+			}
+			gen.emitf("| %s | %s | %s | %s | %s |\n", code(string(f.Name)), gen.fieldTypeLink(f), optional, cell(def), cell(f.Comment))
+		}
+		gen.emit("\n")
+	case rdl.TypeVariantEnumTypeDef:
+		gen.emit("| Symbol | Description |\n|---|---|\n")
+		for _, el := range t.EnumTypeDef.Elements {
+			gen.emitf("| %s | %s |\n", code(string(el.Symbol)), cell(el.Comment))
+		}
+		gen.emit("\n")
+	case rdl.TypeVariantUnionTypeDef:
+		variants := make([]string, 0, len(t.UnionTypeDef.Variants))
+		for _, v := range t.UnionTypeDef.Variants {
+			variants = append(variants, gen.typeLink(v))
+		}
+		gen.emitf("Variants: %s\n\n", strings.Join(variants, ", "))
+	}
+}
+
+func (gen *mdGenerator) emitResource(r *rdl.Resource) {
+	gen.emitf("### `%s %s`\n\n", r.Method, r.Path)
+	if r.Comment != "" {
+		gen.emitf("%s\n\n", strings.TrimSpace(r.Comment))
+	}
+	if r.Name != "" {
+		gen.emitf("- name: %s\n", code(string(r.Name)))
+	}
+	gen.emitf("- type: %s\n", gen.typeLink(r.Type))
+	if r.Auth != nil {
+		switch {
+		case r.Auth.Action != "":
+			auth := fmt.Sprintf("- authorization: action %s on resource %s", code(r.Auth.Action), code(r.Auth.Resource))
+			if r.Auth.Domain != "" {
+				auth += " in domain " + code(r.Auth.Domain)
+			}
+			gen.emit(auth + "\n")
+		case r.Auth.Authenticate:
+			gen.emit("- authentication: required\n")
+		}
+	}
+	if r.Async != nil && *r.Async {
+		gen.emit("- async: yes\n")
+	}
+	if from := r.Annotations["x_included_from"]; from != "" {
+		gen.emitf("- included from: %s\n", code(from))
+	}
+	gen.emit("\n")
+	if len(r.Inputs) > 0 {
+		gen.emit("| Parameter | Type | In | Optional | Default | Description |\n|---|---|---|---|---|---|\n")
+		for _, in := range r.Inputs {
+			optional := ""
+			if in.Optional || in.Default != nil {
+				optional = "yes"
+			}
+			def := ""
+			if in.Default != nil {
+				def = code(genutil.JSONString(in.Default))
+			}
+			typ := gen.typeLink(in.Type)
+			if in.Pattern != "" {
+				typ += " " + code(in.Pattern)
+			}
+			gen.emitf("| %s | %s | %s | %s | %s | %s |\n", code(string(in.Name)), cell(typ), genutil.InputLocation(in, code), optional, cell(def), cell(in.Comment))
+		}
+		gen.emit("\n")
+	}
+	if len(r.Outputs) > 0 {
+		gen.emit("| Output | Header | Type | Description |\n|---|---|---|---|\n")
+		for _, out := range r.Outputs {
+			gen.emitf("| %s | %s | %s | %s |\n", code(string(out.Name)), code(out.Header), gen.typeLink(out.Type), cell(out.Comment))
+		}
+		gen.emit("\n")
+	}
+	gen.emit("| Status | Type | Description |\n|---|---|---|\n")
+	expected := r.Expected
+	if expected == "" {
+		expected = "OK"
+	}
+	gen.emitf("| %s | %s | %s |\n", genutil.StatusText(expected, code), gen.typeLink(r.Type), "expected")
+	for _, alt := range r.Alternatives {
+		gen.emitf("| %s |  | %s |\n", genutil.StatusText(alt, code), "alternative")
+	}
+	syms := make([]string, 0, len(r.Exceptions))
+	for sym := range r.Exceptions {
+		syms = append(syms, sym)
+	}
+	sort.Slice(syms, func(i, j int) bool {
+		return rdl.StatusCode(syms[i]) < rdl.StatusCode(syms[j])
+	})
+	for _, sym := range syms {
+		e := r.Exceptions[sym]
+		gen.emitf("| %s | %s | %s |\n", genutil.StatusText(sym, code), gen.typeLink(rdl.TypeRef(e.Type)), cell(e.Comment))
+	}
+	gen.emit("\n")
+}
@@ -0,0 +1,93 @@
+// Copyright 2015 Yahoo Inc.
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package markdown
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ardielle/ardielle-go/rdl"
+)
+
+const commonRDL = `// Shared by all our services
+type Id String (pattern="[a-z]+");
+type Status Enum {
+    ACTIVE // in use
+    RETIRED
+}
+`
+
+const markdownTestRDL = `name inventory;
+include "common.rdl";
+
+// An item in stock
+type Item Struct {
+    Id id;
+    Status status (default=ACTIVE);
+    Int32 count (optional); // how many | roughly
+}
+
+// Fetch an item
+resource Item GET "/items/{id}" {
+    Id id;
+    String tag (header="X-Tag", optional);
+    expected OK;
+    exceptions {
+        ResourceError NOT_FOUND; // no such item
+        ResourceError BAD_REQUEST;
+    }
+}
+`
+
+func generate(test *testing.T) string {
+	dir := test.TempDir()
+	for name, src := range map[string]string{"common.rdl": commonRDL, "inventory.rdl": markdownTestRDL} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0644); err != nil {
+			test.Fatalf("Cannot write test schema: %v", err)
+		}
+	}
+	schema, err := rdl.ParseRDLFile(filepath.Join(dir, "inventory.rdl"), false, false, true)
+	if err != nil {
+		test.Fatalf("Cannot parse test schema: %v", err)
+	}
+	if err := Generate(schema, &GeneratorParams{Outdir: dir, Banner: "rdl test"}); err != nil {
+		test.Fatalf("Cannot generate markdown: %v", err)
+	}
+	b, err := os.ReadFile(filepath.Join(dir, "inventory.md"))
+	if err != nil {
+		test.Fatalf("Cannot read generated markdown: %v", err)
+	}
+	return string(b)
+}
+
+func TestGenerate(test *testing.T) {
+	md := generate(test)
+	for _, expected := range []string{
+		"<!-- Code generated by rdl test. DO NOT EDIT. -->",
+		"# inventory\n",
+		"### `GET /items/{id}`\n\nFetch an item\n",
+		"| `id` | [Id](#id) | path |  |  |  |",
+		"| `tag` | `String` | header `X-Tag` | yes |  |  |",
+		"| `200` OK | [Item](#item) | expected |",
+		"| `404` Not Found | `ResourceError` | no such item |",
+		"## Types\n\n### Item\n",
+		"| `status` | [Status](#status) |  | `\"ACTIVE\"` |  |",
+		"| `count` | `Int32` | yes |  | how many \\| roughly |",
+		"## Types included from `common.rdl`\n\n### Id\n",
+		"- pattern: `[a-z]+`",
+		"| `ACTIVE` | in use |",
+	} {
+		if !strings.Contains(md, expected) {
+			test.Errorf("Generated markdown does not contain %q", expected)
+		}
+	}
+	if strings.Index(md, "`400` Bad Request") > strings.Index(md, "`404` Not Found") {
+		test.Errorf("Status codes should be in order")
+	}
+	if strings.Index(md, "### Item") > strings.Index(md, "### Id") {
+		test.Errorf("Local types should come before included types")
+	}
+}
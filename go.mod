@@ -1 +1,3 @@
 module github.com/ardielle/ardielle-go
+
+go 1.21
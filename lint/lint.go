@@ -0,0 +1,282 @@
+// Copyright 2015 Yahoo Inc.
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+// Package lint checks RDL schemas against a set of named, individually configurable style rules.
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/ardielle/ardielle-go/rdl"
+)
+
+// Severity is the level at which a rule reports. SeverityOff disables the rule.
+type Severity int
+
+const (
+	SeverityOff Severity = iota
+	SeverityWarning
+	SeverityError
+)
+
+var namesSeverity = []string{
+	SeverityOff:     "off",
+	SeverityWarning: "warning",
+	SeverityError:   "error",
+}
+
+func (s Severity) String() string {
+	if s < 0 || int(s) >= len(namesSeverity) {
+		return "unknown"
+	}
+	return namesSeverity[s]
+}
+
+func (s Severity) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+func (s *Severity) UnmarshalJSON(b []byte) error {
+	var name string
+	if err := json.Unmarshal(b, &name); err != nil {
+		return err
+	}
+	for i, n := range namesSeverity {
+		if n == name {
+			*s = Severity(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("bad severity: %q", name)
+}
+
+// Position identifies a location in an RDL source file. A zero Line means the location is not known,
+// which is the case when linting a Schema that was not parsed from source.
+type Position struct {
+	Filename string `json:"filename,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Column   int    `json:"column,omitempty"`
+}
+
+func (pos Position) String() string {
+	s := pos.Filename
+	if pos.Line > 0 {
+		if s != "" {
+			s += ":"
+		}
+		s += fmt.Sprintf("%d:%d", pos.Line, pos.Column)
+	}
+	return s
+}
+
+// Issue is a single rule violation.
+type Issue struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	Position Position `json:"position"`
+	Message  string   `json:"message"`
+}
+
+// String formats the issue as "file:line:col: severity: message (rule)", the form most CI tools recognize.
+func (issue *Issue) String() string {
+	s := fmt.Sprintf("%s: %s (%s)", issue.Severity, issue.Message, issue.Rule)
+	if pos := issue.Position.String(); pos != "" {
+		s = pos + ": " + s
+	}
+	return s
+}
+
+// Rule describes a lint check. The Severity is its default, which a Config may override.
+type Rule struct {
+	Name        string
+	Description string
+	Severity    Severity
+	check       func(l *linter)
+}
+
+// Config maps rule names to the severity they should report at. Rules not mentioned use their default severity.
+type Config map[string]Severity
+
+// DefaultConfig returns a Config with every rule set to its default severity.
+func DefaultConfig() Config {
+	config := make(Config)
+	for _, r := range rules {
+		config[r.Name] = r.Severity
+	}
+	return config
+}
+
+// LoadConfig reads a Config from a JSON file of the form {"rule-name": "error", "other-rule": "off"}.
+func LoadConfig(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var config Config
+	err = json.Unmarshal(data, &config)
+	if err != nil {
+		return nil, err
+	}
+	for name := range config {
+		if FindRule(name) == nil {
+			return nil, fmt.Errorf("no such lint rule: %q", name)
+		}
+	}
+	return config, nil
+}
+
+func (config Config) severity(r *Rule) Severity {
+	if config != nil {
+		if sev, ok := config[r.Name]; ok {
+			return sev
+		}
+	}
+	return r.Severity
+}
+
+// Rules returns all known rules, in the order they are checked.
+func Rules() []*Rule {
+	return append([]*Rule(nil), rules...)
+}
+
+// FindRule returns the rule with the given name, or nil if there is none.
+func FindRule(name string) *Rule {
+	for _, r := range rules {
+		if r.Name == name {
+			return r
+		}
+	}
+	return nil
+}
+
+// LintFile parses the RDL file at the given path and checks it, reporting issues with their source positions.
+// Types and resources from included files are reported against the file they were included from.
+func LintFile(path string, config Config) ([]*Issue, error) {
+	schema, err := rdl.ParseRDLFile(path, false, false, true)
+	if err != nil {
+		return nil, err
+	}
+	src, err := indexFile(path, schema)
+	if err != nil {
+		return nil, err
+	}
+	return lint(schema, src, config), nil
+}
+
+// LintSchema checks a schema that may not have come from a source file. Positions are not reported,
+// and the rules that can only be checked in the source (such as legacy-syntax) are skipped.
+func LintSchema(schema *rdl.Schema, config Config) []*Issue {
+	return lint(schema, nil, config)
+}
+
+type linter struct {
+	schema   *rdl.Schema
+	registry rdl.TypeRegistry
+	src      *sourceIndex
+	rule     *Rule
+	severity Severity
+	issues   []*Issue
+}
+
+func lint(schema *rdl.Schema, src *sourceIndex, config Config) []*Issue {
+	l := &linter{
+		schema:   schema,
+		registry: rdl.NewTypeRegistry(schema),
+		src:      src,
+	}
+	for _, r := range rules {
+		sev := config.severity(r)
+		if sev == SeverityOff {
+			continue
+		}
+		l.rule = r
+		l.severity = sev
+		r.check(l)
+	}
+	sort.SliceStable(l.issues, func(i, j int) bool {
+		pi, pj := l.issues[i].Position, l.issues[j].Position
+		if pi.Filename != pj.Filename {
+			return pi.Filename < pj.Filename
+		}
+		if pi.Line != pj.Line {
+			return pi.Line < pj.Line
+		}
+		return pi.Column < pj.Column
+	})
+	return l.issues
+}
+
+func (l *linter) report(pos Position, format string, args ...interface{}) {
+	l.issues = append(l.issues, &Issue{
+		Rule:     l.rule.Name,
+		Severity: l.severity,
+		Position: pos,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+func (l *linter) typePos(t *rdl.Type) Position {
+	return l.src.position(typeKey(typeName(t)))
+}
+
+func (l *linter) fieldPos(t *rdl.Type, f *rdl.StructFieldDef) Position {
+	return l.src.position(fieldKey(typeName(t), f.Name))
+}
+
+func (l *linter) elementPos(t *rdl.Type, el *rdl.EnumElementDef) Position {
+	return l.src.position(elementKey(typeName(t), el.Symbol))
+}
+
+func (l *linter) resourcePos(r *rdl.Resource) Position {
+	return l.src.position(resourceKey(r.Method, r.Path))
+}
+
+// inputPos falls back to the resource position for path and query params that are never declared in the body.
+func (l *linter) inputPos(r *rdl.Resource, in *rdl.ResourceInput) Position {
+	pos := l.src.position(inputKey(r.Method, r.Path, string(in.Name)))
+	if pos.Line == 0 {
+		pos = l.resourcePos(r)
+	}
+	return pos
+}
+
+func resourceName(r *rdl.Resource) string {
+	if r.Name != "" {
+		return string(r.Name)
+	}
+	return r.Method + " " + r.Path
+}
+
+func typeKey(name rdl.TypeName) string {
+	return "type " + string(name)
+}
+
+func fieldKey(typeName rdl.TypeName, name rdl.Identifier) string {
+	return "field " + string(typeName) + "." + string(name)
+}
+
+func elementKey(typeName rdl.TypeName, sym rdl.Identifier) string {
+	return "element " + string(typeName) + "." + string(sym)
+}
+
+func resourceKey(method string, path string) string {
+	return "resource " + method + " " + path
+}
+
+func inputKey(method string, path string, name string) string {
+	return "input " + method + " " + path + " " + name
+}
+
+// isLocalType is true for the user types a schema defines itself, as opposed to base types and types
+// brought in with 'use', which carry the namespace prefix of the schema that defines them.
+func isLocalType(t *rdl.Type) bool {
+	return t != nil && t.Variant != rdl.TypeVariantBaseType && !strings.Contains(string(typeName(t)), ".")
+}
+func typeName(t *rdl.Type) rdl.TypeName {
+	tName, _, _ := rdl.TypeInfo(t)
+	return tName
+}
@@ -0,0 +1,112 @@
+// Copyright 2015 Yahoo Inc.
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package lint
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const lintTestRDL = `name lintTest;
+
+type UserName String (pattern="[a-z]+");
+
+//A user
+type User Struct {
+    UserName name;
+    Int32 Age;
+    Any extra;
+}
+
+//status of a thing
+type Status Enum { ACTIVE, notActive }
+
+type lowercase Struct {
+    Long count;
+}
+
+//Get a user
+resource User GET "/users/{name}" {
+    String name;
+    exceptions {
+        ResourceError NOT_FOUND;
+    }
+}
+
+resource User PUT "/users/{name}" {
+    UserName name;
+    User user (required);
+    authenticate;
+}
+`
+
+func lintTestFile(test *testing.T, config Config) []*Issue {
+	path := filepath.Join(test.TempDir(), "linttest.rdl")
+	if err := os.WriteFile(path, []byte(lintTestRDL), 0644); err != nil {
+		test.Fatalf("Cannot write test schema: %v", err)
+	}
+	issues, err := LintFile(path, config)
+	if err != nil {
+		test.Fatalf("Cannot lint test schema: %v", err)
+	}
+	return issues
+}
+
+func findIssue(issues []*Issue, rule string, line int) *Issue {
+	for _, issue := range issues {
+		if issue.Rule == rule && issue.Position.Line == line {
+			return issue
+		}
+	}
+	return nil
+}
+
+func TestLintFile(test *testing.T) {
+	issues := lintTestFile(test, nil)
+	expected := []struct {
+		rule string
+		line int
+	}{
+		{"type-comment", 3},
+		{"field-name-case", 8},
+		{"any-field", 9},
+		{"enum-symbol-case", 13},
+		{"type-name-case", 15},
+		{"type-comment", 15},
+		{"unused-type", 15},
+		{"legacy-syntax", 16},
+		{"unused-type", 13},
+		{"resource-exceptions", 20},
+		{"path-param-type", 21},
+		{"resource-comment", 27},
+		{"resource-exceptions", 27},
+		{"legacy-syntax", 29},
+	}
+	for _, e := range expected {
+		if findIssue(issues, e.rule, e.line) == nil {
+			test.Errorf("Expected a %s issue at line %d", e.rule, e.line)
+		}
+	}
+	if len(issues) != len(expected) {
+		for _, issue := range issues {
+			test.Log(issue)
+		}
+		test.Errorf("Expected %d issues, found %d", len(expected), len(issues))
+	}
+}
+
+func TestLintConfig(test *testing.T) {
+	config := Config{"legacy-syntax": SeverityOff, "any-field": SeverityError}
+	issues := lintTestFile(test, config)
+	for _, issue := range issues {
+		if issue.Rule == "legacy-syntax" {
+			test.Errorf("Disabled rule reported an issue: %v", issue)
+		}
+	}
+	issue := findIssue(issues, "any-field", 9)
+	if issue == nil || issue.Severity != SeverityError {
+		test.Errorf("Expected any-field to be reported as an error, found %v", issue)
+	}
+}
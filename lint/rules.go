@@ -0,0 +1,197 @@
+// Copyright 2015 Yahoo Inc.
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package lint
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/ardielle/ardielle-go/rdl"
+)
+
+var rules = []*Rule{
+	{"type-name-case", "type names must be UpperCamelCase", SeverityError, checkTypeNames},
+	{"field-name-case", "struct field names must be lowerCamelCase", SeverityError, checkFieldNames},
+	{"enum-symbol-case", "enum symbols must be UPPER_CASE", SeverityError, checkEnumSymbols},
+	{"type-comment", "every type must have a comment", SeverityWarning, checkTypeComments},
+	{"resource-comment", "every resource must have a comment", SeverityWarning, checkResourceComments},
+	{"resource-exceptions", "resources must declare exceptions for the 4xx statuses they can return", SeverityWarning, checkResourceExceptions},
+	{"unused-type", "every type must be reachable from a resource", SeverityWarning, checkUnusedTypes},
+	{"any-field", "struct fields must not be of type Any", SeverityWarning, checkAnyFields},
+	{"path-param-type", "path parameters must have constrained types", SeverityWarning, checkPathParams},
+	{"legacy-syntax", "deprecated legacy syntax must not be used", SeverityError, checkLegacySyntax},
+}
+
+var (
+	upperCamelPattern = regexp.MustCompile("^[A-Z][a-zA-Z0-9]*$")
+	lowerCamelPattern = regexp.MustCompile("^[a-z][a-zA-Z0-9]*$")
+	upperCasePattern  = regexp.MustCompile("^[A-Z][A-Z0-9_]*$")
+)
+
+func checkTypeNames(l *linter) {
+	for _, t := range l.schema.Types {
+		if isLocalType(t) && !upperCamelPattern.MatchString(string(typeName(t))) {
+			l.report(l.typePos(t), "type name '%s' is not UpperCamelCase", typeName(t))
+		}
+	}
+}
+
+func checkFieldNames(l *linter) {
+	for _, t := range l.schema.Types {
+		if isLocalType(t) && t.Variant == rdl.TypeVariantStructTypeDef {
+			for _, f := range t.StructTypeDef.Fields {
+				if !lowerCamelPattern.MatchString(string(f.Name)) {
+					l.report(l.fieldPos(t, f), "field name '%s' in type '%s' is not lowerCamelCase", f.Name, typeName(t))
+				}
+			}
+		}
+	}
+}
+
+func checkEnumSymbols(l *linter) {
+	for _, t := range l.schema.Types {
+		if isLocalType(t) && t.Variant == rdl.TypeVariantEnumTypeDef {
+			for _, el := range t.EnumTypeDef.Elements {
+				if !upperCasePattern.MatchString(string(el.Symbol)) {
+					l.report(l.elementPos(t, el), "enum symbol '%s' in type '%s' is not UPPER_CASE", el.Symbol, typeName(t))
+				}
+			}
+		}
+	}
+}
+
+func checkTypeComments(l *linter) {
+	for _, t := range l.schema.Types {
+		if !isLocalType(t) {
+			continue
+		}
+		if _, _, comment := rdl.TypeInfo(t); strings.TrimSpace(comment) == "" {
+			l.report(l.typePos(t), "type '%s' has no comment", typeName(t))
+		}
+	}
+}
+
+func checkResourceComments(l *linter) {
+	for _, r := range l.schema.Resources {
+		if strings.TrimSpace(r.Comment) == "" {
+			l.report(l.resourcePos(r), "resource '%s' has no comment", resourceName(r))
+		}
+	}
+}
+
+// checkResourceExceptions requires the 4xx statuses implied by the resource declaration: BAD_REQUEST when
+// there are inputs to reject, NOT_FOUND when a path param names an entity, and UNAUTHORIZED/FORBIDDEN when
+// authentication or authorization is required. A resource that declares no 4xx status at all is also reported.
+func checkResourceExceptions(l *linter) {
+	for _, r := range l.schema.Resources {
+		declared := make(map[string]bool)
+		has4xx := false
+		for sym := range r.Exceptions {
+			code := rdl.StatusCode(sym)
+			declared[code] = true
+			if strings.HasPrefix(code, "4") {
+				has4xx = true
+			}
+		}
+		var missing []string
+		require := func(sym string) {
+			if !declared[rdl.StatusCode(sym)] {
+				missing = append(missing, sym)
+			}
+		}
+		if len(r.Inputs) > 0 {
+			require("BAD_REQUEST")
+		}
+		for _, in := range r.Inputs {
+			if in.PathParam {
+				require("NOT_FOUND")
+				break
+			}
+		}
+		if r.Auth != nil {
+			require("UNAUTHORIZED")
+			if r.Auth.Action != "" {
+				require("FORBIDDEN")
+			}
+		}
+		if len(missing) > 0 {
+			l.report(l.resourcePos(r), "resource '%s' does not declare exceptions for %s", resourceName(r), strings.Join(missing, ", "))
+		} else if !has4xx {
+			l.report(l.resourcePos(r), "resource '%s' declares no 4xx exceptions", resourceName(r))
+		}
+	}
+}
+
+// checkUnusedTypes only applies to schemas with resources: a schema of types alone is a library, all of whose
+// types are meant to be used elsewhere.
+func checkUnusedTypes(l *linter) {
+	if len(l.schema.Resources) == 0 {
+		return
+	}
+	unused := make(map[rdl.TypeName]bool)
+	for _, n := range rdl.NewTypeGraph(l.schema).UnreachableTypes() {
+		unused[n] = true
+	}
+	for _, t := range l.schema.Types {
+		if isLocalType(t) && unused[typeName(t)] {
+			l.report(l.typePos(t), "type '%s' is not used by any resource", typeName(t))
+		}
+	}
+}
+
+func checkAnyFields(l *linter) {
+	for _, t := range l.schema.Types {
+		if !isLocalType(t) || t.Variant != rdl.TypeVariantStructTypeDef {
+			continue
+		}
+		for _, f := range t.StructTypeDef.Fields {
+			switch {
+			case l.registry.FindBaseType(f.Type) == rdl.BaseTypeAny:
+				l.report(l.fieldPos(t, f), "field '%s' in type '%s' is of type Any", f.Name, typeName(t))
+			case f.Items != "" && l.registry.FindBaseType(f.Items) == rdl.BaseTypeAny:
+				l.report(l.fieldPos(t, f), "field '%s' in type '%s' has items of type Any", f.Name, typeName(t))
+			}
+		}
+	}
+}
+
+// checkPathParams rejects path params that resolve to an unconstrained String, since those match any
+// path segment. Numeric, enum, UUID and other non-String types, as well as patterned, enumerated or
+// size-limited String types, are all considered constrained.
+func checkPathParams(l *linter) {
+	for _, r := range l.schema.Resources {
+		for _, in := range r.Inputs {
+			if !in.PathParam || in.Pattern != "" {
+				continue
+			}
+			if l.registry.FindBaseType(in.Type) == rdl.BaseTypeString && !l.isConstrainedString(in.Type) {
+				l.report(l.inputPos(r, in), "path param '%s' of resource '%s' has unconstrained type '%s'", in.Name, resourceName(r), in.Type)
+			}
+		}
+	}
+}
+
+func (l *linter) isConstrainedString(ref rdl.TypeRef) bool {
+	t := l.registry.FindType(ref)
+	for t != nil && t.Variant != rdl.TypeVariantBaseType {
+		if t.Variant == rdl.TypeVariantStringTypeDef {
+			td := t.StringTypeDef
+			if td.Pattern != "" || len(td.Values) > 0 || td.MaxSize != nil {
+				return true
+			}
+		}
+		_, super, _ := rdl.TypeInfo(t)
+		t = l.registry.FindType(super)
+	}
+	return false
+}
+
+func checkLegacySyntax(l *linter) {
+	if l.src == nil {
+		return
+	}
+	for _, use := range l.src.legacy {
+		l.report(use.pos, "%s", use.message)
+	}
+}
@@ -0,0 +1,371 @@
+// Copyright 2015 Yahoo Inc.
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package lint
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/scanner"
+	"unicode"
+
+	"github.com/ardielle/ardielle-go/rdl"
+)
+
+// The parsed Schema carries no source positions, and normalizes away the legacy syntax, so the
+// source is scanned again to locate each definition and to find the legacy constructs the parser accepted.
+
+var legacySynonyms = map[string]string{
+	"byte":    "Int8",
+	"short":   "Int16",
+	"integer": "Int32",
+	"long":    "Int64",
+	"float":   "Float32",
+	"double":  "Float64",
+	"boolean": "Bool",
+}
+
+type legacyUse struct {
+	pos     Position
+	message string
+}
+
+type sourceIndex struct {
+	filename  string
+	registry  rdl.TypeRegistry
+	positions map[string]Position
+	legacy    []*legacyUse
+	visited   map[string]bool
+}
+
+func indexFile(path string, schema *rdl.Schema) (*sourceIndex, error) {
+	src := &sourceIndex{
+		filename:  path,
+		registry:  rdl.NewTypeRegistry(schema),
+		positions: make(map[string]Position),
+		visited:   make(map[string]bool),
+	}
+	err := src.scanFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return src, nil
+}
+
+// position returns the recorded position for the key. When unknown, only the main file name is reported.
+func (src *sourceIndex) position(key string) Position {
+	if src == nil {
+		return Position{}
+	}
+	if pos, ok := src.positions[key]; ok {
+		return pos
+	}
+	return Position{Filename: src.filename}
+}
+
+// record keeps the first position seen for a key, so that the including file wins over the included one.
+func (src *sourceIndex) record(key string, pos Position) {
+	if _, ok := src.positions[key]; !ok {
+		src.positions[key] = pos
+	}
+}
+
+func (src *sourceIndex) scanFile(path string) error {
+	if src.visited[path] {
+		return nil
+	}
+	src.visited[path] = true
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	sc := &sourceScanner{src: src}
+	sc.scanner.Init(bytes.NewReader(data))
+	sc.scanner.Filename = path
+	sc.scanner.Mode = scanner.ScanIdents | scanner.ScanStrings | scanner.ScanFloats | scanner.ScanComments | scanner.SkipComments
+	sc.scanner.Whitespace = scanner.GoWhitespace &^ (1 << '\n')
+	sc.scanner.IsIdentRune = func(ch rune, i int) bool {
+		return ch == '_' || unicode.IsLetter(ch) || unicode.IsDigit(ch) && i > 0
+	}
+	sc.scanner.Error = func(s *scanner.Scanner, msg string) {} //the parser has already accepted this source
+	return sc.scanSchema()
+}
+
+type token struct {
+	tok  rune
+	text string
+	pos  Position
+}
+
+type sourceScanner struct {
+	src     *sourceIndex
+	scanner scanner.Scanner
+	pending *token
+}
+
+func (sc *sourceScanner) next() token {
+	if sc.pending != nil {
+		t := *sc.pending
+		sc.pending = nil
+		return t
+	}
+	tok := sc.scanner.Scan()
+	pos := sc.scanner.Position
+	t := token{tok, sc.scanner.TokenText(), Position{pos.Filename, pos.Line, pos.Column}}
+	if tok == '#' {
+		sc.legacy(t.pos, "use '//', not '#'")
+		for ch := sc.scanner.Peek(); ch != '\n' && ch != scanner.EOF; ch = sc.scanner.Peek() {
+			sc.scanner.Next()
+		}
+		return sc.next()
+	}
+	return t
+}
+
+func (sc *sourceScanner) pushBack(t token) {
+	sc.pending = &t
+}
+
+func (sc *sourceScanner) legacy(pos Position, message string) {
+	sc.src.legacy = append(sc.src.legacy, &legacyUse{pos, message})
+}
+
+func (sc *sourceScanner) scanSchema() error {
+	for t := sc.next(); t.tok != scanner.EOF; t = sc.next() {
+		if t.tok != scanner.Ident {
+			continue
+		}
+		switch t.text {
+		case "type":
+			sc.scanType()
+		case "resource":
+			sc.scanResource(t.pos)
+		case "service":
+			sc.legacy(t.pos, "use 'name', not 'service'")
+		case "include":
+			if s := sc.next(); s.tok == scanner.String {
+				if fname, err := strconv.Unquote(s.text); err == nil {
+					err = sc.src.scanFile(filepath.Join(filepath.Dir(sc.scanner.Filename), fname))
+					if err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (sc *sourceScanner) scanType() {
+	name := sc.next()
+	if name.tok != scanner.Ident {
+		return
+	}
+	sc.src.record(typeKey(rdl.TypeName(name.text)), name.pos)
+	if t := sc.next(); t.tok == scanner.Ident {
+		sc.scanTypeRef(t)
+	}
+	for t := sc.next(); ; t = sc.next() {
+		switch t.tok {
+		case '(':
+			sc.skipParens(nil)
+		case '{':
+			td := sc.src.registry.FindType(rdl.TypeRef(name.text))
+			switch {
+			case td != nil && td.Variant == rdl.TypeVariantStructTypeDef:
+				sc.scanStructBody(rdl.TypeName(name.text))
+			case td != nil && td.Variant == rdl.TypeVariantEnumTypeDef:
+				sc.scanEnumBody(rdl.TypeName(name.text))
+			default:
+				sc.skipBraces()
+			}
+			return
+		case ';', '\n', scanner.EOF:
+			return
+		}
+	}
+}
+
+// scanTypeRef consumes a type reference starting at the given identifier, i.e. "Foo", "ns.Foo" or
+// "Map<String,Foo>", flagging legacy type name synonyms.
+func (sc *sourceScanner) scanTypeRef(t token) {
+	sc.checkTypeName(t)
+	next := sc.next()
+	if next.tok == '.' {
+		sc.next()
+		next = sc.next()
+	}
+	if next.tok != '<' {
+		sc.pushBack(next)
+		return
+	}
+	depth := 1
+	for depth > 0 {
+		t = sc.next()
+		switch t.tok {
+		case '<':
+			depth++
+		case '>':
+			depth--
+		case scanner.Ident:
+			sc.checkTypeName(t)
+		case scanner.EOF:
+			return
+		}
+	}
+}
+
+func (sc *sourceScanner) checkTypeName(t token) {
+	if n, ok := legacySynonyms[strings.ToLower(t.text)]; ok {
+		if sc.src.registry.FindType(rdl.TypeRef(t.text)) == nil {
+			sc.legacy(t.pos, "use '"+n+"', not '"+t.text+"'")
+		}
+	}
+}
+
+func (sc *sourceScanner) scanStructBody(typeName rdl.TypeName) {
+	for t := sc.next(); t.tok != '}' && t.tok != scanner.EOF; t = sc.next() {
+		if t.tok != scanner.Ident {
+			continue
+		}
+		if t.text == "closed" {
+			sc.legacy(t.pos, "use 'type "+string(typeName)+" Struct (closed) { ... }' syntax instead")
+		} else {
+			sc.scanTypeRef(t)
+			if name := sc.next(); name.tok == scanner.Ident {
+				sc.src.record(fieldKey(typeName, rdl.Identifier(name.text)), name.pos)
+			}
+		}
+		if sc.skipStatement(nil) == '}' {
+			return
+		}
+	}
+}
+
+func (sc *sourceScanner) scanEnumBody(typeName rdl.TypeName) {
+	for t := sc.next(); t.tok != '}' && t.tok != scanner.EOF; t = sc.next() {
+		switch t.tok {
+		case scanner.Ident:
+			sc.src.record(elementKey(typeName, rdl.Identifier(t.text)), t.pos)
+		case '(':
+			sc.skipParens(nil)
+		}
+	}
+}
+
+func (sc *sourceScanner) scanResource(pos Position) {
+	if t := sc.next(); t.tok == scanner.Ident {
+		sc.scanTypeRef(t)
+	}
+	method := sc.next()
+	tmpl := sc.next()
+	if method.tok != scanner.Ident || tmpl.tok != scanner.String {
+		return
+	}
+	path, err := strconv.Unquote(tmpl.text)
+	if err != nil {
+		return
+	}
+	if i := strings.Index(path, "?"); i >= 0 {
+		path = path[:i]
+	}
+	m := strings.ToUpper(method.text)
+	sc.src.record(resourceKey(m, path), pos)
+	for t := sc.next(); ; t = sc.next() {
+		switch t.tok {
+		case '(':
+			sc.skipParens(nil)
+		case '{':
+			sc.scanResourceBody(m, path)
+			return
+		case ';', scanner.EOF:
+			return
+		}
+	}
+}
+
+func (sc *sourceScanner) scanResourceBody(method string, path string) {
+	paramOption := func(t token) {
+		switch t.text {
+		case "required":
+			sc.legacy(t.pos, "omit 'required', it is the default")
+		case "context":
+			sc.legacy(t.pos, "deprecated resource param option: 'context=...'")
+		}
+	}
+	for t := sc.next(); t.tok != '}' && t.tok != scanner.EOF; t = sc.next() {
+		if t.tok != scanner.Ident {
+			continue
+		}
+		var last rune
+		switch t.text {
+		case "authenticate", "authorize", "expected", "async":
+			last = sc.skipStatement(nil)
+		case "exceptions":
+			for t = sc.next(); t.tok != '{' && t.tok != scanner.EOF; t = sc.next() {
+			}
+			sc.skipBraces()
+		case "consumes", "produces":
+			for t = sc.next(); t.tok != '\n' && t.tok != scanner.EOF; t = sc.next() {
+			}
+		default:
+			sc.scanTypeRef(t)
+			if name := sc.next(); name.tok == scanner.Ident {
+				sc.src.record(inputKey(method, path, name.text), name.pos)
+			}
+			last = sc.skipStatement(paramOption)
+		}
+		if last == '}' {
+			return
+		}
+	}
+}
+
+// skipStatement consumes tokens up to the end of the current statement, returning the token that ended it.
+// A closing brace ends the statement and the enclosing block.
+func (sc *sourceScanner) skipStatement(option func(token)) rune {
+	for t := sc.next(); ; t = sc.next() {
+		switch t.tok {
+		case '(':
+			sc.skipParens(option)
+		case ';', '\n', '}', scanner.EOF:
+			return t.tok
+		}
+	}
+}
+
+func (sc *sourceScanner) skipParens(option func(token)) {
+	depth := 1
+	for depth > 0 {
+		t := sc.next()
+		switch t.tok {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case scanner.Ident:
+			if option != nil && depth == 1 {
+				option(t)
+			}
+		case scanner.EOF:
+			return
+		}
+	}
+}
+
+func (sc *sourceScanner) skipBraces() {
+	depth := 1
+	for depth > 0 {
+		switch sc.next().tok {
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case scanner.EOF:
+			return
+		}
+	}
+}
@@ -0,0 +1,221 @@
+// Copyright 2015 Yahoo Inc.
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package rdl
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Notifier tracks the current entity tag of the state behind keys, i.e. the paths of async resources, and
+// wakes the requests that long-poll them when it changes. Handlers call Notify or SetTag after changing the
+// state of a key, and Forget when it is gone. Tags are opaque strings, without the quotes of ETag headers.
+// Only Notify and SetTag keep keys: the keys that were never notified share an initial tag, and are only
+// tracked while requests wait for them.
+type Notifier struct {
+	mutex   sync.Mutex
+	prefix  string
+	counter int64
+	keys    map[string]*notifierKey
+}
+
+type notifierKey struct {
+	tag     string
+	changed chan struct{}
+	waiters int
+	kept    bool
+}
+
+// NewNotifier returns a Notifier without keys. The tags it generates are unique to it, so that tags from
+// before a restart are not mistaken for current ones.
+func NewNotifier() *Notifier {
+	return &Notifier{prefix: strconv.FormatInt(time.Now().UnixNano(), 36) + "-", keys: make(map[string]*notifierKey)}
+}
+
+// key returns the state of the key, with the initial tag if it is new. The mutex must be held.
+func (n *Notifier) key(key string) *notifierKey {
+	k, ok := n.keys[key]
+	if !ok {
+		k = &notifierKey{tag: n.prefix + "0", changed: make(chan struct{})}
+		n.keys[key] = k
+	}
+	return k
+}
+
+func (n *Notifier) nextTag() string {
+	n.counter++
+	return n.prefix + strconv.FormatInt(n.counter, 10)
+}
+
+// Tag returns the current tag of the key.
+func (n *Notifier) Tag(key string) string {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	if k, ok := n.keys[key]; ok {
+		return k.tag
+	}
+	return n.prefix + "0"
+}
+
+// Notify gives the key a new tag, and wakes the requests waiting for it to change. It returns the new tag.
+func (n *Notifier) Notify(key string) string {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	tag := n.nextTag()
+	n.set(key, tag)
+	return tag
+}
+
+// SetTag sets the tag of the key, i.e. a hash of its state, and wakes the requests waiting for it if it
+// changed. The tag must be made of the characters allowed in an ETag: printable ASCII except '"', and no
+// spaces.
+func (n *Notifier) SetTag(key string, tag string) error {
+	if tag == "" || strings.IndexFunc(tag, func(c rune) bool { return c < 0x21 || c == '"' || c > 0x7e }) >= 0 {
+		return fmt.Errorf("Bad entity tag: %q", tag)
+	}
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	if k, ok := n.keys[key]; !ok || k.tag != tag {
+		n.set(key, tag)
+	} else {
+		k.kept = true
+	}
+	return nil
+}
+
+// set changes the tag of the key, and keeps it. The mutex must be held.
+func (n *Notifier) set(key string, tag string) {
+	k := n.key(key)
+	close(k.changed)
+	k.tag, k.changed, k.kept = tag, make(chan struct{}), true
+}
+
+// Forget drops the key, i.e. when the state behind it is gone. The requests waiting for it are woken, and
+// it has the initial tag again.
+func (n *Notifier) Forget(key string) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	if k, ok := n.keys[key]; ok {
+		close(k.changed)
+		delete(n.keys, key)
+	}
+}
+
+// Wait waits until the tag of the key is not the given one, for at most the timeout, or until done is
+// closed. It returns the current tag, and whether it changed.
+func (n *Notifier) Wait(key string, tag string, timeout time.Duration, done <-chan struct{}) (string, bool) {
+	n.mutex.Lock()
+	k := n.key(key)
+	current, changed := k.tag, k.changed
+	if current != tag {
+		n.release(key, k)
+		n.mutex.Unlock()
+		return current, true
+	}
+	k.waiters++
+	n.mutex.Unlock()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	woken := false
+	select {
+	case <-changed:
+		woken = true
+	case <-timer.C:
+	case <-done:
+	}
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	k.waiters--
+	n.release(key, k)
+	if woken {
+		if k, ok := n.keys[key]; ok {
+			return k.tag, true
+		}
+		return n.prefix + "0", true
+	}
+	return current, false
+}
+
+// release drops the state of a key that was only tracked for waiting requests, once there are none. The
+// mutex must be held.
+func (n *Notifier) release(key string, k *notifierKey) {
+	if !k.kept && k.waiters == 0 && n.keys[key] == k {
+		delete(n.keys, key)
+	}
+}
+
+// Poll long-polls the key for the request of the context, given the value of its If-None-Match header. If
+// the value matches the current tag of the key, it waits for the tag to change, for at most the timeout or
+// until the request is canceled, and fails with a 304 ResourceError if it doesn't. Otherwise, it returns
+// the tag the response should have as its ETag, which is also set in the ETag header of the response if
+// it fails.
+func (n *Notifier) Poll(ctx *ResourceContext, key string, ifNoneMatch string, timeout time.Duration) (string, error) {
+	tag := n.Tag(key)
+	if !etagMatches(ifNoneMatch, tag) {
+		return tag, nil
+	}
+	tag, changed := n.Wait(key, tag, timeout, ctx.Request.Context().Done())
+	if !changed {
+		ctx.Writer.Header().Set("ETag", `"`+tag+`"`)
+		return tag, &ResourceError{http.StatusNotModified, "Not Modified"}
+	}
+	return tag, nil
+}
+
+// etagMatches returns whether the value of an If-None-Match header matches the tag. Weak tags match too.
+func etagMatches(ifNoneMatch string, tag string) bool {
+	for _, s := range strings.Split(ifNoneMatch, ",") {
+		s = strings.TrimPrefix(strings.TrimSpace(s), "W/")
+		if s == "*" || (len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' && s[1:len(s)-1] == tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// Middleware returns a ResourceMiddleware for a Router, that long-polls async resources declaring an
+// If-None-Match header input, with the timeout: requests whose If-None-Match matches the current tag of their
+// key wait for it to change before they are handled, and get a 304 Not Modified if it doesn't. Responses get
+// the current tag as the ETag output of the resource, if it declares one and the handler didn't set it. The
+// key of a request is the result of the function, or its path if the function is nil. Other resources are
+// handled as they are.
+func (n *Notifier) Middleware(timeout time.Duration, key func(ctx *ResourceContext, args map[string]interface{}) string) ResourceMiddleware {
+	return func(r *Resource, next ResourceHandler) ResourceHandler {
+		var ifNoneMatch *ResourceInput
+		for _, in := range r.Inputs {
+			if strings.EqualFold(in.Header, "If-None-Match") {
+				ifNoneMatch = in
+			}
+		}
+		if r.Async == nil || !*r.Async || ifNoneMatch == nil {
+			return next
+		}
+		etag := ""
+		for _, out := range r.Outputs {
+			if strings.EqualFold(out.Header, "ETag") {
+				etag = out.Header
+			}
+		}
+		return func(ctx *ResourceContext, args map[string]interface{}) (interface{}, error) {
+			k := ctx.Request.URL.Path
+			if key != nil {
+				k = key(ctx, args)
+			}
+			value, _ := args[string(ifNoneMatch.Name)].(string)
+			tag, err := n.Poll(ctx, k, value, timeout)
+			if err != nil {
+				return nil, err
+			}
+			data, err := next(ctx, args)
+			if err == nil && etag != "" && ctx.Writer.Header().Get(etag) == "" {
+				ctx.Writer.Header().Set(etag, `"`+tag+`"`)
+			}
+			return data, err
+		}
+	}
+}
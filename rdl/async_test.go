@@ -0,0 +1,170 @@
+// Copyright 2015 Yahoo Inc.
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package rdl
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+const asyncTestRDL = `
+type Counter Struct { Int32 count; }
+resource Counter GET "/counters/{name}" (name=getCounter, async) {
+    String name;
+    String matchTag (header="If-None-Match", optional);
+    String tag (header="ETag", out);
+    expected OK, NOT_MODIFIED;
+}
+resource Counter GET "/sync/{name}" (name=getSync) {
+    String name;
+    String matchTag (header="If-None-Match", optional);
+    String tag (header="ETag", out);
+    expected OK, NOT_MODIFIED;
+}
+`
+
+func TestLongPoll(test *testing.T) {
+	schema, err := parseRDLString(asyncTestRDL)
+	if err != nil {
+		test.Fatalf("Cannot parse: %v", err)
+	}
+	if r := schema.Resources[0]; r.Async == nil || !*r.Async {
+		test.Fatalf("Expected the resource to be async")
+	}
+	notifier := NewNotifier()
+	counts := make(map[string]int)
+	handler := func(ctx *ResourceContext, args map[string]interface{}) (interface{}, error) {
+		return map[string]int{"count": counts[args["name"].(string)]}, nil
+	}
+	routers := make(map[time.Duration]*Router)
+	for _, timeout := range []time.Duration{50 * time.Millisecond, 5 * time.Second} {
+		routers[timeout], _ = NewRouter(schema, map[Identifier]ResourceHandler{"getCounter": handler, "getSync": handler})
+		routers[timeout].Use(notifier.Middleware(timeout, nil))
+	}
+	router := routers[50*time.Millisecond]
+	get := func(path string, tag string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		if tag != "" {
+			req.Header.Set("If-None-Match", tag)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	//without a matching tag, the response is immediate, with the current tag
+	w := get("/counters/a", "")
+	etag := w.Header().Get("ETag")
+	if w.Code != 200 || etag != `"`+notifier.Tag("/counters/a")+`"` {
+		test.Fatalf("Unexpected response: %d %q", w.Code, etag)
+	}
+	if w = get("/counters/a", `"stale"`); w.Code != 200 || w.Header().Get("ETag") != etag {
+		test.Errorf("Expected an immediate response to a stale tag: %d", w.Code)
+	}
+
+	//nothing changes before the timeout
+	start := time.Now()
+	if w = get("/counters/a", "W/"+etag); w.Code != 304 || w.Body.Len() != 0 || w.Header().Get("ETag") != etag {
+		test.Errorf("Expected 304 Not Modified: %d %q", w.Code, w.Body.String())
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		test.Errorf("Expected the request to wait for the timeout: %v", elapsed)
+	}
+
+	//a notification wakes the request
+	router = routers[5*time.Second]
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- get("/counters/a", etag) }()
+	time.Sleep(20 * time.Millisecond)
+	counts["a"] = 1
+	notifier.Notify("/counters/b")
+	tag := notifier.Notify("/counters/a")
+	select {
+	case w = <-done:
+		if w.Code != 200 || w.Header().Get("ETag") != `"`+tag+`"` || w.Body.String() != "{\n  \"count\": 1\n}\n" {
+			test.Errorf("Unexpected response after the notification: %d %q %q", w.Code, w.Header().Get("ETag"), w.Body.String())
+		}
+	case <-time.After(2 * time.Second):
+		test.Fatalf("Expected the request to be woken by the notification")
+	}
+
+	//resources that are not async are not long-polled
+	if w = get("/sync/a", `"x"`); w.Code != 200 || w.Header().Get("ETag") != "" {
+		test.Errorf("Unexpected response of a resource that is not async: %d", w.Code)
+	}
+}
+
+func TestNotifier(test *testing.T) {
+	n := NewNotifier()
+	tag := n.Tag("k")
+	if n.Tag("k") != tag || NewNotifier().Tag("k") == tag {
+		test.Errorf("Expected tags to be stable, and unique to the notifier")
+	}
+	n.SetTag("k", "v1")
+	if current, changed := n.Wait("k", tag, time.Second, nil); !changed || current != "v1" {
+		test.Errorf("Expected a changed tag: %q", current)
+	}
+	done := make(chan struct{})
+	close(done)
+	if _, changed := n.Wait("k", "v1", time.Second, done); changed {
+		test.Errorf("Expected the wait to be canceled")
+	}
+	woken := make(chan string)
+	go func() {
+		current, _ := n.Wait("k", "v1", time.Second, nil)
+		woken <- current
+	}()
+	time.Sleep(10 * time.Millisecond)
+	n.SetTag("k", "v1")
+	n.SetTag("k", "v2")
+	if current := <-woken; current != "v2" {
+		test.Errorf("Expected to be woken by the new tag, got %q", current)
+	}
+	//keys that were never notified are not kept
+	for i := 0; i < 1000; i++ {
+		n.Tag("/unknown/" + strconv.Itoa(i))
+		n.Wait("/unknown/"+strconv.Itoa(i), "", time.Second, nil)
+	}
+	n.Wait("/waited", n.Tag("/waited"), time.Millisecond, nil)
+	if len(n.keys) != 1 {
+		test.Errorf("Expected only the notified key to be kept, got %d keys", len(n.keys))
+	}
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		n.Forget("k")
+	}()
+	if current, changed := n.Wait("k", "v2", time.Second, nil); !changed || current != n.Tag("/unknown/0") || len(n.keys) != 0 {
+		test.Errorf("Expected a forgotten key to wake its waiters with the initial tag: %q, %d keys", current, len(n.keys))
+	}
+
+	//tags are quoted as they are, and only valid ones are accepted
+	for _, bad := range []string{"", `a"b`, "a b", "caf\u00e9"} {
+		if err := n.SetTag("k", bad); err == nil {
+			test.Errorf("Expected an error setting the tag %q", bad)
+		}
+	}
+	if err := n.SetTag("k", `a\b`); err != nil || !etagMatches(`W/"a\b"`, n.Tag("k")) {
+		test.Errorf("Expected a tag with a backslash to match its ETag: %v", err)
+	}
+
+	for _, c := range []struct {
+		header string
+		match  bool
+	}{
+		{`"v2"`, true}, {`W/"v2"`, true}, {`"v1", "v2"`, true}, {`*`, true}, {`"v1"`, false}, {``, false}, {`v2`, false}, {`"v2`, false},
+	} {
+		if etagMatches(c.header, "v2") != c.match {
+			test.Errorf("etagMatches(%q): expected %v", c.header, c.match)
+		}
+	}
+
+	req := httptest.NewRequest("GET", "/k", nil)
+	ctx := &ResourceContext{Writer: httptest.NewRecorder(), Request: req}
+	if _, err := n.Poll(ctx, "k", `"a\b"`, time.Millisecond); err == nil || err.(*ResourceError).Code != http.StatusNotModified {
+		test.Errorf("Expected a 304 error, got %v", err)
+	}
+}
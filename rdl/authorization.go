@@ -0,0 +1,155 @@
+// Copyright 2015 Yahoo Inc.
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package rdl
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Authorization enforces the authenticate and authorize directives of resources. Credentials are read from
+// the HTTP header of each Authenticator, in order, and the first one that authenticates its credential
+// gives the Principal of the request. Resources without directive are not restricted, but their requests
+// still get the Principal of valid credentials.
+type Authorization struct {
+	Authenticators []Authenticator
+	Authorizer     Authorizer
+}
+
+// NewAuthorization returns an Authorization with the authorizer, which may be nil if no resource has
+// an authorize directive, and the authenticators.
+func NewAuthorization(authorizer Authorizer, authenticators ...Authenticator) *Authorization {
+	return &Authorization{Authenticators: authenticators, Authorizer: authorizer}
+}
+
+// Check authenticates the request of the context, setting its Principal, and authorizes it for the
+// resource. The action is authorized on the resource string of the directive, prefixed by its domain if
+// any, with {param} placeholders replaced by the path params of the context. The error is a 401
+// ResourceError if the request must be authenticated and is not, a 403 if it is not authorized, and a
+// 500 if that can't be determined.
+func (auth *Authorization) Check(ctx *ResourceContext, r *Resource) error {
+	ctx.Principal = auth.authenticate(ctx.Request)
+	if r.Auth == nil || (!r.Auth.Authenticate && r.Auth.Action == "") {
+		return nil
+	}
+	if ctx.Principal == nil {
+		return &ResourceError{http.StatusUnauthorized, "Unauthorized"}
+	}
+	if r.Auth.Action == "" {
+		return nil
+	}
+	if auth.Authorizer == nil {
+		return &ResourceError{http.StatusForbidden, "Forbidden: no authorizer"}
+	}
+	resource, ok := ExpandAuthResource(r.Auth, ctx.Params)
+	if !ok {
+		return &ResourceError{http.StatusInternalServerError, "Cannot determine the resource to authorize: " + r.Auth.Resource}
+	}
+	allowed, err := auth.Authorizer.Authorize(r.Auth.Action, resource, ctx.Principal)
+	if err != nil {
+		return &ResourceError{http.StatusInternalServerError, "Cannot authorize: " + err.Error()}
+	}
+	if !allowed {
+		return &ResourceError{http.StatusForbidden, "Forbidden"}
+	}
+	return nil
+}
+
+func (auth *Authorization) authenticate(req *http.Request) Principal {
+	for _, authn := range auth.Authenticators {
+		if credential := req.Header.Get(authn.HTTPHeader()); credential != "" {
+			if principal := authn.Authenticate(credential); principal != nil {
+				return principal
+			}
+		}
+	}
+	return nil
+}
+
+// Guard returns a ResourceGuard for a Router, that checks requests before their inputs are extracted, so
+// that unauthenticated requests are rejected without reading their body or revealing their expected inputs.
+func (auth *Authorization) Guard() ResourceGuard {
+	return auth.Check
+}
+
+// ExpandAuthResource returns the resource string of an authorize directive, prefixed by its domain if any,
+// with {param} placeholders replaced by the params. It fails if a placeholder has no param.
+func ExpandAuthResource(auth *ResourceAuth, params map[string]string) (string, bool) {
+	resource := auth.Resource
+	if auth.Domain != "" {
+		resource = auth.Domain + ":" + resource
+	}
+	var expanded strings.Builder
+	for {
+		i := strings.Index(resource, "{")
+		if i < 0 {
+			break
+		}
+		j := strings.Index(resource[i:], "}")
+		if j < 0 {
+			return "", false
+		}
+		value, ok := params[resource[i+1:i+j]]
+		if !ok {
+			return "", false
+		}
+		expanded.WriteString(resource[:i])
+		expanded.WriteString(value)
+		resource = resource[i+j+1:]
+	}
+	expanded.WriteString(resource)
+	return expanded.String(), true
+}
+
+// AuthorizerFunc is an Authorizer implemented by a function.
+type AuthorizerFunc func(action string, resource string, principal Principal) (bool, error)
+
+// Authorize calls the function.
+func (f AuthorizerFunc) Authorize(action string, resource string, principal Principal) (bool, error) {
+	return f(action, resource, principal)
+}
+
+// AuthorizeCall is a call to Authorize recorded by a StaticAuthorizer.
+type AuthorizeCall struct {
+	Action    string
+	Resource  string
+	Principal Principal
+}
+
+// StaticAuthorizer is an Authorizer for tests, that allows the actions on the resources it is given, for
+// any principal, and records the calls to Authorize.
+type StaticAuthorizer struct {
+	mutex   sync.Mutex
+	allowed map[string]bool
+	calls   []AuthorizeCall
+}
+
+// NewStaticAuthorizer returns a StaticAuthorizer that allows nothing.
+func NewStaticAuthorizer() *StaticAuthorizer {
+	return &StaticAuthorizer{allowed: make(map[string]bool)}
+}
+
+// Allow allows the action on the resource.
+func (a *StaticAuthorizer) Allow(action string, resource string) *StaticAuthorizer {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.allowed[action+" "+resource] = true
+	return a
+}
+
+// Authorize returns whether the action is allowed on the resource.
+func (a *StaticAuthorizer) Authorize(action string, resource string, principal Principal) (bool, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.calls = append(a.calls, AuthorizeCall{action, resource, principal})
+	return a.allowed[action+" "+resource], nil
+}
+
+// Calls returns the calls to Authorize so far.
+func (a *StaticAuthorizer) Calls() []AuthorizeCall {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	return append([]AuthorizeCall(nil), a.calls...)
+}
@@ -0,0 +1,152 @@
+// Copyright 2015 Yahoo Inc.
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package rdl
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const authTestRDL = `
+type Entity Struct { String name; }
+resource Entity GET "/public/{name}" (name=getPublic) {
+    String name;
+}
+resource Entity GET "/me" (name=getMe) {
+    authenticate;
+}
+resource Entity GET "/domain/{domain}/entity/{name}" (name=getEntity) {
+    String domain;
+    String name;
+    authorize("read", "{domain}:entity.{name}");
+}
+resource Entity DELETE "/entity/{name}" (name=deleteEntity) {
+    String name;
+    authorize("delete", "entity.{name}", "sys.admin");
+}
+resource Entity GET "/items?n={n}" (name=getItems) {
+    Int32 n;
+    authenticate;
+}
+resource Entity PUT "/entity/{name}" (name=putEntity) {
+    String name;
+    Entity entity;
+    authorize("write", "entity.{name}");
+}
+`
+
+func TestAuthorization(test *testing.T) {
+	schema, err := parseRDLString(authTestRDL)
+	if err != nil {
+		test.Fatalf("Cannot parse: %v", err)
+	}
+	keys := NewAPIKeyAuthenticator("X-Api-Key").AddKey("secret1", "users", "jane")
+	authz := NewStaticAuthorizer().Allow("read", "sports:entity.ball").Allow("delete", "sys.admin:entity.ball")
+	handler := func(ctx *ResourceContext, args map[string]interface{}) (interface{}, error) {
+		name := ""
+		if ctx.Principal != nil {
+			name = ctx.Principal.GetYRN()
+		}
+		return map[string]string{"name": name}, nil
+	}
+	router, _ := NewRouter(schema, map[Identifier]ResourceHandler{
+		"getPublic": handler, "getMe": handler, "getEntity": handler, "deleteEntity": handler, "putEntity": handler,
+	})
+	router.Guard(NewAuthorization(authz, keys).Guard())
+	for _, c := range []struct {
+		method string
+		path   string
+		key    string
+		code   int
+		body   string
+	}{
+		{"GET", "/public/x", "", 200, ""},
+		{"GET", "/public/x", "secret1", 200, "users.jane"},
+		{"GET", "/me", "", 401, ""},
+		{"GET", "/me", "wrong", 401, ""},
+		{"GET", "/me", "secret1", 200, "users.jane"},
+		{"GET", "/domain/sports/entity/ball", "secret1", 200, "users.jane"},
+		{"GET", "/domain/sports/entity/bat", "secret1", 403, ""},
+		{"GET", "/domain/sports/entity/ball", "", 401, ""},
+		{"DELETE", "/entity/ball", "secret1", 200, "users.jane"},
+		//requests are checked before their params and body, and before the handler is looked up
+		{"GET", "/items", "", 401, ""},
+		{"GET", "/items?n=x", "wrong", 401, ""},
+		{"GET", "/items", "secret1", 400, ""},
+		{"GET", "/items?n=1", "secret1", 501, ""},
+		{"PUT", "/entity/ball", "", 401, ""},
+		{"PUT", "/entity/ball", "secret1", 403, ""},
+	} {
+		req := httptest.NewRequest(c.method, c.path, nil)
+		if c.key != "" {
+			req.Header.Set("X-Api-Key", c.key)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != c.code || (c.code == 200 && w.Body.String() != "{\n  \"name\": \""+c.body+"\"\n}\n") {
+			test.Errorf("%s %s with %q: unexpected response %d %s", c.method, c.path, c.key, w.Code, w.Body)
+		}
+	}
+	calls := authz.Calls()
+	if len(calls) != 4 || calls[1].Resource != "sports:entity.bat" || calls[1].Principal.GetName() != "jane" {
+		test.Errorf("Unexpected calls to the authorizer: %+v", calls)
+	}
+
+	ctx := &ResourceContext{Request: httptest.NewRequest("GET", "/me", nil), Params: map[string]string{}}
+	failing := AuthorizerFunc(func(action, resource string, principal Principal) (bool, error) {
+		return false, errors.New("unavailable")
+	})
+	ctx.Request.Header.Set("X-Api-Key", "secret1")
+	if err := NewAuthorization(failing, keys).Check(ctx, schema.Resources[3]); err == nil || err.(*ResourceError).Code != 500 {
+		test.Errorf("Expected a 500 error when authorization fails, got %v", err)
+	}
+	if err := NewAuthorization(nil, keys).Check(ctx, schema.Resources[3]); err == nil || err.(*ResourceError).Code != 403 {
+		test.Errorf("Expected a 403 error without authorizer, got %v", err)
+	}
+	if err := NewAuthorization(authz, keys).Check(ctx, schema.Resources[2]); err == nil || err.(*ResourceError).Code != 500 {
+		test.Errorf("Expected a 500 error for a resource that can't be expanded, got %v", err)
+	}
+	keys.RemoveKey("secret1")
+	if keys.Authenticate("secret1") != nil {
+		test.Errorf("Expected a removed key not to authenticate")
+	}
+}
+
+func TestHMACAuthenticator(test *testing.T) {
+	now := time.Unix(1600000000, 0)
+	old := NewHMACAuthenticator("X-Token", []byte("old key"))
+	authn := NewHMACAuthenticator("X-Token", []byte("new key"), []byte("old key"))
+	for _, a := range []*HMACAuthenticator{old, authn} {
+		a.Now = func() time.Time { return now }
+	}
+	token, err := authn.Sign("users", "jane", time.Hour)
+	if err != nil {
+		test.Fatalf("Cannot sign: %v", err)
+	}
+	p := authn.Authenticate(token)
+	if p == nil || p.GetYRN() != "users.jane" || p.GetCredentials() != token || p.GetHTTPHeaderName() != "X-Token" {
+		test.Fatalf("Cannot authenticate a token: %v", p)
+	}
+	oldToken, _ := old.Sign("users", "joe", time.Hour)
+	if p := authn.Authenticate(oldToken); p == nil || p.GetName() != "joe" {
+		test.Errorf("Expected a token signed with an old key to be accepted")
+	}
+	if old.Authenticate(token) != nil {
+		test.Errorf("Expected a token signed with another key to be rejected")
+	}
+	for _, bad := range []string{token[:len(token)-1], "d=users;n=admin" + token[len("d=users;n=jane"):], "", "s="} {
+		if authn.Authenticate(bad) != nil {
+			test.Errorf("Expected a bad token to be rejected: %q", bad)
+		}
+	}
+	now = now.Add(2 * time.Hour)
+	if authn.Authenticate(token) != nil {
+		test.Errorf("Expected an expired token to be rejected")
+	}
+	if _, err := authn.Sign("users", "a;e=0", time.Hour); err == nil {
+		test.Errorf("Expected an error signing a bad name")
+	}
+}
@@ -0,0 +1,242 @@
+// Copyright 2015 Yahoo Inc.
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package rdl
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ParamBinder converts the string values of path, query and header params to values of their type in a
+// schema, and checks them against its constraints. Params of any base type can be bound, as well as enums,
+// constrained types and arrays of them, whose items are repeated values or comma-separated.
+// Errors are 400 ResourceErrors.
+type ParamBinder struct {
+	checker *validator
+}
+
+// NewParamBinder returns a ParamBinder for the types of the schema.
+func NewParamBinder(schema *Schema) *ParamBinder {
+	return &ParamBinder{checker: &validator{schema: schema, registry: NewTypeRegistry(schema)}}
+}
+
+var paramGoTypes = map[BaseType]reflect.Type{
+	BaseTypeBool:      reflect.TypeOf(false),
+	BaseTypeInt8:      reflect.TypeOf(int8(0)),
+	BaseTypeInt16:     reflect.TypeOf(int16(0)),
+	BaseTypeInt32:     reflect.TypeOf(int32(0)),
+	BaseTypeInt64:     reflect.TypeOf(int64(0)),
+	BaseTypeFloat32:   reflect.TypeOf(float32(0)),
+	BaseTypeFloat64:   reflect.TypeOf(float64(0)),
+	BaseTypeString:    reflect.TypeOf(""),
+	BaseTypeEnum:      reflect.TypeOf(""),
+	BaseTypeSymbol:    reflect.TypeOf(Symbol("")),
+	BaseTypeUUID:      reflect.TypeOf(UUID(nil)),
+	BaseTypeTimestamp: reflect.TypeOf(Timestamp{}),
+}
+
+// Bind returns the value of the input in the request, as the Go type of its base type: int32 for an
+// Int32, Timestamp for a Timestamp, string for an enum or a String, a slice of those for an array, and so
+// on. The path params are the values matched by the path template. An absent param has its default value,
+// false for a flag, and nil if it is optional. Body and context inputs are not params, and are nil.
+func (binder *ParamBinder) Bind(req *http.Request, in *ResourceInput, pathParams map[string]string) (interface{}, error) {
+	if !isParam(in) {
+		return nil, nil
+	}
+	values := paramValues(req, req.URL.Query(), in, pathParams)
+	var value interface{}
+	var err error
+	if values == nil {
+		value, err = absentParam(in)
+	} else {
+		value, err = binder.generic(in, values)
+	}
+	if value == nil || err != nil {
+		return nil, err
+	}
+	return binder.typed(binder.checker.registry.FindType(in.Type), value)
+}
+
+// Parse returns the value of the input for its string values, as Bind does.
+func (binder *ParamBinder) Parse(in *ResourceInput, values []string) (interface{}, error) {
+	if len(values) == 0 {
+		return nil, &ResourceError{400, "Missing value for parameter '" + string(in.Name) + "'"}
+	}
+	value, err := binder.generic(in, values)
+	if err != nil {
+		return nil, err
+	}
+	return binder.typed(binder.checker.registry.FindType(in.Type), value)
+}
+
+func isParam(in *ResourceInput) bool {
+	return in.Context == "" && (in.PathParam || in.QueryParam != "" || in.Header != "")
+}
+
+// paramValues returns the string values of a param in the request, or nil if it is absent. A flag without
+// value is "true".
+func paramValues(req *http.Request, query url.Values, in *ResourceInput, pathParams map[string]string) []string {
+	switch {
+	case in.PathParam:
+		if s, ok := pathParams[string(in.Name)]; ok {
+			return []string{s}
+		}
+	case in.QueryParam != "":
+		values := query[in.QueryParam]
+		if in.Flag && len(values) == 1 && values[0] == "" {
+			return []string{"true"}
+		}
+		return values
+	case in.Header != "":
+		if values := req.Header.Values(in.Header); len(values) > 0 {
+			return values
+		}
+	}
+	return nil
+}
+
+// absentParam returns the generic value of an absent param: its default, false for a flag, nil if it is
+// optional, and otherwise an error.
+func absentParam(in *ResourceInput) (interface{}, error) {
+	switch {
+	case in.Default != nil:
+		return in.Default, nil
+	case in.Flag:
+		return false, nil
+	case in.Optional:
+		return nil, nil
+	}
+	return nil, &ResourceError{400, "Missing required parameter '" + string(in.Name) + "'"}
+}
+
+// generic converts the string values of a param to generic data, the way encoding/json decodes it, except
+// that integers are int64 so that they keep their precision, and validates it.
+func (binder *ParamBinder) generic(in *ResourceInput, values []string) (interface{}, error) {
+	reg := binder.checker.registry
+	t := reg.FindType(in.Type)
+	if t == nil {
+		return nil, &ResourceError{500, fmt.Sprintf("Parameter '%s' has an unknown type: %s", in.Name, in.Type)}
+	}
+	var value interface{}
+	if reg.BaseType(t) == BaseTypeArray {
+		items := binder.arrayItems(t)
+		list := []interface{}{}
+		for _, v := range values {
+			for _, s := range strings.Split(v, ",") {
+				item, err := binder.scalar(in, items, s)
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, item)
+			}
+		}
+		value = list
+	} else {
+		raw := values[0]
+		if in.Header != "" {
+			raw = strings.Join(values, ",")
+		}
+		item, err := binder.scalar(in, in.Type, raw)
+		if err != nil {
+			return nil, err
+		}
+		value = item
+	}
+	if v := binder.checker.validate(t, validationData(value), string(in.Name)); !v.Valid {
+		return nil, badParam(in, strings.Join(values, ","), v.Error)
+	}
+	return value, nil
+}
+
+// validationData returns the generic data of a param with its integers as float64, the only numbers the
+// validator takes.
+func validationData(value interface{}) interface{} {
+	switch v := value.(type) {
+	case int64:
+		return float64(v)
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, item := range v {
+			list[i] = validationData(item)
+		}
+		return list
+	}
+	return value
+}
+
+func (binder *ParamBinder) arrayItems(t *Type) TypeRef {
+	t = binder.checker.resolveAliases(t, "")
+	if t.Variant == TypeVariantArrayTypeDef && t.ArrayTypeDef.Items != "" {
+		return t.ArrayTypeDef.Items
+	}
+	return "String"
+}
+
+func badParam(in *ResourceInput, raw string, reason string) *ResourceError {
+	msg := fmt.Sprintf("Parameter '%s' is not of type %s: %s", in.Name, in.Type, raw)
+	if reason != "" {
+		msg += " (" + reason + ")"
+	}
+	return &ResourceError{400, msg}
+}
+
+// scalar converts the string value of a param, or of an item of an array param, to generic data.
+func (binder *ParamBinder) scalar(in *ResourceInput, typename TypeRef, raw string) (interface{}, error) {
+	switch bt := binder.checker.registry.FindBaseType(typename); bt {
+	case BaseTypeBool:
+		switch raw {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		}
+	case BaseTypeInt8, BaseTypeInt16, BaseTypeInt32, BaseTypeInt64:
+		if n, err := strconv.ParseInt(raw, 10, paramGoTypes[bt].Bits()); err == nil {
+			return n, nil
+		}
+	case BaseTypeFloat32, BaseTypeFloat64:
+		if n, err := strconv.ParseFloat(raw, paramGoTypes[bt].Bits()); err == nil {
+			return n, nil
+		}
+	case BaseTypeString, BaseTypeEnum, BaseTypeUUID, BaseTypeTimestamp, BaseTypeSymbol:
+		return raw, nil
+	default:
+		return nil, &ResourceError{400, fmt.Sprintf("Parameter '%s' has an unsupported type: %s", in.Name, in.Type)}
+	}
+	return nil, badParam(in, raw, "")
+}
+
+// typed converts the generic value of a param of the type to its Go type.
+func (binder *ParamBinder) typed(t *Type, value interface{}) (interface{}, error) {
+	reg := binder.checker.registry
+	bt := reg.BaseType(t)
+	if bt == BaseTypeArray {
+		items := reg.FindType(binder.arrayItems(t))
+		list := value.([]interface{})
+		slice := reflect.MakeSlice(reflect.SliceOf(paramGoTypes[reg.BaseType(items)]), len(list), len(list))
+		for i, item := range list {
+			v, err := binder.typed(items, item)
+			if err != nil {
+				return nil, err
+			}
+			slice.Index(i).Set(reflect.ValueOf(v))
+		}
+		return slice.Interface(), nil
+	}
+	switch bt {
+	case BaseTypeTimestamp:
+		ts, err := TimestampParse(value.(string))
+		if err != nil {
+			return nil, &ResourceError{400, "Bad Timestamp: " + value.(string)}
+		}
+		return ts, nil
+	case BaseTypeUUID:
+		return ParseUUID(value.(string)), nil
+	}
+	return reflect.ValueOf(value).Convert(paramGoTypes[bt]).Interface(), nil
+}
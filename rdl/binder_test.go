@@ -0,0 +1,140 @@
+// Copyright 2015 Yahoo Inc.
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package rdl
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const binderTestRDL = `
+type Code String (pattern="[A-Z]{3}");
+type Codes Array<Code>;
+type Sizes Array<Int16> (maxSize=3);
+type Color Enum { RED, GREEN }
+resource String GET "/items/{id}?small={small}&big={big}&ratio={ratio}&flag&color={color}&since={since}&codes={codes}&sizes={sizes}&sym={sym}&opt={opt}" {
+    UUID id;
+    Int8 small (optional);
+    Int64 big (default=7);
+    Float32 ratio (optional);
+    Bool flag;
+    Color color (default=GREEN);
+    Timestamp since (optional);
+    Codes codes (optional);
+    Sizes sizes (optional);
+    Symbol sym (optional);
+    Bool opt (optional);
+    String trace (header="X-Trace", optional);
+}
+`
+
+func TestParamBinder(test *testing.T) {
+	schema, err := parseRDLString(binderTestRDL)
+	if err != nil {
+		test.Fatalf("Cannot parse: %v", err)
+	}
+	binder := NewParamBinder(schema)
+	inputs := make(map[string]*ResourceInput)
+	for _, in := range schema.Resources[0].Inputs {
+		inputs[string(in.Name)] = in
+	}
+	bind := func(query string) map[string]interface{} {
+		req := httptest.NewRequest("GET", "/items/x?"+query, nil)
+		req.Header.Add("X-Trace", "a")
+		req.Header.Add("X-Trace", "b")
+		values := make(map[string]interface{})
+		for name, in := range inputs {
+			v, err := binder.Bind(req, in, map[string]string{"id": "00112233-4455-6677-8899-aabbccddeeff"})
+			if err != nil {
+				test.Fatalf("Cannot bind %s of %q: %v", name, query, err)
+			}
+			if v != nil {
+				values[name] = v
+			}
+		}
+		return values
+	}
+	values := bind("")
+	expected := map[string]interface{}{
+		"id":    ParseUUID("00112233-4455-6677-8899-aabbccddeeff"),
+		"big":   int64(7),
+		"flag":  false,
+		"color": "GREEN",
+		"trace": "a,b",
+	}
+	if !reflect.DeepEqual(values, expected) {
+		test.Errorf("Unexpected defaults: %#v", values)
+	}
+	values = bind("small=-5&big=9000000000&ratio=0.5&flag&color=RED&since=2020-01-02T03:04:05.000Z&codes=ABC,DEF&codes=GHI&sizes=1,2&sym=x&opt=false")
+	since, _ := TimestampParse("2020-01-02T03:04:05.000Z")
+	expected = map[string]interface{}{
+		"id":    ParseUUID("00112233-4455-6677-8899-aabbccddeeff"),
+		"small": int8(-5),
+		"big":   int64(9000000000),
+		"ratio": float32(0.5),
+		"flag":  true,
+		"color": "RED",
+		"since": since,
+		"codes": []string{"ABC", "DEF", "GHI"},
+		"sizes": []int16{1, 2},
+		"sym":   Symbol("x"),
+		"opt":   false,
+		"trace": "a,b",
+	}
+	if !reflect.DeepEqual(values, expected) {
+		test.Errorf("Unexpected values: %#v", values)
+	}
+
+	//Int64 params keep their precision
+	for raw, n := range map[string]int64{
+		"9007199254740993":     9007199254740993,
+		"9223372036854775807":  9223372036854775807,
+		"-9223372036854775808": -9223372036854775808,
+	} {
+		if v, err := binder.Parse(inputs["big"], []string{raw}); err != nil || v != n {
+			test.Errorf("Expected big=%s to be bound as %d, got %#v, %v", raw, n, v, err)
+		}
+	}
+	if _, err := binder.Parse(inputs["big"], []string{"9223372036854775808"}); err == nil {
+		test.Errorf("Expected an error for an Int64 param out of range")
+	}
+
+	for name, raw := range map[string]string{
+		"small": "300",
+		"big":   "1.5",
+		"ratio": "x",
+		"flag":  "yes",
+		"color": "BLUE",
+		"since": "yesterday",
+		"codes": "ABC,de",
+		"sizes": "1,2,3,4",
+		"opt":   "",
+	} {
+		_, err := binder.Parse(inputs[name], []string{raw})
+		e, ok := err.(*ResourceError)
+		if !ok || e.Code != 400 || !strings.HasPrefix(e.Message, "Parameter '"+name+"' is not of type ") {
+			test.Errorf("Expected a 400 error parsing %s=%q, got %v", name, raw, err)
+		}
+	}
+	req := httptest.NewRequest("GET", "/items/x", nil)
+	if _, err := binder.Bind(req, inputs["id"], nil); err == nil || err.(*ResourceError).Message != "Missing required parameter 'id'" {
+		test.Errorf("Expected an error for a missing required param, got %v", err)
+	}
+}
+
+func TestOptionalBoolParam(test *testing.T) {
+	req := httptest.NewRequest("GET", "/?other=1", nil)
+	if b, err := OptionalBoolParam(req, "flag"); b != nil || err != nil {
+		test.Errorf("Expected nil for an absent Bool param, got %v, %v", b, err)
+	}
+	if b, _ := BoolParam(req, "flag", true); !b {
+		test.Errorf("Expected the default value of an absent Bool param")
+	}
+	req = httptest.NewRequest("GET", "/?flag=false", nil)
+	if b, _ := BoolParam(req, "flag", true); b {
+		test.Errorf("Expected the value of a present Bool param")
+	}
+}
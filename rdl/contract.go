@@ -0,0 +1,277 @@
+// Copyright 2015 Yahoo Inc.
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package rdl
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// ContractMode selects what a ContractVerifier does with violations.
+type ContractMode int
+
+const (
+	// ContractObserve reports violations, and lets the traffic through unchanged.
+	ContractObserve ContractMode = iota
+
+	// ContractEnforce reports violations, and rejects them: a request that violates the contract gets an
+	// error response without reaching the server, and a response that violates it is replaced by a 500
+	// ResourceError.
+	ContractEnforce
+)
+
+// ContractViolation is a difference between the traffic of a server and its schema. Status is the status
+// code of the response, or 0 for a violation of the request. Resource is empty if no resource matches.
+type ContractViolation struct {
+	Method   string
+	Path     string
+	Resource Identifier
+	Status   int
+	Message  string
+}
+
+func (v *ContractViolation) Error() string {
+	s := v.Method + " " + v.Path
+	if v.Resource != "" {
+		s += " (" + string(v.Resource) + ")"
+	}
+	if v.Status != 0 {
+		s += " " + strconv.Itoa(v.Status)
+	}
+	return s + ": " + v.Message
+}
+
+// ContractSink receives the violations found by a ContractVerifier, i.e. to log them or count them.
+// It may be called concurrently.
+type ContractSink func(v *ContractViolation)
+
+// LogContractSink returns a ContractSink that logs violations, with the standard logger if it is nil.
+func LogContractSink(logger *log.Logger) ContractSink {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return func(v *ContractViolation) {
+		logger.Printf("contract violation: %v", v)
+	}
+}
+
+// ContractVerifier is an HTTP middleware that checks live traffic against a schema: the params and body
+// of requests against the inputs of the resource, the status of responses against the expected,
+// alternative and exception codes of the resource, and their body against the type of the resource or of
+// the exception. Non-optional output headers are checked on successful responses.
+type ContractVerifier struct {
+	router *Router
+	mode   ContractMode
+	sink   ContractSink
+}
+
+// NewContractVerifier returns a ContractVerifier for the schema, that reports violations to the sink.
+func NewContractVerifier(schema *Schema, mode ContractMode, sink ContractSink) (*ContractVerifier, error) {
+	router, err := NewRouter(schema, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &ContractVerifier{router: router, mode: mode, sink: sink}, nil
+}
+
+// SetMaxBodySize sets the limit of the size of request bodies that are verified, DefaultMaxBodySize by
+// default. Larger bodies are violations, rejected with a 413 in enforce mode. In observe mode, response
+// bodies are only captured up to the limit too, and larger ones are not verified.
+func (verifier *ContractVerifier) SetMaxBodySize(n int64) {
+	verifier.router.SetMaxBodySize(n)
+}
+
+// Handler returns the handler that verifies the traffic of next.
+func (verifier *ContractVerifier) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		verifier.serve(w, req, next)
+	})
+}
+
+func (verifier *ContractVerifier) report(req *http.Request, r *Resource, status int, msg string) {
+	v := &ContractViolation{Method: req.Method, Path: req.URL.Path, Status: status, Message: msg}
+	if r != nil {
+		v.Resource = ResourceName(r)
+	}
+	if verifier.sink != nil {
+		verifier.sink(v)
+	}
+}
+
+func (verifier *ContractVerifier) serve(w http.ResponseWriter, req *http.Request, next http.Handler) {
+	rt, params, err := verifier.router.lookup(req.Method, req.URL.EscapedPath())
+	if err != nil {
+		verifier.report(req, nil, 0, "No resource matches the request")
+		if verifier.mode == ContractEnforce {
+			ErrorResponse(w, err)
+			return
+		}
+		next.ServeHTTP(w, req)
+		return
+	}
+	r := rt.resource
+	var body []byte
+	if req.Body != nil {
+		//a body over the limit is not verified, but still passed on in observe mode
+		original := req.Body
+		body, err = io.ReadAll(io.LimitReader(original, verifier.router.maxBody+1))
+		if err != nil {
+			ErrorResponse(w, &ResourceError{400, "Cannot read the request body: " + err.Error()})
+			return
+		}
+		if int64(len(body)) > verifier.router.maxBody {
+			err = &ResourceError{http.StatusRequestEntityTooLarge, fmt.Sprintf("Request body larger than %d bytes", verifier.router.maxBody)}
+			verifier.report(req, r, 0, err.(*ResourceError).Message)
+			if verifier.mode == ContractEnforce {
+				ErrorResponse(w, err)
+				return
+			}
+			req.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.MultiReader(bytes.NewReader(body), original), original}
+			verifier.respond(w, req, r, next)
+			return
+		}
+		original.Close()
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	_, err = verifier.router.inputs(r, req, params)
+	if req.Body != nil {
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	if err != nil {
+		verifier.report(req, r, 0, err.(*ResourceError).Message)
+		if verifier.mode == ContractEnforce {
+			ErrorResponse(w, err)
+			return
+		}
+	}
+	verifier.respond(w, req, r, next)
+}
+
+// respond serves the request with next, and checks its response against the resource.
+func (verifier *ContractVerifier) respond(w http.ResponseWriter, req *http.Request, r *Resource, next http.Handler) {
+	cw := &contractWriter{ResponseWriter: w, buffered: verifier.mode == ContractEnforce, limit: verifier.router.maxBody}
+	next.ServeHTTP(cw, req)
+	if cw.status == 0 {
+		cw.status = http.StatusOK
+	}
+	violations := verifier.response(r, cw)
+	for _, msg := range violations {
+		verifier.report(req, r, cw.status, msg)
+	}
+	if !cw.buffered {
+		return
+	}
+	if len(violations) > 0 {
+		for name := range w.Header() {
+			delete(w.Header(), name)
+		}
+		ErrorResponse(w, &ResourceError{500, "Response violates the contract of the resource: " + violations[0]})
+		return
+	}
+	w.WriteHeader(cw.status)
+	w.Write(cw.body.Bytes())
+}
+
+// response checks the response captured by the writer against the resource.
+func (verifier *ContractVerifier) response(r *Resource, cw *contractWriter) []string {
+	code, typename, err := declaredStatus(r, strconv.Itoa(cw.status))
+	if err != nil {
+		return []string{fmt.Sprintf("Undeclared response status: %d", cw.status)}
+	}
+	var violations []string
+	if code < 400 {
+		for _, out := range r.Outputs {
+			if !out.Optional && cw.Header().Get(out.Header) == "" {
+				violations = append(violations, "Missing output header: "+out.Header)
+			}
+		}
+	}
+	switch code {
+	case http.StatusNoContent, http.StatusNotModified:
+		if cw.body.Len() != 0 {
+			violations = append(violations, "Unexpected response body")
+		}
+		return violations
+	}
+	var body interface{}
+	if cw.body.Len() == 0 {
+		return append(violations, "Missing response body")
+	}
+	if cw.truncated {
+		return violations
+	}
+	if err := json.Unmarshal(cw.body.Bytes(), &body); err != nil {
+		return append(violations, "Bad response body: "+err.Error())
+	}
+	if v := validateWithValidator(verifier.router.checker, string(typename), body); !v.Valid {
+		violations = append(violations, fmt.Sprintf("Invalid %s in response body: %s (%s)", typename, v.Error, v.Context))
+	}
+	return violations
+}
+
+// contractWriter captures the status and the body of a response. Unless it is buffered, the response is
+// passed through as it is written, flushes included, and only the first bytes of the body up to the limit
+// are captured: a larger body is not verified.
+type contractWriter struct {
+	http.ResponseWriter
+	buffered  bool
+	limit     int64
+	truncated bool
+	status    int
+	body      bytes.Buffer
+}
+
+func (cw *contractWriter) WriteHeader(status int) {
+	if cw.status != 0 {
+		return
+	}
+	cw.status = status
+	if !cw.buffered {
+		cw.ResponseWriter.WriteHeader(status)
+	}
+}
+
+func (cw *contractWriter) Write(b []byte) (int, error) {
+	if cw.status == 0 {
+		cw.WriteHeader(http.StatusOK)
+	}
+	if cw.buffered {
+		return cw.body.Write(b)
+	}
+	if room := cw.limit - int64(cw.body.Len()); cw.truncated || int64(len(b)) > room {
+		if !cw.truncated {
+			cw.body.Write(b[:room])
+			cw.truncated = true
+		}
+	} else {
+		cw.body.Write(b)
+	}
+	return cw.ResponseWriter.Write(b)
+}
+
+// Flush flushes the response if it is passed through and the underlying writer can flush it.
+func (cw *contractWriter) Flush() {
+	if cw.buffered {
+		return
+	}
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		if cw.status == 0 {
+			cw.WriteHeader(http.StatusOK)
+		}
+		f.Flush()
+	}
+}
+
+// Unwrap returns the underlying writer, for http.ResponseController.
+func (cw *contractWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
@@ -0,0 +1,157 @@
+// Copyright 2015 Yahoo Inc.
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package rdl
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// driftingServer answers the requests of the mock test schema, correctly or not depending on the id.
+func driftingServer(w http.ResponseWriter, req *http.Request) {
+	switch strings.TrimPrefix(req.URL.Path, "/contacts/") {
+	case "ok1":
+		w.Header().Set("ETag", "v1")
+		JSONResponse(w, 200, map[string]interface{}{"id": "ok1", "name": "A", "color": "RED", "age": 20})
+	case "missing1":
+		JSONResponse(w, 404, ResourceError{404, "Not Found"})
+	case "young1":
+		w.Header().Set("ETag", "v1")
+		JSONResponse(w, 200, map[string]interface{}{"id": "young1", "name": "A", "color": "RED", "age": 2})
+	case "conflict1":
+		JSONResponse(w, 409, ResourceError{409, "Conflict"})
+	case "noetag1":
+		JSONResponse(w, 200, map[string]interface{}{"id": "noetag1", "name": "A", "color": "RED", "age": 20})
+	default:
+		w.Header().Set("ETag", "v1")
+		JSONResponse(w, 200, map[string]interface{}{"id": "x1", "name": "A", "color": "RED", "age": 20})
+	}
+}
+
+func TestContractVerifier(test *testing.T) {
+	schema, err := parseRDLString(mockTestRDL)
+	if err != nil {
+		test.Fatalf("Cannot parse: %v", err)
+	}
+	var mutex sync.Mutex
+	var violations []*ContractViolation
+	sink := func(v *ContractViolation) {
+		mutex.Lock()
+		violations = append(violations, v)
+		mutex.Unlock()
+	}
+	cases := []struct {
+		path       string
+		violations int
+		enforced   int
+	}{
+		{"/contacts/ok1", 0, 200},
+		{"/contacts/missing1", 0, 404},
+		{"/contacts/young1", 1, 500},
+		{"/contacts/conflict1", 1, 500},
+		{"/contacts/noetag1", 1, 500},
+		{"/contacts/BAD", 1, 400},
+		{"/other", 1, 404},
+	}
+	for _, mode := range []ContractMode{ContractObserve, ContractEnforce} {
+		verifier, err := NewContractVerifier(schema, mode, sink)
+		if err != nil {
+			test.Fatalf("Cannot create verifier: %v", err)
+		}
+		handler := verifier.Handler(http.HandlerFunc(driftingServer))
+		for _, c := range cases {
+			violations = nil
+			direct := httptest.NewRecorder()
+			driftingServer(direct, httptest.NewRequest("GET", c.path, nil))
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest("GET", c.path, nil))
+			if len(violations) != c.violations {
+				test.Errorf("%s: expected %d violations, got %v", c.path, c.violations, violations)
+			}
+			if mode == ContractObserve && (w.Code != direct.Code || w.Body.String() != direct.Body.String()) {
+				test.Errorf("%s: expected the response to be unchanged in observe mode, got %d", c.path, w.Code)
+			}
+			if mode == ContractEnforce && w.Code != c.enforced {
+				test.Errorf("%s: expected status %d in enforce mode, got %d: %s", c.path, c.enforced, w.Code, w.Body)
+			}
+		}
+	}
+
+	//the request body is checked, and still available to the server
+	verifier, _ := NewContractVerifier(schema, ContractEnforce, sink)
+	var received string
+	server := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var buf bytes.Buffer
+		buf.ReadFrom(req.Body)
+		received = buf.String()
+		w.WriteHeader(204)
+	})
+	handler := verifier.Handler(server)
+	violations = nil
+	contact := `{"id":"a1","name":"A","color":"RED","age":30}`
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("PUT", "/contacts/a1", strings.NewReader(contact)))
+	if w.Code != 204 || received != contact || len(violations) != 0 {
+		test.Errorf("Unexpected response to a valid request: %d, %q, %v", w.Code, received, violations)
+	}
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("PUT", "/contacts/a1", strings.NewReader(`{"id":"a1"}`)))
+	if w.Code != 400 || len(violations) != 1 || violations[0].Status != 0 || violations[0].Resource != "putContact" {
+		test.Errorf("Expected an invalid request body to be rejected, got %d, %v", w.Code, violations)
+	}
+
+	//a body over the limit is rejected in enforce mode, and passed on unverified in observe mode
+	verifier.SetMaxBodySize(16)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("PUT", "/contacts/a1", strings.NewReader(contact)))
+	if w.Code != 413 {
+		test.Errorf("Expected a body over the limit to be rejected, got %d", w.Code)
+	}
+	observer, _ := NewContractVerifier(schema, ContractObserve, sink)
+	observer.SetMaxBodySize(16)
+	violations, received = nil, ""
+	w = httptest.NewRecorder()
+	observer.Handler(server).ServeHTTP(w, httptest.NewRequest("PUT", "/contacts/a1", strings.NewReader(contact)))
+	if w.Code != 204 || received != contact || len(violations) != 1 {
+		test.Errorf("Unexpected observation of a body over the limit: %q, %v", received, violations)
+	}
+
+	var out bytes.Buffer
+	LogContractSink(log.New(&out, "", 0))(violations[0])
+	if !strings.HasPrefix(out.String(), "contract violation: PUT /contacts/a1 (putContact): ") {
+		test.Errorf("Unexpected log of a violation: %q", out.String())
+	}
+}
+
+func TestContractVerifierStreaming(test *testing.T) {
+	schema, _ := parseRDLString(mockTestRDL)
+	var violations []*ContractViolation
+	verifier, _ := NewContractVerifier(schema, ContractObserve, func(v *ContractViolation) {
+		violations = append(violations, v)
+	})
+	verifier.SetMaxBodySize(16)
+	handler := verifier.Handler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("ETag", "1")
+		f, ok := w.(http.Flusher)
+		if !ok {
+			test.Fatalf("Expected the verifier to pass flushes through")
+		}
+		w.Write([]byte(`{"id":"a1","name":"`))
+		f.Flush()
+		w.Write([]byte(strings.Repeat("x", 100) + `","color":"RED","age":30}`))
+	}))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/contacts/a1", nil))
+	if !w.Flushed || w.Code != 200 || w.Body.Len() != 19+100+25 {
+		test.Errorf("Expected the response to be flushed and passed through, got %d %q", w.Code, w.Body)
+	}
+	if len(violations) != 0 {
+		test.Errorf("Expected a body over the limit not to be verified, got %v", violations)
+	}
+}
@@ -0,0 +1,155 @@
+// Copyright 2015 Yahoo Inc.
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package rdl
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SimplePrincipal is a Principal with fixed fields. Unless it is set, its YRN is "domain.name", or the
+// name if there is no domain.
+type SimplePrincipal struct {
+	Domain      string
+	Name        string
+	YRN         string
+	Credentials string
+	Header      string
+}
+
+func (p *SimplePrincipal) GetDomain() string {
+	return p.Domain
+}
+
+func (p *SimplePrincipal) GetName() string {
+	return p.Name
+}
+
+func (p *SimplePrincipal) GetYRN() string {
+	if p.YRN != "" {
+		return p.YRN
+	}
+	if p.Domain == "" {
+		return p.Name
+	}
+	return p.Domain + "." + p.Name
+}
+
+func (p *SimplePrincipal) GetCredentials() string {
+	return p.Credentials
+}
+
+func (p *SimplePrincipal) GetHTTPHeaderName() string {
+	return p.Header
+}
+
+// APIKeyAuthenticator authenticates static API keys. Keys are only kept as SHA-256 hashes.
+type APIKeyAuthenticator struct {
+	header string
+	mutex  sync.RWMutex
+	keys   map[[sha256.Size]byte]*SimplePrincipal
+}
+
+// NewAPIKeyAuthenticator returns an APIKeyAuthenticator for keys in the HTTP header, without keys.
+func NewAPIKeyAuthenticator(header string) *APIKeyAuthenticator {
+	return &APIKeyAuthenticator{header: header, keys: make(map[[sha256.Size]byte]*SimplePrincipal)}
+}
+
+// AddKey adds a key, that authenticates as the domain and name.
+func (a *APIKeyAuthenticator) AddKey(key string, domain string, name string) *APIKeyAuthenticator {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.keys[sha256.Sum256([]byte(key))] = &SimplePrincipal{Domain: domain, Name: name, Header: a.header}
+	return a
+}
+
+// RemoveKey revokes a key.
+func (a *APIKeyAuthenticator) RemoveKey(key string) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	delete(a.keys, sha256.Sum256([]byte(key)))
+}
+
+func (a *APIKeyAuthenticator) Authenticate(key string) Principal {
+	a.mutex.RLock()
+	p, ok := a.keys[sha256.Sum256([]byte(key))]
+	a.mutex.RUnlock()
+	if !ok {
+		return nil
+	}
+	principal := *p
+	principal.Credentials = key
+	return &principal
+}
+
+func (a *APIKeyAuthenticator) HTTPHeader() string {
+	return a.header
+}
+
+// HMACAuthenticator authenticates tokens signed with HMAC-SHA256 by a shared key. A token has the form
+// "d=<domain>;n=<name>;e=<expiry in seconds since the epoch>;s=<signature>", the signature being the
+// unpadded base64url encoding of the HMAC of everything before ";s=".
+type HMACAuthenticator struct {
+	header string
+	keys   [][]byte
+
+	// Now returns the current time, to check the expiry of tokens.
+	Now func() time.Time
+}
+
+// NewHMACAuthenticator returns an HMACAuthenticator for tokens in the HTTP header. Tokens are signed with
+// the key, and the old keys are still accepted, to rotate keys.
+func NewHMACAuthenticator(header string, key []byte, oldKeys ...[]byte) *HMACAuthenticator {
+	return &HMACAuthenticator{header: header, keys: append([][]byte{key}, oldKeys...), Now: time.Now}
+}
+
+func hmacSignature(key []byte, data string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Sign returns a token for the domain and name, that expires after the ttl.
+func (a *HMACAuthenticator) Sign(domain string, name string, ttl time.Duration) (string, error) {
+	if strings.ContainsAny(domain+name, ";=") || name == "" {
+		return "", fmt.Errorf("Bad principal for a token: %q.%q", domain, name)
+	}
+	data := fmt.Sprintf("d=%s;n=%s;e=%d", domain, name, a.Now().Add(ttl).Unix())
+	return data + ";s=" + hmacSignature(a.keys[0], data), nil
+}
+
+func (a *HMACAuthenticator) Authenticate(token string) Principal {
+	i := strings.LastIndex(token, ";s=")
+	if i < 0 {
+		return nil
+	}
+	data, signature := token[:i], token[i+3:]
+	valid := false
+	for _, key := range a.keys {
+		valid = valid || hmac.Equal([]byte(signature), []byte(hmacSignature(key, data)))
+	}
+	if !valid {
+		return nil
+	}
+	fields := make(map[string]string)
+	for _, field := range strings.Split(data, ";") {
+		k, v, _ := strings.Cut(field, "=")
+		fields[k] = v
+	}
+	expiry, err := strconv.ParseInt(fields["e"], 10, 64)
+	if err != nil || a.Now().Unix() >= expiry || fields["n"] == "" {
+		return nil
+	}
+	return &SimplePrincipal{Domain: fields["d"], Name: fields["n"], Credentials: token, Header: a.header}
+}
+
+func (a *HMACAuthenticator) HTTPHeader() string {
+	return a.header
+}
@@ -0,0 +1,330 @@
+// Copyright 2015 Yahoo Inc.
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package rdl
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+)
+
+// ExampleValue returns example data for the named type, in the generic form encoding/json produces, that
+// Validate accepts. An x_example annotation on the type or field is used when present, then field defaults,
+// and otherwise a value is synthesized from the type's constraints: the first enum symbol or String value,
+// a string matching the pattern, a number within min and max, one item for arrays and maps, and the first
+// variant of a union. Optional fields are included, except where they would recurse into a type already
+// being generated.
+func ExampleValue(reg TypeRegistry, typename TypeRef) (interface{}, error) {
+	t := reg.FindType(typename)
+	if t == nil {
+		return nil, fmt.Errorf("no such type: %s", typename)
+	}
+	gen := &exampleGenerator{registry: reg, active: make(map[TypeName]bool)}
+	return gen.example(t), nil
+}
+
+type exampleGenerator struct {
+	registry TypeRegistry
+	active   map[TypeName]bool
+}
+
+func annotatedExample(anno map[ExtendedAnnotation]string) (interface{}, bool) {
+	if anno == nil || anno["x_expectfail"] == "true" {
+		return nil, false
+	}
+	s, ok := anno["x_example"]
+	if !ok {
+		return nil, false
+	}
+	var value interface{}
+	if err := json.Unmarshal([]byte(s), &value); err != nil {
+		return s, true
+	}
+	return value, true
+}
+
+func (gen *exampleGenerator) example(t *Type) interface{} {
+	if v, ok := annotatedExample(TypeAnnotations(t)); ok {
+		return v
+	}
+	tName, tSuper, _ := TypeInfo(t)
+	switch t.Variant {
+	case TypeVariantAliasTypeDef:
+		return gen.exampleOf(tSuper)
+	case TypeVariantStructTypeDef:
+		return gen.structExample(t)
+	case TypeVariantArrayTypeDef:
+		td := t.ArrayTypeDef
+		return gen.arrayExample(td.Items, td.Size, td.MinSize, td.MaxSize)
+	case TypeVariantMapTypeDef:
+		td := t.MapTypeDef
+		return gen.mapExample(td.Keys, td.Items, td.Size, td.MinSize, td.MaxSize)
+	case TypeVariantEnumTypeDef:
+		if len(t.EnumTypeDef.Elements) > 0 {
+			return string(t.EnumTypeDef.Elements[0].Symbol)
+		}
+		return ""
+	case TypeVariantUnionTypeDef:
+		if len(t.UnionTypeDef.Variants) == 0 {
+			return map[string]interface{}{}
+		}
+		v := t.UnionTypeDef.Variants[0]
+		return map[string]interface{}{string(v): gen.exampleOf(v)}
+	case TypeVariantStringTypeDef:
+		return gen.stringExample(t, tName)
+	case TypeVariantNumberTypeDef:
+		return gen.numberExample(t)
+	case TypeVariantBytesTypeDef:
+		return "AAECAw=="
+	case TypeVariantBaseType:
+		return gen.baseTypeExample(*t.BaseType)
+	}
+	return nil
+}
+
+func (gen *exampleGenerator) exampleOf(ref TypeRef) interface{} {
+	t := gen.registry.FindType(ref)
+	if t == nil {
+		return nil
+	}
+	return gen.example(t)
+}
+
+func (gen *exampleGenerator) baseTypeExample(bt BaseType) interface{} {
+	switch bt {
+	case BaseTypeBool:
+		return true
+	case BaseTypeInt8, BaseTypeInt16, BaseTypeInt32, BaseTypeInt64:
+		return float64(1)
+	case BaseTypeFloat32, BaseTypeFloat64:
+		return 1.5
+	case BaseTypeString:
+		return "string"
+	case BaseTypeSymbol:
+		return "symbol"
+	case BaseTypeBytes:
+		return "AAECAw=="
+	case BaseTypeTimestamp:
+		return "2015-03-14T15:59:26.535Z"
+	case BaseTypeUUID:
+		return "1f4e5cc8-5c07-4a69-a8a5-6e1c2a7a4e1b"
+	case BaseTypeArray:
+		return []interface{}{}
+	case BaseTypeMap, BaseTypeStruct, BaseTypeAny:
+		return map[string]interface{}{}
+	}
+	return nil
+}
+
+func (gen *exampleGenerator) structExample(t *Type) interface{} {
+	tName, _, _ := TypeInfo(t)
+	if gen.active[tName] {
+		return map[string]interface{}{}
+	}
+	gen.active[tName] = true
+	defer delete(gen.active, tName)
+	data := make(map[string]interface{})
+	for _, f := range flattenedFields(gen.registry, t) {
+		if v, ok := annotatedExample(f.Annotations); ok {
+			data[string(f.Name)] = v
+			continue
+		}
+		if f.Default != nil {
+			data[string(f.Name)] = f.Default
+			continue
+		}
+		if f.Optional && gen.recurses(f) {
+			continue
+		}
+		switch gen.registry.FindBaseType(f.Type) {
+		case BaseTypeArray:
+			if f.Items != "" {
+				data[string(f.Name)] = gen.arrayExample(f.Items, nil, nil, nil)
+				continue
+			}
+		case BaseTypeMap:
+			if f.Items != "" || f.Keys != "" {
+				data[string(f.Name)] = gen.mapExample(f.Keys, f.Items, nil, nil, nil)
+				continue
+			}
+		}
+		data[string(f.Name)] = gen.exampleOf(f.Type)
+	}
+	return data
+}
+
+// recurses is true if the field refers back to a struct currently being generated.
+func (gen *exampleGenerator) recurses(f *StructFieldDef) bool {
+	return gen.isActive(f.Type) || gen.isActive(f.Items) || gen.isActive(f.Keys)
+}
+
+func (gen *exampleGenerator) isActive(ref TypeRef) bool {
+	if t := gen.registry.FindType(ref); t != nil && t.Variant != TypeVariantBaseType {
+		tName, _, _ := TypeInfo(t)
+		return gen.active[tName]
+	}
+	return false
+}
+
+func exampleCount(size, minSize, maxSize *int32) int {
+	n := 1
+	if size != nil {
+		return int(*size)
+	}
+	if minSize != nil && int(*minSize) > n {
+		n = int(*minSize)
+	}
+	if maxSize != nil && int(*maxSize) < n {
+		n = int(*maxSize)
+	}
+	return n
+}
+
+func (gen *exampleGenerator) arrayExample(items TypeRef, size, minSize, maxSize *int32) interface{} {
+	data := make([]interface{}, 0)
+	if items == "" || gen.registry.FindBaseType(items) == BaseTypeAny || gen.isActive(items) {
+		return data
+	}
+	item := gen.exampleOf(items)
+	for i := exampleCount(size, minSize, maxSize); i > 0; i-- {
+		data = append(data, item)
+	}
+	return data
+}
+
+func (gen *exampleGenerator) mapExample(keys, items TypeRef, size, minSize, maxSize *int32) interface{} {
+	data := make(map[string]interface{})
+	if items == "" || gen.registry.FindBaseType(items) == BaseTypeAny || exampleCount(size, minSize, maxSize) == 0 {
+		return data
+	}
+	key := "key"
+	if keys != "" {
+		if k := gen.exampleOf(keys); k != nil {
+			key = fmt.Sprint(k)
+		}
+	}
+	data[key] = gen.exampleOf(items)
+	return data
+}
+
+func (gen *exampleGenerator) stringExample(t *Type, tName TypeName) interface{} {
+	var pattern string
+	var values []string
+	var minSize, maxSize *int32
+	for tt := t; tt != nil && tt.Variant == TypeVariantStringTypeDef; {
+		td := tt.StringTypeDef
+		if pattern == "" {
+			pattern = td.Pattern
+		}
+		if values == nil {
+			values = td.Values
+		}
+		if minSize == nil {
+			minSize = td.MinSize
+		}
+		if maxSize == nil {
+			maxSize = td.MaxSize
+		}
+		if TypeName(td.Type) == td.Name {
+			break
+		}
+		tt = gen.registry.FindType(td.Type)
+	}
+	if len(values) > 0 {
+		return values[0]
+	}
+	if pattern != "" {
+		if s, ok := patternExample(pattern); ok {
+			return s
+		}
+	}
+	s := strings.ToLower(string(tName))
+	if minSize != nil {
+		for len(s) < int(*minSize) {
+			s += "x"
+		}
+	}
+	if maxSize != nil && len(s) > int(*maxSize) {
+		s = s[:*maxSize]
+	}
+	return s
+}
+
+// patternExample synthesizes a short, non-empty string matching the regular expression, taking the first
+// alternative throughout, and one repetition of starred subexpressions.
+func patternExample(pattern string) (string, bool) {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return "", false
+	}
+	var buf strings.Builder
+	var walk func(re *syntax.Regexp)
+	walk = func(re *syntax.Regexp) {
+		switch re.Op {
+		case syntax.OpLiteral:
+			buf.WriteString(string(re.Rune))
+		case syntax.OpCharClass:
+			if len(re.Rune) > 0 {
+				buf.WriteRune(re.Rune[0])
+			}
+		case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+			buf.WriteRune('x')
+		case syntax.OpCapture, syntax.OpPlus, syntax.OpStar:
+			walk(re.Sub[0])
+		case syntax.OpRepeat:
+			for i := 0; i < re.Min || i == 0 && re.Max != 0; i++ {
+				walk(re.Sub[0])
+			}
+		case syntax.OpConcat:
+			for _, sub := range re.Sub {
+				walk(sub)
+			}
+		case syntax.OpAlternate:
+			walk(re.Sub[0])
+		}
+	}
+	walk(re)
+	s := buf.String()
+	matched, err := regexp.MatchString("^(?:"+pattern+")$", s)
+	return s, s != "" && err == nil && matched
+}
+
+func (gen *exampleGenerator) numberExample(t *Type) interface{} {
+	td := t.NumberTypeDef
+	v, ok := gen.baseTypeExample(gen.registry.BaseType(t)).(float64)
+	if !ok {
+		return nil
+	}
+	if td.Min != nil {
+		if min := numberValue(td.Min); v < min {
+			v = min
+		}
+	}
+	if td.Max != nil {
+		if max := numberValue(td.Max); v > max {
+			v = max
+		}
+	}
+	return v
+}
+
+func numberValue(n *Number) float64 {
+	switch n.Variant {
+	case NumberVariantInt8:
+		return float64(*n.Int8)
+	case NumberVariantInt16:
+		return float64(*n.Int16)
+	case NumberVariantInt32:
+		return float64(*n.Int32)
+	case NumberVariantInt64:
+		return float64(*n.Int64)
+	case NumberVariantFloat32:
+		return float64(*n.Float32)
+	case NumberVariantFloat64:
+		return *n.Float64
+	}
+	return 0
+}
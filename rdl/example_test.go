@@ -156,3 +156,31 @@ func TestExample(test *testing.T) {
 	}
 
 }
+
+func TestExampleValue(test *testing.T) {
+	for _, filename := range []string{"basictypes.rdl", "exampletest.rdl", "recursive.rdl", "rdl.rdl", "bigtest.rdl"} {
+		schema := loadTestSchema(test, filename)
+		if schema == nil {
+			return
+		}
+		reg := NewTypeRegistry(schema)
+		for _, t := range schema.Types {
+			typename, _, _ := TypeInfo(t)
+			if _, expectFail := getTypeExample(t, typename); expectFail {
+				continue
+			}
+			example, err := ExampleValue(reg, TypeRef(typename))
+			if err != nil {
+				test.Errorf("Cannot generate example for type %q: %v", typename, err)
+				continue
+			}
+			if reg.BaseType(t) == BaseTypeBytes {
+				continue //the validator has no support for Bytes
+			}
+			validation := Validate(schema, string(typename), example)
+			if !validation.Valid {
+				test.Errorf("Generated example for type %q (in %s) failed to validate: %v", typename, filename, validation)
+			}
+		}
+	}
+}
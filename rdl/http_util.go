@@ -194,6 +194,7 @@ func Float32Param(r *http.Request, name string, defaultValue float32) (float32,
 	return defaultValue, nil
 }
 
+// OptionalBoolParam returns nil if the parameter is absent, so that BoolParam can use its default value.
 func OptionalBoolParam(r *http.Request, name string) (*bool, error) {
 	var b bool
 	if r.Form == nil {
@@ -208,8 +209,9 @@ func OptionalBoolParam(r *http.Request, name string) (*bool, error) {
 		default:
 			return nil, &ResourceError{400, "Parameter '" + name + "' is not a Bool: " + vs[0]}
 		}
+		return &b, nil
 	}
-	return &b, nil
+	return nil, nil
 }
 
 func BoolParam(r *http.Request, name string, defaultValue bool) (bool, error) {
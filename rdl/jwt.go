@@ -0,0 +1,369 @@
+// Copyright 2015 Yahoo Inc.
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package rdl
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// JWTKeySet holds the keys that verify JWT signatures, by key id: HMAC secrets as []byte for HS256,
+// *rsa.PublicKey for RS256, and P-256 *ecdsa.PublicKey for ES256.
+type JWTKeySet struct {
+	keys map[string]interface{}
+}
+
+// NewJWTKeySet returns an empty key set.
+func NewJWTKeySet() *JWTKeySet {
+	return &JWTKeySet{keys: make(map[string]interface{})}
+}
+
+// AddKey adds the key with the key id, which may be empty. Private keys are added as their public key.
+func (ks *JWTKeySet) AddKey(kid string, key interface{}) error {
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		key = &k.PublicKey
+	case *ecdsa.PrivateKey:
+		key = &k.PublicKey
+	}
+	if _, err := jwtAlgorithm(key); err != nil {
+		return err
+	}
+	ks.keys[kid] = key
+	return nil
+}
+
+// jwtAlgorithm returns the signature algorithm of a key, the only one it is used with.
+func jwtAlgorithm(key interface{}) (string, error) {
+	switch k := key.(type) {
+	case []byte:
+		return "HS256", nil
+	case *rsa.PublicKey, *rsa.PrivateKey:
+		return "RS256", nil
+	case *ecdsa.PublicKey:
+		if k.Curve == elliptic.P256() {
+			return "ES256", nil
+		}
+	case *ecdsa.PrivateKey:
+		if k.Curve == elliptic.P256() {
+			return "ES256", nil
+		}
+	}
+	return "", fmt.Errorf("Unsupported JWT key: %T", key)
+}
+
+// jwk is a JSON Web Key of a JWKS.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	K   string `json:"k"`
+}
+
+// ParseJWKS returns the key set of a JSON Web Key Set. Keys of other types than RSA, P-256 EC and oct,
+// and keys that are not for signatures, are skipped.
+func ParseJWKS(data []byte) (*JWTKeySet, error) {
+	var jwks struct {
+		Keys []*jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &jwks); err != nil {
+		return nil, fmt.Errorf("Bad JWKS: %v", err)
+	}
+	ks := NewJWTKeySet()
+	b64 := base64.RawURLEncoding
+	for _, k := range jwks.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		var key interface{}
+		switch {
+		case k.Kty == "RSA":
+			n, err1 := b64.DecodeString(k.N)
+			e, err2 := b64.DecodeString(k.E)
+			if err1 != nil || err2 != nil || len(e) == 0 || len(e) > 4 {
+				return nil, fmt.Errorf("Bad RSA key in JWKS: %q", k.Kid)
+			}
+			key = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		case k.Kty == "EC" && k.Crv == "P-256":
+			x, err1 := b64.DecodeString(k.X)
+			y, err2 := b64.DecodeString(k.Y)
+			if err1 != nil || err2 != nil || len(x) != 32 || len(y) != 32 {
+				return nil, fmt.Errorf("Bad EC key in JWKS: %q", k.Kid)
+			}
+			pub := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+			if !pub.Curve.IsOnCurve(pub.X, pub.Y) {
+				return nil, fmt.Errorf("Bad EC key in JWKS: %q: point not on curve", k.Kid)
+			}
+			key = pub
+		case k.Kty == "oct":
+			secret, err := b64.DecodeString(k.K)
+			if err != nil || len(secret) == 0 {
+				return nil, fmt.Errorf("Bad oct key in JWKS: %q", k.Kid)
+			}
+			key = secret
+		default:
+			continue
+		}
+		ks.keys[k.Kid] = key
+	}
+	return ks, nil
+}
+
+// LoadJWKS returns the key set of a JSON Web Key Set file.
+func LoadJWKS(path string) (*JWTKeySet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseJWKS(data)
+}
+
+// SignJWT returns a JWT of the claims, signed with the key: HS256 for a []byte secret, RS256 for an
+// *rsa.PrivateKey, ES256 for a P-256 *ecdsa.PrivateKey. The key id is set in the header if not empty.
+func SignJWT(claims map[string]interface{}, kid string, key interface{}) (string, error) {
+	alg, err := jwtAlgorithm(key)
+	if err != nil {
+		return "", err
+	}
+	header := map[string]string{"alg": alg, "typ": "JWT"}
+	if kid != "" {
+		header["kid"] = kid
+	}
+	h, _ := json.Marshal(header)
+	c, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	b64 := base64.RawURLEncoding
+	data := b64.EncodeToString(h) + "." + b64.EncodeToString(c)
+	hash := sha256.Sum256([]byte(data))
+	var sig []byte
+	switch k := key.(type) {
+	case []byte:
+		mac := hmac.New(sha256.New, k)
+		mac.Write([]byte(data))
+		sig = mac.Sum(nil)
+	case *rsa.PrivateKey:
+		sig, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, hash[:])
+	case *ecdsa.PrivateKey:
+		var r, s *big.Int
+		if r, s, err = ecdsa.Sign(rand.Reader, k, hash[:]); err == nil {
+			sig = make([]byte, 64)
+			r.FillBytes(sig[:32])
+			s.FillBytes(sig[32:])
+		}
+	default:
+		err = fmt.Errorf("Cannot sign with a public key: %T", key)
+	}
+	if err != nil {
+		return "", err
+	}
+	return data + "." + b64.EncodeToString(sig), nil
+}
+
+// JWTPrincipal is the Principal of a JWT, with its claims.
+type JWTPrincipal struct {
+	SimplePrincipal
+	Claims map[string]interface{}
+}
+
+// JWTAuthenticator is an Authenticator of JWTs, read from the Authorization header as bearer tokens by
+// default. The signature must be HS256, RS256 or ES256 by a key of the key set, with the key id of the
+// token if it has one. The token must have an exp claim, and the time must be within its exp and nbf
+// claims, give or take the leeway. If the authenticator has an issuer or an audience, the iss claim must
+// match it, or the aud claim contain it.
+//
+// The name of the principal is the sub claim, or the NameClaim if set. Its domain is the DomainClaim if
+// set, and otherwise the name is split at its last dot into a domain and a name, i.e. "sports.jane".
+// Its YRN is the YRNClaim if set and present, and otherwise "domain.name".
+type JWTAuthenticator struct {
+	Header      string
+	Issuer      string
+	Audience    string
+	Leeway      time.Duration
+	NameClaim   string
+	DomainClaim string
+	YRNClaim    string
+	Now         func() time.Time
+
+	mutex sync.RWMutex
+	keys  *JWTKeySet
+}
+
+// NewJWTAuthenticator returns a JWTAuthenticator of bearer tokens in the Authorization header, verified
+// with the key set.
+func NewJWTAuthenticator(keys *JWTKeySet, issuer string, audience string) *JWTAuthenticator {
+	return &JWTAuthenticator{Header: "Authorization", Issuer: issuer, Audience: audience, Now: time.Now, keys: keys}
+}
+
+// SetKeys replaces the key set, i.e. after reloading a JWKS file.
+func (a *JWTAuthenticator) SetKeys(keys *JWTKeySet) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.keys = keys
+}
+
+func (a *JWTAuthenticator) HTTPHeader() string {
+	return a.Header
+}
+
+// Authenticate returns the principal of a valid token, or nil.
+func (a *JWTAuthenticator) Authenticate(credential string) Principal {
+	p, err := a.Verify(credential)
+	if err != nil {
+		return nil
+	}
+	return p
+}
+
+// Verify returns the principal of a token, or the reason it is not valid. A "Bearer " prefix is ignored.
+func (a *JWTAuthenticator) Verify(credential string) (*JWTPrincipal, error) {
+	token := credential
+	if len(token) > 7 && strings.EqualFold(token[:7], "bearer ") {
+		token = strings.TrimSpace(token[7:])
+	}
+	claims, err := a.verifySignature(token)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.checkClaims(claims); err != nil {
+		return nil, err
+	}
+	nameClaim := a.NameClaim
+	if nameClaim == "" {
+		nameClaim = "sub"
+	}
+	name, _ := claims[nameClaim].(string)
+	domain := ""
+	if a.DomainClaim != "" {
+		domain, _ = claims[a.DomainClaim].(string)
+	} else if i := strings.LastIndex(name, "."); i >= 0 {
+		domain, name = name[:i], name[i+1:]
+	}
+	if name == "" {
+		return nil, errors.New("No principal name in the token")
+	}
+	p := &JWTPrincipal{SimplePrincipal{Domain: domain, Name: name, Credentials: token, Header: a.Header}, claims}
+	if a.YRNClaim != "" {
+		p.YRN, _ = claims[a.YRNClaim].(string)
+	}
+	return p, nil
+}
+
+func (a *JWTAuthenticator) verifySignature(token string) (map[string]interface{}, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("Not a JWT")
+	}
+	b64 := base64.RawURLEncoding
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	h, err := b64.DecodeString(parts[0])
+	if err != nil || json.Unmarshal(h, &header) != nil {
+		return nil, errors.New("Bad JWT header")
+	}
+	sig, err := b64.DecodeString(parts[2])
+	if err != nil {
+		return nil, errors.New("Bad JWT signature encoding")
+	}
+	a.mutex.RLock()
+	keys := a.keys
+	a.mutex.RUnlock()
+	if keys == nil {
+		return nil, errors.New("No keys to verify the JWT")
+	}
+	data := parts[0] + "." + parts[1]
+	verified := false
+	for kid, key := range keys.keys {
+		if header.Kid != "" && kid != header.Kid {
+			continue
+		}
+		//a key is only used with its own algorithm, so that i.e. a public key can't be used as an HMAC secret
+		if alg, _ := jwtAlgorithm(key); alg == header.Alg && verifyJWTSignature(key, data, sig) {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return nil, fmt.Errorf("JWT signature not verified (alg %q, kid %q)", header.Alg, header.Kid)
+	}
+	c, err := b64.DecodeString(parts[1])
+	var claims map[string]interface{}
+	if err != nil || json.Unmarshal(c, &claims) != nil {
+		return nil, errors.New("Bad JWT claims")
+	}
+	return claims, nil
+}
+
+func verifyJWTSignature(key interface{}, data string, sig []byte) bool {
+	hash := sha256.Sum256([]byte(data))
+	switch k := key.(type) {
+	case []byte:
+		mac := hmac.New(sha256.New, k)
+		mac.Write([]byte(data))
+		return hmac.Equal(sig, mac.Sum(nil))
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(k, crypto.SHA256, hash[:], sig) == nil
+	case *ecdsa.PublicKey:
+		if len(sig) != 64 {
+			return false
+		}
+		r, s := new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])
+		return ecdsa.Verify(k, hash[:], r, s)
+	}
+	return false
+}
+
+func (a *JWTAuthenticator) checkClaims(claims map[string]interface{}) error {
+	now := a.Now()
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return errors.New("No exp claim in the token")
+	}
+	if !now.Before(time.Unix(int64(exp), 0).Add(a.Leeway)) {
+		return errors.New("Expired token")
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(a.Leeway).Before(time.Unix(int64(nbf), 0)) {
+		return errors.New("Token not valid yet")
+	}
+	if a.Issuer != "" && claims["iss"] != a.Issuer {
+		return fmt.Errorf("Unexpected token issuer: %v", claims["iss"])
+	}
+	if a.Audience != "" {
+		found := false
+		switch aud := claims["aud"].(type) {
+		case string:
+			found = aud == a.Audience
+		case []interface{}:
+			for _, s := range aud {
+				found = found || s == a.Audience
+			}
+		}
+		if !found {
+			return fmt.Errorf("Token not for audience %q", a.Audience)
+		}
+	}
+	return nil
+}
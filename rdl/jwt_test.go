@@ -0,0 +1,137 @@
+// Copyright 2015 Yahoo Inc.
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package rdl
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testJWKS returns a JWKS file of the public keys and the secret.
+func testJWKS(test *testing.T, rsaKey *rsa.PrivateKey, ecKey *ecdsa.PrivateKey, secret []byte) string {
+	b64 := base64.RawURLEncoding
+	x, y := make([]byte, 32), make([]byte, 32)
+	ecKey.X.FillBytes(x)
+	ecKey.Y.FillBytes(y)
+	jwks := map[string]interface{}{"keys": []map[string]string{
+		{"kty": "RSA", "kid": "rsa1", "use": "sig", "n": b64.EncodeToString(rsaKey.N.Bytes()), "e": b64.EncodeToString(big.NewInt(int64(rsaKey.E)).Bytes())},
+		{"kty": "EC", "kid": "ec1", "crv": "P-256", "x": b64.EncodeToString(x), "y": b64.EncodeToString(y)},
+		{"kty": "oct", "kid": "hs1", "k": b64.EncodeToString(secret)},
+		{"kty": "RSA", "kid": "enc1", "use": "enc", "n": "AQAB", "e": "AQAB"},
+		{"kty": "OKP", "kid": "ed1", "crv": "Ed25519", "x": "AA"},
+	}}
+	data, _ := json.Marshal(jwks)
+	path := filepath.Join(test.TempDir(), "jwks.json")
+	os.WriteFile(path, data, 0644)
+	return path
+}
+
+func TestJWTAuthenticator(test *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	secret := []byte("a shared secret of the services")
+	keys, err := LoadJWKS(testJWKS(test, rsaKey, ecKey, secret))
+	if err != nil {
+		test.Fatalf("Cannot load the JWKS: %v", err)
+	}
+	if len(keys.keys) != 3 {
+		test.Errorf("Expected the keys not for signatures to be skipped, got %d keys", len(keys.keys))
+	}
+	offCurve := `{"keys": [{"kty": "EC", "kid": "ec2", "crv": "P-256", "x": "` + base64.RawURLEncoding.EncodeToString(make([]byte, 32)) + `", "y": "` + base64.RawURLEncoding.EncodeToString(make([]byte, 32)) + `"}]}`
+	if _, err := ParseJWKS([]byte(offCurve)); err == nil {
+		test.Errorf("Expected an error for an EC key not on the curve")
+	}
+	now := time.Unix(1700000000, 0)
+	authn := NewJWTAuthenticator(keys, "https://issuer", "api")
+	authn.Now = func() time.Time { return now }
+	claims := func(extra map[string]interface{}) map[string]interface{} {
+		c := map[string]interface{}{"sub": "sports.jane", "iss": "https://issuer", "aud": []string{"web", "api"}, "exp": now.Unix() + 60}
+		for k, v := range extra {
+			c[k] = v
+		}
+		return c
+	}
+
+	for kid, key := range map[string]interface{}{"rsa1": rsaKey, "ec1": ecKey, "hs1": secret} {
+		token, err := SignJWT(claims(nil), kid, key)
+		if err != nil {
+			test.Fatalf("Cannot sign with %s: %v", kid, err)
+		}
+		p := authn.Authenticate("Bearer " + token)
+		if p == nil || p.GetDomain() != "sports" || p.GetName() != "jane" || p.GetYRN() != "sports.jane" || p.GetCredentials() != token {
+			test.Errorf("Cannot authenticate a token signed with %s: %v", kid, p)
+		}
+		//without key id, the keys of the algorithm are tried
+		if token, _ = SignJWT(claims(nil), "", key); authn.Authenticate(token) == nil {
+			test.Errorf("Cannot authenticate a token without key id signed with %s", kid)
+		}
+	}
+
+	otherKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	bad := map[string]func() (string, error){
+		"unknown key": func() (string, error) { return SignJWT(claims(nil), "ec1", otherKey) },
+		"wrong kid":   func() (string, error) { return SignJWT(claims(nil), "rsa1", ecKey) },
+		"expired": func() (string, error) {
+			return SignJWT(claims(map[string]interface{}{"exp": now.Unix() - 1}), "hs1", secret)
+		},
+		"no exp": func() (string, error) { return SignJWT(claims(map[string]interface{}{"exp": nil}), "hs1", secret) },
+		"not yet valid": func() (string, error) {
+			return SignJWT(claims(map[string]interface{}{"nbf": now.Unix() + 30}), "hs1", secret)
+		},
+		"issuer":     func() (string, error) { return SignJWT(claims(map[string]interface{}{"iss": "other"}), "hs1", secret) },
+		"audience":   func() (string, error) { return SignJWT(claims(map[string]interface{}{"aud": "web"}), "hs1", secret) },
+		"no subject": func() (string, error) { return SignJWT(claims(map[string]interface{}{"sub": ""}), "hs1", secret) },
+		//an HS256 token whose secret is the public RSA modulus must not verify with the RSA key
+		"confused": func() (string, error) { return SignJWT(claims(nil), "rsa1", rsaKey.N.Bytes()) },
+	}
+	for name, sign := range bad {
+		token, err := sign()
+		if err != nil {
+			test.Fatalf("%s: cannot sign: %v", name, err)
+		}
+		if _, err := authn.Verify(token); err == nil {
+			test.Errorf("%s: expected the token to be rejected", name)
+		}
+	}
+	token, _ := SignJWT(claims(nil), "hs1", secret)
+	for _, garbage := range []string{"", "a.b", token[:len(token)-2], "x" + token} {
+		if authn.Authenticate(garbage) != nil {
+			test.Errorf("Expected a malformed token to be rejected: %q", garbage)
+		}
+	}
+
+	//leeway, claim mapping and key rotation
+	authn.Leeway = time.Minute
+	expired, _ := SignJWT(claims(map[string]interface{}{"exp": now.Unix() - 30, "dom": "media", "yrn": "yrn:media:jane"}), "hs1", secret)
+	if p := authn.Authenticate(expired); p == nil {
+		test.Errorf("Expected the leeway to accept a recently expired token")
+	}
+	authn.DomainClaim, authn.YRNClaim = "dom", "yrn"
+	if p, err := authn.Verify(expired); err != nil || p.GetDomain() != "media" || p.GetName() != "sports.jane" || p.GetYRN() != "yrn:media:jane" || p.Claims["iss"] != "https://issuer" {
+		test.Errorf("Unexpected mapping of the claims: %+v, %v", p, err)
+	}
+	rotated := NewJWTKeySet()
+	if err := rotated.AddKey("ec2", otherKey); err != nil {
+		test.Fatalf("Cannot add a key: %v", err)
+	}
+	authn.SetKeys(rotated)
+	if token, _ = SignJWT(claims(nil), "ec2", otherKey); authn.Authenticate(token) == nil {
+		test.Errorf("Cannot authenticate with the new keys")
+	}
+	if authn.Authenticate(expired) != nil {
+		test.Errorf("Expected the old keys to be replaced")
+	}
+	if err := rotated.AddKey("x", "not a key"); err == nil {
+		test.Errorf("Expected an error adding an unsupported key")
+	}
+}
@@ -0,0 +1,178 @@
+// Copyright 2015 Yahoo Inc.
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package rdl
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// MockStatusHeader is the request header that selects the response of a MockServer, by symbolic or numeric
+// status code, instead of the scripted one. The status must be the expected one of the resource, an
+// alternative or an exception.
+const MockStatusHeader = "Rdl-Mock-Status"
+
+// MockResponse is a scripted response of a MockServer. Status is a symbolic or numeric status code, the
+// expected one of the resource when empty. Body replaces the example data, and Headers are set after the
+// example output headers.
+type MockResponse struct {
+	Status  string            `json:"status,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    interface{}       `json:"body,omitempty"`
+}
+
+// MockServer is an http.Handler that answers the resources of a schema with synthetic responses. Requests
+// are checked like a Router does. By default the response has the expected status of the resource, an
+// example value of the resource type as body (see ExampleValue), and example values of the declared outputs
+// as headers. An error response has an example value of the type of the exception as body.
+//
+// Fixtures script the responses of a resource: each request gets the next one, and the last one is repeated.
+type MockServer struct {
+	router   *Router
+	mutex    sync.Mutex
+	fixtures map[Identifier][]*MockResponse
+	served   map[Identifier]int
+}
+
+// NewMockServer returns a MockServer for the resources of the schema.
+func NewMockServer(schema *Schema) (*MockServer, error) {
+	router, err := NewRouter(schema, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &MockServer{
+		router:   router,
+		fixtures: make(map[Identifier][]*MockResponse),
+		served:   make(map[Identifier]int),
+	}, nil
+}
+
+// SetMaxBodySize sets the limit of the size of request bodies, DefaultMaxBodySize by default.
+func (mock *MockServer) SetMaxBodySize(n int64) {
+	mock.router.SetMaxBodySize(n)
+}
+
+// SetFixture scripts the responses of the named resource, or restores the default response if there are
+// none. Their status codes must be declared by the resource.
+func (mock *MockServer) SetFixture(name Identifier, responses ...*MockResponse) error {
+	var rt *route
+	for _, r := range mock.router.routes {
+		if r.name == name {
+			rt = r
+		}
+	}
+	if rt == nil {
+		return fmt.Errorf("No such resource: %s", name)
+	}
+	for _, resp := range responses {
+		if resp.Status != "" {
+			if _, _, err := declaredStatus(rt.resource, resp.Status); err != nil {
+				return err
+			}
+		}
+	}
+	mock.mutex.Lock()
+	defer mock.mutex.Unlock()
+	if len(responses) == 0 {
+		delete(mock.fixtures, name)
+	} else {
+		mock.fixtures[name] = responses
+	}
+	delete(mock.served, name)
+	return nil
+}
+
+// LoadFixtures scripts resources with the JSON files of a directory. Each file is named after a resource,
+// i.e. "getContact.json", and holds a MockResponse or an array of them.
+func (mock *MockServer) LoadFixtures(dir string) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var responses []*MockResponse
+		if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+			err = json.Unmarshal(data, &responses)
+		} else {
+			var resp MockResponse
+			err = json.Unmarshal(data, &resp)
+			responses = append(responses, &resp)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+		name := Identifier(strings.TrimSuffix(filepath.Base(path), ".json"))
+		if err := mock.SetFixture(name, responses...); err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+	}
+	return nil
+}
+
+// next returns the next scripted response of the resource, or nil.
+func (mock *MockServer) next(name Identifier) *MockResponse {
+	mock.mutex.Lock()
+	defer mock.mutex.Unlock()
+	responses := mock.fixtures[name]
+	if len(responses) == 0 {
+		return nil
+	}
+	i := mock.served[name]
+	if i < len(responses)-1 {
+		mock.served[name] = i + 1
+	}
+	return responses[i]
+}
+
+func (mock *MockServer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	rt, _, _ := mock.router.prepare(w, req)
+	if rt == nil {
+		return
+	}
+	r := rt.resource
+	var resp *MockResponse
+	status := r.Expected
+	if s := req.Header.Get(MockStatusHeader); s != "" {
+		status = s
+	} else if resp = mock.next(rt.name); resp != nil && resp.Status != "" {
+		status = resp.Status
+	}
+	code, typename, err := declaredStatus(r, status)
+	if err != nil {
+		ErrorResponse(w, err)
+		return
+	}
+	reg := mock.router.checker.registry
+	if code < 400 {
+		for _, out := range r.Outputs {
+			if v, err := ExampleValue(reg, out.Type); err == nil {
+				w.Header().Set(out.Header, fmt.Sprint(v))
+			}
+		}
+	}
+	var body interface{}
+	if resp != nil {
+		for name, value := range resp.Headers {
+			w.Header().Set(name, value)
+		}
+		body = resp.Body
+	}
+	if body == nil {
+		if body, err = ExampleValue(reg, typename); err != nil {
+			ErrorResponse(w, err)
+			return
+		}
+	}
+	JSONResponse(w, code, body)
+}
@@ -0,0 +1,109 @@
+// Copyright 2015 Yahoo Inc.
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package rdl
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const mockTestRDL = `
+type Id String (pattern="[a-z]+[0-9]+");
+type Color Enum { RED, GREEN }
+type Contact Struct { Id id; String name (x_example="Jane Doe"); Color color; Int32 age (min=18); }
+type Problem Struct { Int32 code; String message; }
+resource Contact GET "/contacts/{id}" (name=getContact) {
+    Id id;
+    String tag (header="ETag", out);
+    expected OK, NOT_MODIFIED;
+    exceptions { Problem NOT_FOUND; }
+}
+resource Contact PUT "/contacts/{id}" (name=putContact) {
+    Id id;
+    Contact contact;
+    expected NO_CONTENT;
+}
+`
+
+func mockRequest(mock http.Handler, method, path, status string) (*httptest.ResponseRecorder, map[string]interface{}) {
+	req := httptest.NewRequest(method, path, nil)
+	if status != "" {
+		req.Header.Set(MockStatusHeader, status)
+	}
+	w := httptest.NewRecorder()
+	mock.ServeHTTP(w, req)
+	var body map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &body)
+	return w, body
+}
+
+func TestMockServer(test *testing.T) {
+	schema, err := parseRDLString(mockTestRDL)
+	if err != nil {
+		test.Fatalf("Cannot parse: %v", err)
+	}
+	mock, err := NewMockServer(schema)
+	if err != nil {
+		test.Fatalf("Cannot create mock server: %v", err)
+	}
+	w, body := mockRequest(mock, "GET", "/contacts/a1", "")
+	if w.Code != 200 || w.Header().Get("ETag") == "" {
+		test.Fatalf("Unexpected response: %d %v", w.Code, w.Header())
+	}
+	if v := Validate(schema, "Contact", body); !v.Valid || body["name"] != "Jane Doe" {
+		test.Errorf("Expected a valid example Contact, got %v: %v", body, v.Error)
+	}
+	if w, _ = mockRequest(mock, "GET", "/contacts/a1", "NOT_MODIFIED"); w.Code != 304 || w.Body.Len() != 0 {
+		test.Errorf("Expected an empty 304 response, got %d: %s", w.Code, w.Body)
+	}
+	if w, body = mockRequest(mock, "GET", "/contacts/a1", "404"); w.Code != 404 || Validate(schema, "Problem", body).Error != "" {
+		test.Errorf("Expected a 404 Problem, got %d: %s", w.Code, w.Body)
+	}
+	if w, _ = mockRequest(mock, "GET", "/contacts/a1", "CONFLICT"); w.Code != 400 {
+		test.Errorf("Expected an undeclared status to be rejected, got %d", w.Code)
+	}
+	if w, _ = mockRequest(mock, "GET", "/contacts/A", ""); w.Code != 400 {
+		test.Errorf("Expected an invalid path param to be rejected, got %d", w.Code)
+	}
+	if w, _ = mockRequest(mock, "PUT", "/contacts/a1", ""); w.Code != 400 {
+		test.Errorf("Expected a missing body to be rejected, got %d", w.Code)
+	}
+}
+
+func TestMockFixtures(test *testing.T) {
+	schema, _ := parseRDLString(mockTestRDL)
+	mock, _ := NewMockServer(schema)
+	dir := test.TempDir()
+	fixture := `[
+  {"body": {"id": "b2", "name": "Bob", "color": "GREEN", "age": 40}, "headers": {"ETag": "v1"}},
+  {"status": "NOT_FOUND", "body": {"code": 404, "message": "gone"}}
+]`
+	os.WriteFile(filepath.Join(dir, "getContact.json"), []byte(fixture), 0644)
+	if err := mock.LoadFixtures(dir); err != nil {
+		test.Fatalf("Cannot load fixtures: %v", err)
+	}
+	w, body := mockRequest(mock, "GET", "/contacts/b2", "")
+	if w.Code != 200 || body["name"] != "Bob" || w.Header().Get("ETag") != "v1" {
+		test.Errorf("Unexpected first scripted response: %d %v %v", w.Code, body, w.Header())
+	}
+	for i := 0; i < 2; i++ {
+		if w, body = mockRequest(mock, "GET", "/contacts/b2", ""); w.Code != 404 || body["message"] != "gone" {
+			test.Errorf("Expected the last scripted response to be repeated, got %d %v", w.Code, body)
+		}
+	}
+	if err := mock.SetFixture("getContact", &MockResponse{Status: "CREATED"}); err == nil {
+		test.Errorf("Expected an error scripting an undeclared status")
+	}
+	if err := mock.SetFixture("deleteContact"); err == nil {
+		test.Errorf("Expected an error scripting an unknown resource")
+	}
+	mock.SetFixture("getContact")
+	if w, body = mockRequest(mock, "GET", "/contacts/b2", ""); w.Code != 200 || body["name"] != "Jane Doe" {
+		test.Errorf("Expected the example response without fixture, got %d %v", w.Code, body)
+	}
+}
@@ -0,0 +1,332 @@
+// Copyright 2015 Yahoo Inc.
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package rdl
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Policy is a set of roles and of rules that allow or deny actions on resources to them. A principal is
+// in a role if its full name, "domain.name" or just the name without domain, matches one of the principals
+// of the role, or its domain one of its domains. The action and the resource of a rule are globs, where
+// '*' matches any string and '?' any character, that are matched against the authorized action and
+// resource, i.e. "read" and "sports:entity.ball". Deny rules take precedence over allow rules, and nothing
+// is allowed without an allow rule.
+//
+// As JSON:
+//
+//	{
+//	  "roles": [{"name": "readers", "principals": ["sports.jane"], "domains": ["media"]}],
+//	  "rules": [{"effect": "allow", "role": "readers", "action": "read", "resource": "sports:*"}]
+//	}
+type Policy struct {
+	Roles []*PolicyRole `json:"roles"`
+	Rules []*PolicyRule `json:"rules"`
+}
+
+// PolicyRole is a role of a Policy, and the principals and domains in it, as globs.
+type PolicyRole struct {
+	Name       string   `json:"name"`
+	Principals []string `json:"principals,omitempty"`
+	Domains    []string `json:"domains,omitempty"`
+}
+
+// PolicyRule allows or denies the actions on the resources matching its globs to a role of a Policy.
+type PolicyRule struct {
+	Effect   string `json:"effect"`
+	Role     string `json:"role"`
+	Action   string `json:"action"`
+	Resource string `json:"resource"`
+}
+
+// ParsePolicy returns the Policy of the JSON data. Roles must have unique names, and rules must refer to
+// them and have an effect of "allow" or "deny".
+func ParsePolicy(data []byte) (*Policy, error) {
+	var p Policy
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("Bad policy: %v", err)
+	}
+	roles := make(map[string]bool)
+	for _, role := range p.Roles {
+		if role.Name == "" || roles[role.Name] {
+			return nil, fmt.Errorf("Bad policy: missing or duplicate role name: %q", role.Name)
+		}
+		roles[role.Name] = true
+	}
+	for i, rule := range p.Rules {
+		if rule.Effect != "allow" && rule.Effect != "deny" {
+			return nil, fmt.Errorf("Bad policy: rule %d: effect must be allow or deny: %q", i, rule.Effect)
+		}
+		if !roles[rule.Role] {
+			return nil, fmt.Errorf("Bad policy: rule %d: unknown role: %q", i, rule.Role)
+		}
+		if rule.Action == "" || rule.Resource == "" {
+			return nil, fmt.Errorf("Bad policy: rule %d: missing action or resource", i)
+		}
+	}
+	return &p, nil
+}
+
+// LoadPolicy returns the Policy of a JSON file.
+func LoadPolicy(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePolicy(data)
+}
+
+// RolesOf returns the names of the roles the principal is in.
+func (p *Policy) RolesOf(principal Principal) []string {
+	var roles []string
+	if principal == nil {
+		return roles
+	}
+	name := principal.GetName()
+	if principal.GetDomain() != "" {
+		name = principal.GetDomain() + "." + name
+	}
+	for _, role := range p.Roles {
+		if matchAny(role.Principals, name) || (principal.GetDomain() != "" && matchAny(role.Domains, principal.GetDomain())) {
+			roles = append(roles, role.Name)
+		}
+	}
+	return roles
+}
+
+// Authorize returns whether the rules of the roles of the principal allow the action on the resource.
+func (p *Policy) Authorize(action string, resource string, principal Principal) (bool, error) {
+	roles := make(map[string]bool)
+	for _, role := range p.RolesOf(principal) {
+		roles[role] = true
+	}
+	allowed := false
+	for _, rule := range p.Rules {
+		if !roles[rule.Role] || !globMatch(rule.Action, action) || !globMatch(rule.Resource, resource) {
+			continue
+		}
+		if rule.Effect == "deny" {
+			return false, nil
+		}
+		allowed = true
+	}
+	return allowed, nil
+}
+
+// ResourcesByRole returns the resources of the schema with an authorize directive that each role of the
+// policy can reach, by role name, in the order of the schema. The resource string of a directive is a
+// template, prefixed by its domain if any: a resource is reachable if an allow rule matches some expansion
+// of its {param} placeholders, and is not if a deny rule matches all of them, i.e. "sports:*" for
+// "sports:entity.{name}". Resources that only some values of their params can be denied for are listed.
+func (p *Policy) ResourcesByRole(schema *Schema) map[string][]*Resource {
+	reach := make(map[string][]*Resource)
+	for _, role := range p.Roles {
+		reach[role.Name] = []*Resource{}
+	}
+	for _, r := range schema.Resources {
+		if r.Auth == nil || r.Auth.Action == "" {
+			continue
+		}
+		template := r.Auth.Resource
+		if r.Auth.Domain != "" {
+			template = r.Auth.Domain + ":" + template
+		}
+		pattern := templateGlob(template)
+		allowed := make(map[string]bool)
+		denied := make(map[string]bool)
+		for _, rule := range p.Rules {
+			if !globMatch(rule.Action, r.Auth.Action) {
+				continue
+			}
+			if rule.Effect == "deny" {
+				if globMatch(rule.Resource, template) {
+					denied[rule.Role] = true
+				}
+			} else if globsIntersect(rule.Resource, pattern) {
+				allowed[rule.Role] = true
+			}
+		}
+		for _, role := range p.Roles {
+			if allowed[role.Name] && !denied[role.Name] {
+				reach[role.Name] = append(reach[role.Name], r)
+			}
+		}
+	}
+	return reach
+}
+
+// templateGlob returns the glob of the expansions of a resource template, its placeholders becoming '*'.
+func templateGlob(template string) string {
+	var glob strings.Builder
+	for {
+		i := strings.Index(template, "{")
+		j := strings.Index(template, "}")
+		if i < 0 || j < i {
+			break
+		}
+		glob.WriteString(template[:i])
+		glob.WriteString("*")
+		template = template[j+1:]
+	}
+	glob.WriteString(template)
+	return glob.String()
+}
+
+func matchAny(globs []string, s string) bool {
+	for _, glob := range globs {
+		if globMatch(glob, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// globMatch returns whether the glob matches the whole string. '*' matches any string, including an empty
+// one, and '?' any single character.
+func globMatch(glob string, s string) bool {
+	star, mark := -1, 0
+	i, j := 0, 0
+	for j < len(s) {
+		switch {
+		case i < len(glob) && glob[i] == '*':
+			star, mark = i, j
+			i++
+		case i < len(glob) && (glob[i] == '?' || glob[i] == s[j]):
+			i++
+			j++
+		case star >= 0:
+			mark++
+			i, j = star+1, mark
+		default:
+			return false
+		}
+	}
+	for i < len(glob) && glob[i] == '*' {
+		i++
+	}
+	return i == len(glob)
+}
+
+// globsIntersect returns whether some string matches both globs.
+func globsIntersect(a string, b string) bool {
+	seen := make(map[[2]int]bool)
+	var intersect func(i, j int) bool
+	intersect = func(i, j int) bool {
+		if seen[[2]int{i, j}] {
+			return false
+		}
+		seen[[2]int{i, j}] = true
+		switch {
+		case i == len(a) && j == len(b):
+			return true
+		case i < len(a) && a[i] == '*':
+			return intersect(i+1, j) || (j < len(b) && intersect(i, j+1))
+		case j < len(b) && b[j] == '*':
+			return intersect(i, j+1) || (i < len(a) && intersect(i+1, j))
+		case i == len(a) || j == len(b):
+			return false
+		case a[i] == '?' || b[j] == '?' || a[i] == b[j]:
+			return intersect(i+1, j+1)
+		}
+		return false
+	}
+	return intersect(0, 0)
+}
+
+// PolicyAuthorizer is an Authorizer with the Policy of a file, that can be reloaded while it is in use.
+type PolicyAuthorizer struct {
+	path    string
+	mutex   sync.RWMutex
+	policy  *Policy
+	modTime time.Time
+	size    int64
+}
+
+// NewPolicyAuthorizer returns a PolicyAuthorizer with the policy file.
+func NewPolicyAuthorizer(path string) (*PolicyAuthorizer, error) {
+	a := &PolicyAuthorizer{path: path}
+	if err := a.Reload(); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Policy returns the current policy.
+func (a *PolicyAuthorizer) Policy() *Policy {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+	return a.policy
+}
+
+// Authorize returns whether the current policy allows the action on the resource to the principal.
+func (a *PolicyAuthorizer) Authorize(action string, resource string, principal Principal) (bool, error) {
+	return a.Policy().Authorize(action, resource, principal)
+}
+
+// Reload reads the policy file again. If it is not valid, the current policy is kept.
+func (a *PolicyAuthorizer) Reload() error {
+	info, err := os.Stat(a.path)
+	if err != nil {
+		return err
+	}
+	policy, err := LoadPolicy(a.path)
+	if err != nil {
+		return err
+	}
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.policy, a.modTime, a.size = policy, info.ModTime(), info.Size()
+	return nil
+}
+
+// Watch checks the policy file at the interval, and reloads it when it is modified. Errors reloading it are
+// passed to the function, if not nil, once per modification. The returned function stops watching.
+func (a *PolicyAuthorizer) Watch(interval time.Duration, onError func(error)) (stop func()) {
+	done := make(chan struct{})
+	a.mutex.RLock()
+	modTime, size := a.modTime, a.size
+	a.mutex.RUnlock()
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				info, err := os.Stat(a.path)
+				if err != nil || (info.ModTime().Equal(modTime) && info.Size() == size) {
+					continue
+				}
+				modTime, size = info.ModTime(), info.Size()
+				if err := a.Reload(); err != nil && onError != nil {
+					onError(err)
+				}
+			}
+		}
+	}()
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }
+}
+
+// ResourcesByRole returns the resources of the schema each role of the current policy can reach.
+func (a *PolicyAuthorizer) ResourcesByRole(schema *Schema) map[string][]*Resource {
+	return a.Policy().ResourcesByRole(schema)
+}
+
+// RoleNames returns the names of the roles of the policy, sorted.
+func (p *Policy) RoleNames() []string {
+	names := make([]string, 0, len(p.Roles))
+	for _, role := range p.Roles {
+		names = append(names, role.Name)
+	}
+	sort.Strings(names)
+	return names
+}
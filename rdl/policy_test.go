@@ -0,0 +1,143 @@
+// Copyright 2015 Yahoo Inc.
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package rdl
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const testPolicy = `{
+  "roles": [
+    {"name": "readers", "principals": ["sports.*"], "domains": ["media"]},
+    {"name": "admins", "principals": ["sys.admin"]}
+  ],
+  "rules": [
+    {"effect": "allow", "role": "readers", "action": "read", "resource": "*:entity.*"},
+    {"effect": "deny", "role": "readers", "action": "read", "resource": "*:entity.secret?"},
+    {"effect": "allow", "role": "admins", "action": "*", "resource": "*"},
+    {"effect": "deny", "role": "admins", "action": "delete", "resource": "sys.admin:entity.*"}
+  ]
+}`
+
+func TestPolicyAuthorize(test *testing.T) {
+	policy, err := ParsePolicy([]byte(testPolicy))
+	if err != nil {
+		test.Fatalf("Cannot parse the policy: %v", err)
+	}
+	jane := &SimplePrincipal{Domain: "sports", Name: "jane"}
+	bob := &SimplePrincipal{Domain: "media", Name: "bob"}
+	admin := &SimplePrincipal{Domain: "sys", Name: "admin"}
+	for _, c := range []struct {
+		action    string
+		resource  string
+		principal Principal
+		allowed   bool
+	}{
+		{"read", "sports:entity.ball", jane, true},
+		{"read", "sports:entity.ball", bob, true},
+		{"read", "sports:entity.secret1", jane, false},
+		{"read", "sports:entity.secret12", jane, true},
+		{"write", "sports:entity.ball", jane, false},
+		{"read", "sports:user.jane", jane, false},
+		{"read", "sports:entity.ball", &SimplePrincipal{Name: "jane"}, false},
+		{"read", "sports:entity.ball", nil, false},
+		{"write", "sports:user.jane", admin, true},
+		{"delete", "sys.admin:entity.ball", admin, false},
+	} {
+		allowed, err := policy.Authorize(c.action, c.resource, c.principal)
+		if err != nil || allowed != c.allowed {
+			test.Errorf("Authorize(%q, %q, %v): expected %v, got %v, %v", c.action, c.resource, c.principal, c.allowed, allowed, err)
+		}
+	}
+	if roles := policy.RolesOf(jane); len(roles) != 1 || roles[0] != "readers" {
+		test.Errorf("Unexpected roles of jane: %v", roles)
+	}
+	for _, bad := range []string{
+		`{"roles": [{"name": "a"}, {"name": "a"}]}`,
+		`{"roles": [{"name": "a"}], "rules": [{"effect": "allow", "role": "b", "action": "*", "resource": "*"}]}`,
+		`{"roles": [{"name": "a"}], "rules": [{"effect": "permit", "role": "a", "action": "*", "resource": "*"}]}`,
+		`{"roles": [{"name": "a"}], "rules": [{"effect": "allow", "role": "a", "resource": "*"}]}`,
+		`{"roles": `,
+	} {
+		if _, err := ParsePolicy([]byte(bad)); err == nil {
+			test.Errorf("Expected an error parsing the policy: %s", bad)
+		}
+	}
+}
+
+func TestPolicyResourcesByRole(test *testing.T) {
+	schema, err := parseRDLString(authTestRDL)
+	if err != nil {
+		test.Fatalf("Cannot parse: %v", err)
+	}
+	policy, _ := ParsePolicy([]byte(testPolicy))
+	reach := policy.ResourcesByRole(schema)
+	names := func(role string) []string {
+		var list []string
+		for _, r := range reach[role] {
+			list = append(list, string(r.Name))
+		}
+		return list
+	}
+	//some entities are denied to readers, but not all of them
+	if got := names("readers"); len(got) != 1 || got[0] != "getEntity" {
+		test.Errorf("Unexpected resources of readers: %v", got)
+	}
+	//deleting entities of the sys.admin domain is denied to admins
+	if got := names("admins"); len(got) != 2 || got[0] != "getEntity" || got[1] != "putEntity" {
+		test.Errorf("Unexpected resources of admins: %v", got)
+	}
+	if roles := policy.RoleNames(); len(roles) != 2 || roles[0] != "admins" {
+		test.Errorf("Unexpected role names: %v", roles)
+	}
+	if !globsIntersect("sports:*", "*:entity.*") || globsIntersect("sports:user.?", "*:entity.*") || !globsIntersect("a?c", "*b*") {
+		test.Errorf("Unexpected intersection of globs")
+	}
+}
+
+func TestPolicyAuthorizerReload(test *testing.T) {
+	path := filepath.Join(test.TempDir(), "policy.json")
+	if _, err := NewPolicyAuthorizer(path); err == nil {
+		test.Errorf("Expected an error without policy file")
+	}
+	os.WriteFile(path, []byte(testPolicy), 0644)
+	authz, err := NewPolicyAuthorizer(path)
+	if err != nil {
+		test.Fatalf("Cannot load the policy: %v", err)
+	}
+	jane := &SimplePrincipal{Domain: "sports", Name: "jane"}
+	if allowed, _ := authz.Authorize("read", "sports:entity.ball", jane); !allowed {
+		test.Errorf("Expected read to be allowed")
+	}
+	errs := make(chan error, 10)
+	stop := authz.Watch(5*time.Millisecond, func(err error) { errs <- err })
+	defer stop()
+
+	//an invalid policy is reported and the current one kept
+	os.WriteFile(path, []byte(`{"roles": [`), 0644)
+	select {
+	case <-errs:
+	case <-time.After(2 * time.Second):
+		test.Fatalf("Expected an error reloading an invalid policy")
+	}
+	if allowed, _ := authz.Authorize("read", "sports:entity.ball", jane); !allowed {
+		test.Errorf("Expected the current policy to be kept")
+	}
+
+	os.WriteFile(path, []byte(`{"roles": [{"name": "readers", "principals": ["sports.jane"]}], "rules": []}`), 0644)
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if allowed, _ := authz.Authorize("read", "sports:entity.ball", jane); !allowed {
+			break
+		}
+		if time.Now().After(deadline) {
+			test.Fatalf("Expected the policy to be reloaded")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	stop()
+}
@@ -0,0 +1,234 @@
+// Copyright 2015 Yahoo Inc.
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package rdl
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ContentTypeProblem is the content type of RFC 7807 problem details.
+const ContentTypeProblem = "application/problem+json"
+
+// ExceptionError is a service error with a typed payload: the body of one of the exceptions declared by a
+// resource, whose type is Type. Without payload, it is equivalent to a ResourceError.
+//
+// As JSON, it has the legacy shape of error responses: the payload itself, or {code,message} without one.
+type ExceptionError struct {
+	Code    int
+	Message string
+	Type    TypeRef
+	Payload interface{}
+}
+
+// ResourceException returns an ExceptionError for the status code, with the type of the exception the
+// resource declares for it, if any. The message defaults to the status text.
+func ResourceException(r *Resource, code int, message string, payload interface{}) *ExceptionError {
+	if message == "" {
+		message = http.StatusText(code)
+	}
+	e := &ExceptionError{Code: code, Message: message, Payload: payload}
+	if r != nil {
+		e.Type, _ = ExceptionType(r, code)
+	}
+	return e
+}
+
+// ExceptionType returns the type of the exception the resource declares for the status code.
+func ExceptionType(r *Resource, code int) (TypeRef, bool) {
+	sym := strconv.Itoa(code)
+	for exc, def := range r.Exceptions {
+		if StatusCode(exc) == sym {
+			return TypeRef(def.Type), true
+		}
+	}
+	return "", false
+}
+
+func (e *ExceptionError) Error() string {
+	return fmt.Sprintf("%d %s", e.Code, e.Message)
+}
+
+func (e *ExceptionError) StatusCode() int {
+	return e.Code
+}
+
+func (e *ExceptionError) MarshalJSON() ([]byte, error) {
+	if e.Payload != nil {
+		return json.Marshal(e.Payload)
+	}
+	return json.Marshal(ResourceError{e.Code, e.Message})
+}
+
+// Problem is an RFC 7807 problem details object. The payload of an ExceptionError is its "exception"
+// extension member, and the type of the exception its "type".
+type Problem struct {
+	Type      string      `json:"type,omitempty"`
+	Title     string      `json:"title,omitempty"`
+	Status    int         `json:"status,omitempty"`
+	Detail    string      `json:"detail,omitempty"`
+	Instance  string      `json:"instance,omitempty"`
+	Exception interface{} `json:"exception,omitempty"`
+}
+
+// ProblemOf returns the problem details of the error. Like ErrorResponse, an error without a status code is
+// a 500 Internal Server Error.
+func ProblemOf(err error) *Problem {
+	code, message := errorStatus(err)
+	p := &Problem{Type: "about:blank", Title: http.StatusText(code), Status: code, Detail: message}
+	var e *ExceptionError
+	if errors.As(err, &e) {
+		if e.Type != "" {
+			p.Type = string(e.Type)
+		}
+		p.Exception = e.Payload
+	}
+	return p
+}
+
+// errorStatus returns the status code and the message of an error, as ErrorResponse writes it.
+func errorStatus(err error) (int, string) {
+	var ee *ExceptionError
+	var re *ResourceError
+	var rv ResourceError
+	switch {
+	case errors.As(err, &ee):
+		return ee.Code, ee.Message
+	case errors.As(err, &re):
+		return re.Code, re.Message
+	case errors.As(err, &rv):
+		return rv.Code, rv.Message
+	}
+	if e, ok := err.(interface{ StatusCode() int }); ok {
+		return e.StatusCode(), err.Error()
+	}
+	return http.StatusInternalServerError, err.Error()
+}
+
+// ProblemResponse writes the error as application/problem+json. Like JSONResponse, it writes no body for
+// 204 and 304.
+func ProblemResponse(w http.ResponseWriter, err error) {
+	p := ProblemOf(err)
+	b, e := json.MarshalIndent(p, "", "  ")
+	if e != nil {
+		p = &Problem{Type: "about:blank", Title: http.StatusText(500), Status: 500, Detail: "Server Error"}
+		b, _ = json.MarshalIndent(p, "", "  ")
+	}
+	w.Header().Set("Content-Type", ContentTypeProblem)
+	w.WriteHeader(p.Status)
+	switch p.Status {
+	case http.StatusNoContent, http.StatusNotModified:
+		/* no body */
+	default:
+		fmt.Fprintf(w, "%s\n", string(b))
+	}
+}
+
+// WriteError writes the error as application/problem+json if the request accepts it explicitly, and
+// otherwise with ErrorResponse, in the legacy shape.
+func WriteError(w http.ResponseWriter, req *http.Request, err error) {
+	for _, accept := range req.Header.Values("Accept") {
+		for _, s := range strings.Split(accept, ",") {
+			mediaType, params, e := mime.ParseMediaType(strings.TrimSpace(s))
+			if e != nil || mediaType != ContentTypeProblem {
+				continue
+			}
+			if q, e := strconv.ParseFloat(params["q"], 64); e == nil && q == 0 {
+				continue
+			}
+			ProblemResponse(w, err)
+			return
+		}
+	}
+	ErrorResponse(w, err)
+}
+
+// ErrorDecoder decodes error responses on the client side into ExceptionErrors, with the payload decoded
+// into the Go type registered for the type of the exception, or as generic data otherwise.
+type ErrorDecoder struct {
+	types map[TypeRef]reflect.Type
+}
+
+// NewErrorDecoder returns an ErrorDecoder without registered types.
+func NewErrorDecoder() *ErrorDecoder {
+	return &ErrorDecoder{types: make(map[TypeRef]reflect.Type)}
+}
+
+// Register sets the Go type of the payload of exceptions of the type, as the type of the prototype value.
+// If the prototype is a pointer, payloads are decoded as pointers to new values.
+func (d *ErrorDecoder) Register(typename TypeRef, prototype interface{}) *ErrorDecoder {
+	d.types[typename] = reflect.TypeOf(prototype)
+	return d
+}
+
+// Decode reads the body of an error response to a request for the resource, which may be nil. The type of
+// the exception is the "type" of problem details, and otherwise the one the resource declares for the
+// status code.
+func (d *ErrorDecoder) Decode(r *Resource, resp *http.Response) *ExceptionError {
+	e := &ExceptionError{Code: resp.StatusCode, Message: http.StatusText(resp.StatusCode)}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil || len(bytes.TrimSpace(body)) == 0 {
+		return e
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType == ContentTypeProblem {
+		var p struct {
+			Problem
+			Exception json.RawMessage `json:"exception"`
+		}
+		if json.Unmarshal(body, &p) != nil {
+			return e
+		}
+		if p.Detail != "" {
+			e.Message = p.Detail
+		}
+		if p.Type != "about:blank" {
+			e.Type = TypeRef(p.Type)
+		}
+		if len(p.Exception) > 0 {
+			e.Payload = d.payload(e.Type, p.Exception)
+		}
+		return e
+	}
+	if r != nil {
+		e.Type, _ = ExceptionType(r, resp.StatusCode)
+	}
+	var legacy ResourceError
+	if json.Unmarshal(body, &legacy) == nil && legacy.Message != "" {
+		e.Message = legacy.Message
+	}
+	if e.Type != "" {
+		e.Payload = d.payload(e.Type, body)
+	}
+	return e
+}
+
+func (d *ErrorDecoder) payload(typename TypeRef, data []byte) interface{} {
+	t, ok := d.types[typename]
+	if !ok {
+		var generic interface{}
+		json.Unmarshal(data, &generic)
+		return generic
+	}
+	ptr := t.Kind() == reflect.Ptr
+	if ptr {
+		t = t.Elem()
+	}
+	v := reflect.New(t)
+	if err := json.Unmarshal(data, v.Interface()); err != nil {
+		return nil
+	}
+	if ptr {
+		return v.Interface()
+	}
+	return v.Elem().Interface()
+}
@@ -0,0 +1,98 @@
+// Copyright 2015 Yahoo Inc.
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package rdl
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+type testProblem struct {
+	Code    int32  `json:"code"`
+	Message string `json:"message"`
+}
+
+func TestExceptionError(test *testing.T) {
+	schema, err := parseRDLString(mockTestRDL)
+	if err != nil {
+		test.Fatalf("Cannot parse: %v", err)
+	}
+	r := schema.Resources[0]
+	payload := &testProblem{404, "no contact a1"}
+	e := ResourceException(r, 404, "", payload)
+	if e.Type != "Problem" || e.Message != "Not Found" {
+		test.Errorf("Expected the declared exception type and the status text, got %+v", e)
+	}
+	if b, _ := json.Marshal(e); string(b) != `{"code":404,"message":"no contact a1"}` {
+		test.Errorf("Unexpected legacy JSON: %s", b)
+	}
+	if b, _ := json.Marshal(ResourceException(r, 409, "busy", nil)); string(b) != `{"code":409,"message":"busy"}` {
+		test.Errorf("Unexpected legacy JSON without payload: %s", b)
+	}
+
+	decoder := NewErrorDecoder().Register("Problem", &testProblem{})
+	wrapped := fmt.Errorf("lookup: %w", e)
+	for _, accept := range []string{"", "application/json, application/problem+json;q=0.5"} {
+		req := httptest.NewRequest("GET", "/contacts/a1", nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		w := httptest.NewRecorder()
+		WriteError(w, req, wrapped)
+		resp := w.Result()
+		if resp.StatusCode != 404 {
+			test.Errorf("Accept %q: unexpected status %d", accept, resp.StatusCode)
+		}
+		problem := resp.Header.Get("Content-Type") == ContentTypeProblem
+		if problem != (accept != "") {
+			test.Errorf("Accept %q: unexpected content type %s", accept, resp.Header.Get("Content-Type"))
+		}
+		decoded := decoder.Decode(r, resp)
+		if decoded.Code != 404 || decoded.Type != "Problem" || !reflect.DeepEqual(decoded.Payload, payload) {
+			test.Errorf("Accept %q: unexpected decoded error: %+v", accept, decoded)
+		}
+	}
+
+	p := ProblemOf(&ResourceError{403, "Forbidden: a1"})
+	if *p != (Problem{Type: "about:blank", Title: "Forbidden", Status: 403, Detail: "Forbidden: a1"}) {
+		test.Errorf("Unexpected problem for a ResourceError: %+v", p)
+	}
+	w := httptest.NewRecorder()
+	ProblemResponse(w, fmt.Errorf("boom"))
+	decoded := NewErrorDecoder().Decode(nil, w.Result())
+	if decoded.Code != 500 || decoded.Message != "boom" || decoded.Type != "" || decoded.Payload != nil {
+		test.Errorf("Unexpected decoded problem: %+v", decoded)
+	}
+}
+
+func TestRouterExceptions(test *testing.T) {
+	schema, _ := parseRDLString(mockTestRDL)
+	gone := &ExceptionError{Code: 404, Message: "gone", Payload: map[string]interface{}{"code": 404, "message": "gone"}}
+	router, _ := NewRouter(schema, map[Identifier]ResourceHandler{
+		"getContact": func(ctx *ResourceContext, args map[string]interface{}) (interface{}, error) {
+			return nil, gone
+		},
+	})
+	req := httptest.NewRequest("GET", "/contacts/a1", nil)
+	req.Header.Set("Accept", ContentTypeProblem)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var p Problem
+	json.Unmarshal(w.Body.Bytes(), &p)
+	if w.Code != 404 || p.Type != "Problem" || p.Detail != "gone" || p.Exception == nil {
+		test.Errorf("Unexpected problem from the router: %d %s", w.Code, w.Body)
+	}
+	if gone.Type != "" {
+		test.Errorf("Expected the error returned by the handler to be unchanged, got type %q", gone.Type)
+	}
+
+	w = httptest.NewRecorder()
+	ProblemResponse(w, &ResourceError{304, "Not Modified"})
+	if w.Code != 304 || w.Body.Len() != 0 {
+		test.Errorf("Expected a 304 problem without body, got %d %q", w.Code, w.Body)
+	}
+}
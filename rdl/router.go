@@ -0,0 +1,419 @@
+// Copyright 2015 Yahoo Inc.
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package rdl
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ResourceHandler handles a request routed to a resource by a Router. The args hold the inputs of the
+// resource by name as generic data, the way encoding/json decodes it: path, query and header params converted
+// to their type, with integers as int64, and the request body. Absent optional inputs without a default are
+// not in the args.
+//
+// The result is written as JSON with the expected status code of the resource. An error with a status code,
+// like a ResourceError, is written with its code, which is also how an alternative response such as 304 Not
+// Modified is returned. Any other error is written as a 500 ResourceError. Errors are written as problem
+// details to requests that accept them (see WriteError), and an ExceptionError without type gets the type of
+// the exception the resource declares for its code.
+type ResourceHandler func(ctx *ResourceContext, args map[string]interface{}) (interface{}, error)
+
+// ResourceMiddleware wraps the handler of a resource. It runs after the inputs of the request are extracted
+// and checked, with them as the args of the handler.
+type ResourceMiddleware func(r *Resource, next ResourceHandler) ResourceHandler
+
+// ResourceGuard checks a request routed to a resource before its inputs are extracted, so that the request
+// body is not read and the inputs are not checked if it fails, i.e. to authenticate it. The context has no
+// args yet.
+type ResourceGuard func(ctx *ResourceContext, r *Resource) error
+
+// Router is an http.Handler that serves the resources of a schema without generated code. Requests are
+// matched against the method and path template of each resource, their inputs are checked against the
+// types of the schema, and they are dispatched to the handler registered for the name of the resource.
+type Router struct {
+	schema     *Schema
+	binder     *ParamBinder
+	checker    *validator
+	routes     []*route
+	handlers   map[Identifier]ResourceHandler
+	middleware []ResourceMiddleware
+	guards     []ResourceGuard
+	maxBody    int64
+}
+
+// DefaultMaxBodySize is the default limit of the size of request bodies read by a Router.
+const DefaultMaxBodySize = 1 << 20
+
+// route is a resource with its path template compiled to a regular expression.
+type route struct {
+	resource *Resource
+	name     Identifier
+	matcher  *regexp.Regexp
+	params   []string
+	groups   []int
+	literals int
+}
+
+// NewRouter compiles the resources of the schema, and registers the handlers keyed by resource name, as
+// returned by ResourceName.
+func NewRouter(schema *Schema, handlers map[Identifier]ResourceHandler) (*Router, error) {
+	binder := NewParamBinder(schema)
+	router := &Router{
+		schema:   schema,
+		binder:   binder,
+		checker:  binder.checker,
+		handlers: make(map[Identifier]ResourceHandler),
+		maxBody:  DefaultMaxBodySize,
+	}
+	names := make(map[Identifier]bool)
+	for _, r := range schema.Resources {
+		rt, err := router.compileRoute(r)
+		if err != nil {
+			return nil, err
+		}
+		if names[rt.name] {
+			return nil, fmt.Errorf("Duplicate resource name: %s", rt.name)
+		}
+		names[rt.name] = true
+		router.routes = append(router.routes, rt)
+	}
+	//literal path segments take precedence over params, i.e. "/users/me" is matched before "/users/{id}"
+	sort.SliceStable(router.routes, func(i, j int) bool {
+		return router.routes[i].literals > router.routes[j].literals
+	})
+	for name, handler := range handlers {
+		if err := router.Handle(name, handler); err != nil {
+			return nil, err
+		}
+	}
+	return router, nil
+}
+
+// ResourceName returns the name of the resource, or else its lowercase method followed by its type,
+// i.e. "getContact".
+func ResourceName(r *Resource) Identifier {
+	if r.Name != "" {
+		return r.Name
+	}
+	return Identifier(strings.ToLower(r.Method) + string(r.Type))
+}
+
+// Handle registers the handler of the named resource, replacing any previous one.
+func (router *Router) Handle(name Identifier, handler ResourceHandler) error {
+	for _, rt := range router.routes {
+		if rt.name == name {
+			router.handlers[name] = handler
+			return nil
+		}
+	}
+	return fmt.Errorf("No such resource: %s", name)
+}
+
+// Use adds middleware around the handlers of all resources. The first one added is the outermost.
+func (router *Router) Use(middleware ...ResourceMiddleware) {
+	router.middleware = append(router.middleware, middleware...)
+}
+
+// SetMaxBodySize sets the limit of the size of request bodies, in bytes. Larger bodies are rejected with a
+// 413 ResourceError.
+func (router *Router) SetMaxBodySize(n int64) {
+	router.maxBody = n
+}
+
+// Guard adds guards that check all requests, in order, before their inputs are extracted.
+func (router *Router) Guard(guards ...ResourceGuard) {
+	router.guards = append(router.guards, guards...)
+}
+
+func (router *Router) compileRoute(r *Resource) (*route, error) {
+	rt := &route{resource: r, name: ResourceName(r)}
+	for _, in := range r.Inputs {
+		if in.Context == "" && router.checker.registry.FindType(in.Type) == nil {
+			return nil, fmt.Errorf("Resource %s: no such type for input '%s': %s", rt.name, in.Name, in.Type)
+		}
+	}
+	path := strings.TrimSuffix(router.schema.Base, "/") + r.Path
+	var expr strings.Builder
+	expr.WriteString("^")
+	for _, segment := range strings.Split(strings.TrimPrefix(path, "/"), "/") {
+		expr.WriteString("/")
+		if !strings.Contains(segment, "{") {
+			rt.literals++
+		}
+		for segment != "" {
+			i := strings.Index(segment, "{")
+			j := strings.Index(segment, "}")
+			if i < 0 || j < i {
+				expr.WriteString(regexp.QuoteMeta(segment))
+				break
+			}
+			expr.WriteString(regexp.QuoteMeta(segment[:i]))
+			name, pattern, _ := strings.Cut(segment[i+1:j], ":")
+			if in := pathInput(r, name); in != nil && in.Pattern != "" {
+				pattern = in.Pattern
+			}
+			if pattern == "" {
+				pattern = "[^/]+"
+			}
+			fmt.Fprintf(&expr, "(?P<p%d>%s)", len(rt.params), pattern)
+			rt.params = append(rt.params, name)
+			segment = segment[j+1:]
+		}
+	}
+	expr.WriteString("$")
+	matcher, err := regexp.Compile(expr.String())
+	if err != nil {
+		return nil, fmt.Errorf("Resource %s: bad path template %q: %v", rt.name, r.Path, err)
+	}
+	rt.matcher = matcher
+	//the patterns of params may have groups of their own, so their values are found by group name
+	for i := range rt.params {
+		rt.groups = append(rt.groups, matcher.SubexpIndex(fmt.Sprintf("p%d", i)))
+	}
+	return rt, nil
+}
+
+func pathInput(r *Resource, name string) *ResourceInput {
+	for _, in := range r.Inputs {
+		if in.PathParam && string(in.Name) == name {
+			return in
+		}
+	}
+	return nil
+}
+
+// Lookup returns the resource matching the method and the escaped path of a request, with the values of
+// its path params. If there is none, the error is a 404 or 405 ResourceError.
+func (router *Router) Lookup(method string, path string) (*Resource, map[string]string, error) {
+	rt, params, err := router.lookup(method, path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return rt.resource, params, nil
+}
+
+func (router *Router) lookup(method string, path string) (*route, map[string]string, error) {
+	found := false
+	for _, rt := range router.routes {
+		groups := rt.matcher.FindStringSubmatch(path)
+		if groups == nil {
+			continue
+		}
+		if rt.resource.Method != method {
+			found = true
+			continue
+		}
+		params := make(map[string]string, len(rt.params))
+		for i, name := range rt.params {
+			value := groups[rt.groups[i]]
+			s, err := url.PathUnescape(value)
+			if err != nil {
+				return nil, nil, &ResourceError{400, "Bad value for path parameter '" + name + "': " + value}
+			}
+			params[name] = s
+		}
+		return rt, params, nil
+	}
+	if found {
+		return nil, nil, &ResourceError{405, "Method Not Allowed"}
+	}
+	return nil, nil, &ResourceError{404, "Not Found"}
+}
+
+// methods returns the methods of the resources matching the path, for the Allow header of a 405 response.
+func (router *Router) methods(path string) []string {
+	var methods []string
+	for _, rt := range router.routes {
+		if rt.matcher.MatchString(path) {
+			methods = append(methods, rt.resource.Method)
+		}
+	}
+	return methods
+}
+
+func (router *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	rt, ctx, args := router.prepare(w, req)
+	if rt == nil {
+		return
+	}
+	handler := router.handlers[rt.name]
+	if handler == nil {
+		WriteError(w, req, &ResourceError{501, "Not Implemented: " + string(rt.name)})
+		return
+	}
+	for i := len(router.middleware) - 1; i >= 0; i-- {
+		handler = router.middleware[i](rt.resource, handler)
+	}
+	data, err := handler(ctx, args)
+	if err != nil {
+		//the error may be shared by requests, so its type is set on a copy
+		var ee *ExceptionError
+		if errors.As(err, &ee) && ee.Type == "" {
+			typed := *ee
+			typed.Type, _ = ExceptionType(rt.resource, ee.Code)
+			err = &typed
+		}
+		WriteError(w, req, err)
+		return
+	}
+	JSONResponse(w, ExpectedStatus(rt.resource), data)
+}
+
+// prepare routes the request, checks it with the guards and extracts its inputs. If that fails, the error
+// response is written and the route is nil.
+func (router *Router) prepare(w http.ResponseWriter, req *http.Request) (*route, *ResourceContext, map[string]interface{}) {
+	rt, params, err := router.lookup(req.Method, req.URL.EscapedPath())
+	if err != nil {
+		if err.(*ResourceError).Code == http.StatusMethodNotAllowed {
+			w.Header().Set("Allow", strings.Join(router.methods(req.URL.EscapedPath()), ", "))
+		}
+		WriteError(w, req, err)
+		return nil, nil, nil
+	}
+	ctx := &ResourceContext{Writer: w, Request: req, Params: params}
+	for _, guard := range router.guards {
+		if err := guard(ctx, rt.resource); err != nil {
+			WriteError(w, req, err)
+			return nil, nil, nil
+		}
+	}
+	args, err := router.inputs(rt.resource, req, params)
+	if err != nil {
+		WriteError(w, req, err)
+		return nil, nil, nil
+	}
+	return rt, ctx, args
+}
+
+// ExpectedStatus returns the numeric status code of the expected response of the resource.
+func ExpectedStatus(r *Resource) int {
+	if code, err := strconv.Atoi(StatusCode(r.Expected)); err == nil {
+		return code
+	}
+	return http.StatusOK
+}
+
+// ErrorResponse writes the error as JSON. An error with a StatusCode method, like ResourceError or
+// ExceptionError, is written with that status code, any other error as a 500 ResourceError.
+func ErrorResponse(w http.ResponseWriter, err error) {
+	var ee *ExceptionError
+	if errors.As(err, &ee) {
+		JSONResponse(w, ee.Code, ee)
+		return
+	}
+	var re *ResourceError
+	if errors.As(err, &re) {
+		JSONResponse(w, re.Code, re)
+		return
+	}
+	var rv ResourceError
+	if errors.As(err, &rv) {
+		JSONResponse(w, rv.Code, rv)
+		return
+	}
+	if e, ok := err.(interface{ StatusCode() int }); ok {
+		JSONResponse(w, e.StatusCode(), err)
+		return
+	}
+	JSONResponse(w, http.StatusInternalServerError, ResourceError{500, err.Error()})
+}
+
+// inputs extracts the inputs of the resource from the request, checking them against their types.
+func (router *Router) inputs(r *Resource, req *http.Request, params map[string]string) (map[string]interface{}, error) {
+	args := make(map[string]interface{}, len(r.Inputs))
+	query := req.URL.Query()
+	for _, in := range r.Inputs {
+		var value interface{}
+		var err error
+		switch {
+		case in.Context != "":
+			continue
+		case isParam(in):
+			if values := paramValues(req, query, in, params); values != nil {
+				value, err = router.binder.generic(in, values)
+			} else {
+				value, err = absentParam(in)
+			}
+		default:
+			value, err = router.body(in, req)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if value != nil {
+			args[string(in.Name)] = value
+		}
+	}
+	return args, nil
+}
+
+// body decodes the JSON request body and validates it against the type of the input. An absent optional
+// body is returned as nil.
+func (router *Router) body(in *ResourceInput, req *http.Request) (interface{}, error) {
+	data, err := readBody(req, router.maxBody)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		if in.Optional {
+			return nil, nil
+		}
+		return nil, &ResourceError{400, "Missing request body"}
+	}
+	var body interface{}
+	if err := json.Unmarshal(data, &body); err != nil {
+		return nil, &ResourceError{400, "Bad request body: " + err.Error()}
+	}
+	if v := validateWithValidator(router.checker, string(in.Type), body); !v.Valid {
+		return nil, &ResourceError{400, fmt.Sprintf("Invalid %s in request body: %s (%s)", in.Type, v.Error, v.Context)}
+	}
+	return body, nil
+}
+
+// readBody reads the request body, failing with a 413 ResourceError if it is larger than the limit.
+func readBody(req *http.Request, limit int64) ([]byte, error) {
+	if req.Body == nil {
+		return nil, nil
+	}
+	data, err := io.ReadAll(http.MaxBytesReader(nil, req.Body, limit))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return nil, &ResourceError{http.StatusRequestEntityTooLarge, fmt.Sprintf("Request body larger than %d bytes", limit)}
+		}
+		return nil, &ResourceError{400, "Cannot read the request body: " + err.Error()}
+	}
+	return data, nil
+}
+
+// declaredStatus returns the numeric code of a status declared by the resource, and the type of its body.
+func declaredStatus(r *Resource, status string) (int, TypeRef, error) {
+	sym := StatusCode(status)
+	code, err := strconv.Atoi(sym)
+	if err == nil {
+		if sym == StatusCode(r.Expected) {
+			return code, r.Type, nil
+		}
+		for _, alt := range r.Alternatives {
+			if sym == StatusCode(alt) {
+				return code, r.Type, nil
+			}
+		}
+		for exc, def := range r.Exceptions {
+			if sym == StatusCode(exc) {
+				return code, TypeRef(def.Type), nil
+			}
+		}
+	}
+	return 0, "", &ResourceError{400, fmt.Sprintf("Status not declared by resource %s: %s", ResourceName(r), status)}
+}
@@ -0,0 +1,155 @@
+// Copyright 2015 Yahoo Inc.
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package rdl
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const routerTestRDL = `
+base "/api";
+type Id String (pattern="[a-z0-9]+");
+type Count Int32 (min=1, max=100);
+type Contact Struct { Id id; String name; }
+type Contacts Struct { Array<Contact> list; }
+resource Contacts GET "/contacts?limit={limit}&all" {
+    Count limit (default=10);
+    Bool all;
+    String tag (header="X-Tag", optional);
+}
+resource Contact GET "/contacts/{id}" (name=getContact) {
+    Id id;
+}
+resource Contact GET "/contacts/me" (name=getMe) {
+}
+resource Contact PUT "/contacts/{id}" (name=putContact) {
+    Id id;
+    Contact contact;
+    expected NO_CONTENT;
+}
+resource Contact GET "/files/{path:.+}" (name=getFile) {
+    String path;
+}
+resource Contact GET "/pairs/{x:(foo|bar)}/{y}" (name=getPair) {
+    String x;
+    String y;
+}
+`
+
+type routerTestCase struct {
+	method string
+	path   string
+	body   string
+	code   int
+	result string
+}
+
+func TestRouter(test *testing.T) {
+	schema, err := parseRDLString(routerTestRDL)
+	if err != nil {
+		test.Fatalf("Cannot parse: %v", err)
+	}
+	echo := func(ctx *ResourceContext, args map[string]interface{}) (interface{}, error) {
+		return args, nil
+	}
+	router, err := NewRouter(schema, map[Identifier]ResourceHandler{
+		"getContacts": echo,
+		"getContact":  echo,
+		"getMe":       echo,
+		"getFile":     echo,
+		"getPair":     echo,
+		"putContact": func(ctx *ResourceContext, args map[string]interface{}) (interface{}, error) {
+			if ctx.Params["id"] != "x1" {
+				return nil, &ResourceError{404, "No such contact: " + ctx.Params["id"]}
+			}
+			return nil, nil
+		},
+	})
+	if err != nil {
+		test.Fatalf("Cannot create router: %v", err)
+	}
+	for _, c := range []routerTestCase{
+		{"GET", "/api/contacts", "", 200, `{"all":false,"limit":10}`},
+		{"GET", "/api/contacts?limit=5&all", "", 200, `{"all":true,"limit":5}`},
+		{"GET", "/api/contacts?limit=500", "", 400, ""},
+		{"GET", "/api/contacts?limit=five", "", 400, ""},
+		{"GET", "/api/contacts?all=maybe", "", 400, ""},
+		{"GET", "/api/contacts/me", "", 200, `{}`},
+		{"GET", "/api/contacts/a1", "", 200, `{"id":"a1"}`},
+		{"GET", "/api/contacts/A1", "", 400, ""},
+		{"GET", "/api/files/a/b%20c", "", 200, `{"path":"a/b c"}`},
+		{"GET", "/api/pairs/foo/zzz", "", 200, `{"x":"foo","y":"zzz"}`},
+		{"GET", "/api/pairs/baz/zzz", "", 404, ""},
+		{"GET", "/api/other", "", 404, ""},
+		{"DELETE", "/api/contacts/a1", "", 405, ""},
+		{"PUT", "/api/contacts/x1", `{"id":"x1","name":"X"}`, 204, ""},
+		{"PUT", "/api/contacts/x2", `{"id":"x2","name":"X"}`, 404, ""},
+		{"PUT", "/api/contacts/x1", `{"id":"x1"}`, 400, ""},
+		{"PUT", "/api/contacts/x1", `{`, 400, ""},
+		{"PUT", "/api/contacts/x1", "", 400, ""},
+	} {
+		req := httptest.NewRequest(c.method, c.path, strings.NewReader(c.body))
+		req.Header.Set("X-Tag", "")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != c.code {
+			test.Errorf("%s %s: expected status %d, got %d: %s", c.method, c.path, c.code, w.Code, w.Body)
+			continue
+		}
+		if c.result != "" {
+			var result map[string]interface{}
+			json.Unmarshal(w.Body.Bytes(), &result)
+			delete(result, "tag")
+			if b, _ := json.Marshal(result); string(b) != c.result {
+				test.Errorf("%s %s: unexpected result %s", c.method, c.path, b)
+			}
+		} else if w.Code >= 400 {
+			var e ResourceError
+			if err := json.Unmarshal(w.Body.Bytes(), &e); err != nil || e.Code != c.code || e.Message == "" {
+				test.Errorf("%s %s: expected a ResourceError, got %s", c.method, c.path, w.Body)
+			}
+		}
+	}
+
+	req := httptest.NewRequest("DELETE", "/api/contacts/a1", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if allow := w.Header().Get("Allow"); allow != "GET, PUT" {
+		test.Errorf("Unexpected Allow header: %q", allow)
+	}
+	router.SetMaxBodySize(16)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("PUT", "/api/contacts/x1", strings.NewReader(`{"id":"x1","name":"X"}`)))
+	if w.Code != 413 {
+		test.Errorf("Expected a body over the limit to be rejected, got %d", w.Code)
+	}
+	if r, params, err := router.Lookup("GET", "/api/contacts/b2"); err != nil || r.Name != "getContact" || params["id"] != "b2" {
+		test.Errorf("Cannot look up a resource: %v, %v", params, err)
+	}
+}
+
+func TestRouterErrors(test *testing.T) {
+	schema, _ := parseRDLString(routerTestRDL)
+	router, _ := NewRouter(schema, nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/contacts/me", nil))
+	if w.Code != http.StatusNotImplemented {
+		test.Errorf("Expected a resource without handler to be Not Implemented, got %d", w.Code)
+	}
+	if err := router.Handle("deleteContact", nil); err == nil {
+		test.Errorf("Expected an error registering the handler of an unknown resource")
+	}
+	router.Handle("getMe", func(ctx *ResourceContext, args map[string]interface{}) (interface{}, error) {
+		return nil, http.ErrHandlerTimeout
+	})
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/contacts/me", nil))
+	if w.Code != http.StatusInternalServerError || !strings.Contains(w.Body.String(), "timeout") {
+		test.Errorf("Expected a 500 ResourceError for an error without a status code, got %d: %s", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,227 @@
+// Copyright 2015 Yahoo Inc.
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package rdl
+
+import (
+	"sort"
+)
+
+// DependencyKind describes how one type refers to another.
+type DependencyKind int
+
+const (
+	_ DependencyKind = iota
+	DependencySupertype
+	DependencyField
+	DependencyItems
+	DependencyKeys
+	DependencyVariant
+)
+
+var namesDependencyKind = []string{
+	DependencySupertype: "supertype",
+	DependencyField:     "field",
+	DependencyItems:     "items",
+	DependencyKeys:      "keys",
+	DependencyVariant:   "variant",
+}
+
+func (kind DependencyKind) String() string {
+	if kind <= 0 || int(kind) >= len(namesDependencyKind) {
+		return "unknown"
+	}
+	return namesDependencyKind[kind]
+}
+
+// TypeDependency is an edge in a TypeGraph, from a type to one of the user types it refers to. For field
+// dependencies, and for the items or keys of an inline collection field, Field names the field.
+type TypeDependency struct {
+	Kind  DependencyKind
+	Type  TypeName
+	Field Identifier
+}
+
+// TypeGraph is the dependency graph of the user types in a schema. Base types are not part of the graph,
+// and type references are resolved case-insensitively, as the TypeRegistry does.
+type TypeGraph struct {
+	schema     *Schema
+	registry   TypeRegistry
+	deps       map[TypeName][]*TypeDependency
+	dependents map[TypeName][]TypeName
+}
+
+// NewTypeGraph builds the dependency graph for the types in the schema.
+func NewTypeGraph(schema *Schema) *TypeGraph {
+	g := &TypeGraph{
+		schema:     schema,
+		registry:   NewTypeRegistry(schema),
+		deps:       make(map[TypeName][]*TypeDependency),
+		dependents: make(map[TypeName][]TypeName),
+	}
+	for _, t := range schema.Types {
+		tName, tSuper, _ := TypeInfo(t)
+		var deps []*TypeDependency
+		add := func(kind DependencyKind, ref TypeRef, field Identifier) {
+			if dep := g.userType(ref); dep != "" {
+				deps = append(deps, &TypeDependency{Kind: kind, Type: dep, Field: field})
+			}
+		}
+		add(DependencySupertype, tSuper, "")
+		switch t.Variant {
+		case TypeVariantStructTypeDef:
+			for _, f := range t.StructTypeDef.Fields {
+				add(DependencyField, f.Type, f.Name)
+				add(DependencyItems, f.Items, f.Name)
+				add(DependencyKeys, f.Keys, f.Name)
+			}
+		case TypeVariantArrayTypeDef:
+			add(DependencyItems, t.ArrayTypeDef.Items, "")
+		case TypeVariantMapTypeDef:
+			add(DependencyKeys, t.MapTypeDef.Keys, "")
+			add(DependencyItems, t.MapTypeDef.Items, "")
+		case TypeVariantUnionTypeDef:
+			for _, v := range t.UnionTypeDef.Variants {
+				add(DependencyVariant, v, "")
+			}
+		}
+		g.deps[tName] = deps
+		seen := make(map[TypeName]bool)
+		for _, dep := range deps {
+			if !seen[dep.Type] {
+				seen[dep.Type] = true
+				g.dependents[dep.Type] = append(g.dependents[dep.Type], tName)
+			}
+		}
+	}
+	return g
+}
+
+// userType returns the declared name of the user type the reference resolves to, or "" if it is a base type
+// or not defined at all.
+func (g *TypeGraph) userType(ref TypeRef) TypeName {
+	if ref == "" {
+		return ""
+	}
+	t := g.registry.FindType(ref)
+	if t == nil || t.Variant == TypeVariantBaseType {
+		return ""
+	}
+	tName, _, _ := TypeInfo(t)
+	return tName
+}
+
+// Dependencies returns the direct dependencies of the named type, in declaration order.
+func (g *TypeGraph) Dependencies(name TypeName) []*TypeDependency {
+	return g.deps[g.userType(TypeRef(name))]
+}
+
+// Dependents returns the types that refer directly to the named type.
+func (g *TypeGraph) Dependents(name TypeName) []TypeName {
+	return g.dependents[g.userType(TypeRef(name))]
+}
+
+// ResourceTypes returns the user types a resource refers to directly: its own type, and the types of its
+// inputs, outputs and exceptions.
+func (g *TypeGraph) ResourceTypes(r *Resource) []TypeName {
+	var names []TypeName
+	seen := make(map[TypeName]bool)
+	add := func(ref TypeRef) {
+		if n := g.userType(ref); n != "" && !seen[n] {
+			seen[n] = true
+			names = append(names, n)
+		}
+	}
+	add(r.Type)
+	for _, in := range r.Inputs {
+		add(in.Type)
+	}
+	for _, out := range r.Outputs {
+		add(out.Type)
+	}
+	for _, sym := range sortedExceptionSymbols(r) {
+		add(TypeRef(r.Exceptions[sym].Type))
+	}
+	return names
+}
+
+// Reachable returns the named types along with everything they depend on, transitively, in schema order.
+func (g *TypeGraph) Reachable(roots ...TypeName) []TypeName {
+	reached := make(map[TypeName]bool)
+	var visit func(name TypeName)
+	visit = func(name TypeName) {
+		if name == "" || reached[name] {
+			return
+		}
+		reached[name] = true
+		for _, dep := range g.deps[name] {
+			visit(dep.Type)
+		}
+	}
+	for _, root := range roots {
+		visit(g.userType(TypeRef(root)))
+	}
+	var names []TypeName
+	for _, t := range g.schema.Types {
+		if tName, _, _ := TypeInfo(t); reached[tName] {
+			names = append(names, tName)
+		}
+	}
+	return names
+}
+
+// ResourceClosure returns every type needed by the given resources, in schema order.
+func (g *TypeGraph) ResourceClosure(resources ...*Resource) []TypeName {
+	var roots []TypeName
+	for _, r := range resources {
+		roots = append(roots, g.ResourceTypes(r)...)
+	}
+	return g.Reachable(roots...)
+}
+
+// UnreachableTypes returns the types that no resource in the schema needs, in schema order.
+func (g *TypeGraph) UnreachableTypes() []TypeName {
+	reached := make(map[TypeName]bool)
+	for _, n := range g.ResourceClosure(g.schema.Resources...) {
+		reached[n] = true
+	}
+	var names []TypeName
+	for _, t := range g.schema.Types {
+		if tName, _, _ := TypeInfo(t); !reached[tName] {
+			names = append(names, tName)
+		}
+	}
+	return names
+}
+
+// PruneSchema returns a copy of the schema with only the given resources, and only the types they need.
+// Type order is preserved, so the result is still suitable for code generation. The type and resource
+// definitions themselves are shared with the original schema, not copied.
+func PruneSchema(schema *Schema, resources []*Resource) *Schema {
+	g := NewTypeGraph(schema)
+	needed := make(map[TypeName]bool)
+	for _, n := range g.ResourceClosure(resources...) {
+		needed[n] = true
+	}
+	pruned := *schema
+	pruned.Types = nil
+	for _, t := range schema.Types {
+		if tName, _, _ := TypeInfo(t); needed[tName] {
+			pruned.Types = append(pruned.Types, t)
+		}
+	}
+	pruned.Resources = nil
+	if len(resources) > 0 {
+		pruned.Resources = append([]*Resource(nil), resources...)
+	}
+	return &pruned
+}
+
+func sortedExceptionSymbols(r *Resource) []string {
+	syms := make([]string, 0, len(r.Exceptions))
+	for sym := range r.Exceptions {
+		syms = append(syms, sym)
+	}
+	sort.Strings(syms)
+	return syms
+}
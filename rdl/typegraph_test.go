@@ -0,0 +1,71 @@
+// Copyright 2015 Yahoo Inc.
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package rdl
+
+import (
+	"reflect"
+	"testing"
+)
+
+const typeGraphTestRDL = `
+type Id String (pattern="[a-z]+");
+type Tag String;
+type Base Struct { Id id; }
+type Item Base { Array<Tag> tags; }
+type Items Array<Item>;
+type Color Enum { RED, GREEN }
+type Shape Union<Item,Color>;
+type Unused Struct { Map<Id,Shape> shapes; }
+type Orphan String;
+type Problem Struct { String message; }
+resource Items GET "/items" {
+    exceptions { Problem BAD_REQUEST; }
+}
+resource Item GET "/items/{id}" {
+    Id id;
+}
+`
+
+func TestTypeGraph(test *testing.T) {
+	schema, err := parseRDLString(typeGraphTestRDL)
+	if err != nil {
+		test.Fatalf("Cannot parse: %v", err)
+	}
+	g := NewTypeGraph(schema)
+	deps := g.Dependencies("Item")
+	if len(deps) != 2 || deps[0].Kind != DependencySupertype || deps[0].Type != "Base" || deps[1].Kind != DependencyItems || deps[1].Type != "Tag" || deps[1].Field != "tags" {
+		test.Errorf("Bad dependencies for Item: %v", deps)
+	}
+	if dependents := g.Dependents("id"); !reflect.DeepEqual(dependents, []TypeName{"Base", "Unused"}) {
+		test.Errorf("Bad dependents for Id: %v", dependents)
+	}
+	if reached := g.Reachable("Shape"); !reflect.DeepEqual(reached, []TypeName{"Id", "Tag", "Base", "Item", "Color", "Shape"}) {
+		test.Errorf("Bad closure for Shape: %v", reached)
+	}
+	if unreachable := g.UnreachableTypes(); !reflect.DeepEqual(unreachable, []TypeName{"Color", "Shape", "Unused", "Orphan"}) {
+		test.Errorf("Bad unreachable types: %v", unreachable)
+	}
+}
+
+func TestPruneSchema(test *testing.T) {
+	schema, err := parseRDLString(typeGraphTestRDL)
+	if err != nil {
+		test.Fatalf("Cannot parse: %v", err)
+	}
+	pruned := PruneSchema(schema, schema.Resources[:1])
+	var names []TypeName
+	for _, t := range pruned.Types {
+		tName, _, _ := TypeInfo(t)
+		names = append(names, tName)
+	}
+	if !reflect.DeepEqual(names, []TypeName{"Id", "Tag", "Base", "Item", "Items", "Problem"}) {
+		test.Errorf("Bad pruned types: %v", names)
+	}
+	if len(pruned.Resources) != 1 || len(schema.Resources) != 2 {
+		test.Errorf("Bad pruned resources: %v", pruned.Resources)
+	}
+	if v := Validate(pruned, "Items", []interface{}{map[string]interface{}{"id": "abc", "tags": []interface{}{"x"}}}); !v.Valid {
+		test.Errorf("Pruned schema cannot validate: %v", v)
+	}
+}
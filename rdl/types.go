@@ -113,6 +113,31 @@ func TypeInfo(t *Type) (TypeName, TypeRef, string) {
 	}
 }
 
+// TypeAnnotations returns the extended annotations of a type, or nil for base types.
+func TypeAnnotations(t *Type) map[ExtendedAnnotation]string {
+	switch t.Variant {
+	case TypeVariantAliasTypeDef:
+		return t.AliasTypeDef.Annotations
+	case TypeVariantStringTypeDef:
+		return t.StringTypeDef.Annotations
+	case TypeVariantNumberTypeDef:
+		return t.NumberTypeDef.Annotations
+	case TypeVariantArrayTypeDef:
+		return t.ArrayTypeDef.Annotations
+	case TypeVariantMapTypeDef:
+		return t.MapTypeDef.Annotations
+	case TypeVariantStructTypeDef:
+		return t.StructTypeDef.Annotations
+	case TypeVariantBytesTypeDef:
+		return t.BytesTypeDef.Annotations
+	case TypeVariantEnumTypeDef:
+		return t.EnumTypeDef.Annotations
+	case TypeVariantUnionTypeDef:
+		return t.UnionTypeDef.Annotations
+	}
+	return nil
+}
+
 func (reg *typeRegistry) addType(t *Type) {
 	tName, _, _ := TypeInfo(t)
 	n := strings.ToLower(string(tName))
@@ -82,6 +82,11 @@ func unparseType(reg TypeRegistry, t *Type) string {
 	}
 }
 
+// NumberString returns the literal of a number, as written in RDL source.
+func NumberString(n *Number) string {
+	return unparseNumberValue(n)
+}
+
 func unparseNumberValue(n *Number) string {
 	switch n.Variant {
 	case NumberVariantInt8:
@@ -238,7 +238,7 @@ func (checker *validator) flattenStringConstraints(t *Type, name TypeName, patte
 func (checker *validator) validateString(t *Type, rawdata interface{}, context string) Validation {
 	name, pattern, values, min, max := checker.flattenStringConstraints(t, "", "", nil, nil, nil)
 	data := fmt.Sprintf("%s", rawdata)
-	if strings.HasPrefix(data, "%!s") || data[0] == '&' {
+	if strings.HasPrefix(data, "%!s") || strings.HasPrefix(data, "&") {
 		return checker.bad(context, "Not a string", rawdata, name)
 	}
 	if min != nil {
@@ -333,13 +333,13 @@ func (checker *validator) validateFloat(t *Type, data float64, context string) V
 	if t.Variant == TypeVariantNumberTypeDef {
 		typedef := t.NumberTypeDef
 		if typedef.Min != nil {
-			min := toFloat(typedef.Min, -math.MaxFloat64)
+			min := numberValue(typedef.Min)
 			if data < min {
 				return checker.bad(context, "Value is less than 'min' constraint", data, typedef.Name)
 			}
 		}
 		if typedef.Max != nil {
-			max := toFloat(typedef.Max, math.MaxFloat64)
+			max := numberValue(typedef.Max)
 			if data > max {
 				return checker.bad(context, "Value is greater than 'max' constraint", data, typedef.Name)
 			}
@@ -119,3 +119,21 @@ type Bar Foo (maxSize=4);
 		test.Errorf("Validation error did not occur, string is too long: %v\nschema is: %v", validation, schema)
 	}
 }
+
+func TestValidateNumberRange(test *testing.T) {
+	schema, err := parseRDLString(`type Count Int32 (min=1, max=100); type Name String;`)
+	if err != nil {
+		test.Fatalf("cannot parse valid RDL: %v", err)
+	}
+	for _, n := range []float64{0, 101} {
+		if validation := Validate(schema, "Count", n); validation.Valid {
+			test.Errorf("Validation error did not occur, %v is out of range", n)
+		}
+	}
+	if validation := Validate(schema, "Count", 100.0); !validation.Valid {
+		test.Errorf("Unexpected validation error: %v", validation)
+	}
+	if validation := Validate(schema, "Name", ""); !validation.Valid {
+		test.Errorf("Unexpected validation error for an empty string: %v", validation)
+	}
+}
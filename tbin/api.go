@@ -28,7 +28,9 @@ type TBinMarshallable interface {
 //
 func Marshal(data interface{}) ([]byte, error) {
 	enc := NewEncoder(nil)
-	enc.Encode(data)
+	if err := enc.Encode(data); err != nil {
+		return nil, err
+	}
 	return enc.Bytes(), enc.Error()
 }
 
@@ -46,6 +48,8 @@ type Encoder struct {
 	nextSymId int
 	tagged    bool
 	bytebuf   []byte
+	version   int
+	flushed   bool
 }
 
 // NewEncoder - create and return a new Encoder. This is a "session" for tbin, i.e. accumulated
@@ -54,6 +58,7 @@ func NewEncoder(w io.Writer) *Encoder {
 	enc := Encoder{syms: make(map[string]int, 0), tags: make(map[string]*tagDef, 0), nextTag: FirstUserTag}
 	enc.out = w
 	enc.bytebuf = make([]byte, 32)
+	enc.version = CurrentVersion
 	enc.writeHeader()
 	return &enc
 }
@@ -74,8 +79,7 @@ type TBinUnmarshallable interface {
 // it can, allocating substructure as needed.
 // In this, it tries to imitate the encoding/json behavior.
 func Unmarshal(b []byte, data interface{}) error {
-	in := bytes.NewReader(b)
-	decoder := NewDecoder(in)
+	decoder := NewBytesDecoder(b)
 	return decoder.Decode(data)
 }
 
@@ -92,17 +96,32 @@ type Decoder struct {
 	//	currentCursor *structCursor
 	err        error
 	pendingTag int
-	in         *bufio.Reader
+	in         byteSource
+	stream     []*streamFrame
+	options    DecoderOptions
+	depth      int
+	//the number of elements of empty types decoded, see checkEmptyElements
+	emptyElements int
+	//the inputs that in refers to, kept to be reused by Reset and ResetBytes
+	reader   *bufio.Reader
+	bytes    sliceReader
+	limit    limitReader
+	scratch  []byte
+	zeroCopy bool
 }
 
 // NewDecoder - create and return a new Encoder. This is a "session" for tbin, i.e. accumulated
 // state for this encoder can make repeated Marshal calls more efficient.
 func NewDecoder(r io.Reader) *Decoder {
 	decoder := new(Decoder)
-	decoder.pendingTag = -1
-	decoder.syms = make([]string, 0)
-	decoder.in = bufio.NewReader(r)
-	//	decoder.currentCursor = nil
-	decoder.readHeader()
+	decoder.Reset(r)
+	return decoder
+}
+
+// NewBytesDecoder - create and return a new Decoder that reads the byte slice directly, rather than
+// through a buffer.
+func NewBytesDecoder(b []byte) *Decoder {
+	decoder := new(Decoder)
+	decoder.ResetBytes(b)
 	return decoder
 }
@@ -32,7 +32,8 @@ Marshal ops/ns:
  JSON reflect:              5991    // common with RDL models
 
  TBIN generic:              22878
- TBIN reflect:              11938   // easiest to use, handles unions, etc. Not optimized.
+ TBIN reflect:              3900    // easiest to use, handles unions, etc. 27 allocs/op, down from about
+                                    // 7000 ns/op and 51 allocs/op since reflection is compiled once per type
  TBIN marshallable:         4638    // common with RDL models (using codegen)
  TBIN marshallable inlined: 4544    // smarter codegen could achieve this, or you can hand code this
 
@@ -193,3 +194,54 @@ func BenchmarkTBinUnmarshalReflect(b *testing.B) {
 		dec.DecodeReflect(v)
 	}
 }
+
+//the reflection plans of the types are compiled once, and shared by concurrent encoders and decoders
+func BenchmarkJSONMarshalReflectParallel(b *testing.B) {
+	line := polyline()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			json.Marshal(line)
+		}
+	})
+}
+
+func BenchmarkTBinMarshalReflectParallel(b *testing.B) {
+	line := polyline()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			enc := NewEncoder(nil)
+			enc.EncodeReflect(line)
+		}
+	})
+}
+
+func BenchmarkJSONUnmarshalReflectParallel(b *testing.B) {
+	jd, _ := json.Marshal(polyline())
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			var line2 Polyline
+			json.Unmarshal(jd, &line2)
+		}
+	})
+}
+
+func BenchmarkTBinUnmarshalReflectParallel(b *testing.B) {
+	tdata, _ := Marshal(polyline())
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			var line2 Polyline
+			NewDecoder(bytes.NewReader(tdata)).DecodeReflect(reflect.ValueOf(&line2).Elem())
+		}
+	})
+}
+
+//the hot path of a server: a decoder and the decoded value reused for each message
+func BenchmarkTBinUnmarshalReuse(b *testing.B) {
+	tdata, _ := Marshal(polyline())
+	dec := NewBytesDecoder(tdata)
+	var line2 Polyline
+	for n := 0; n < b.N; n++ {
+		dec.ResetBytes(tdata)
+		dec.Decode(&line2)
+	}
+}
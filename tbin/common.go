@@ -11,10 +11,14 @@ import (
 	"fmt"
 	"io"
 	"reflect"
-	"strings"
 )
 
-const CurrentVersion = 1 // the first versioned version
+const CurrentVersion = 1 // the first versioned version, written by default
+
+// LatestVersion is the latest version of the format. Version 2 encodes timestamps as their seconds and
+// nanoseconds since epoch, "TIMESTAMPTAG varint(seconds) uvarint(nanos)", rather than as a double that
+// cannot represent nanoseconds for current dates. Encoders select the version, decoders read all of them.
+const LatestVersion = 2
 
 const NullTag = 0x00      // "nil" or "null"
 const BoolTag = 0x01      // "uvarint(b? 1 : 0)"
@@ -197,6 +201,64 @@ func (sig *Signature) cacheKey() string {
 	}
 }
 
+// matches compares the signature with one read from a stream. Types read from a stream do not
+// know which struct fields are optional, those are always Any, and their enums carry a leading
+// empty symbol for the unused zero value.
+func (sig *Signature) matches(wire *Signature) bool {
+	if sig == wire {
+		return true
+	}
+	if sig == nil || wire == nil || sig.Tag != wire.Tag {
+		return false
+	}
+	switch sig.Tag {
+	case StructTag:
+		if len(sig.Fields) != len(wire.Fields) {
+			return false
+		}
+		for i, f := range sig.Fields {
+			wf := wire.Fields[i]
+			if f.Name != wf.Name {
+				return false
+			}
+			if f.optional {
+				if wf.Type.Tag != AnyTag {
+					return false
+				}
+			} else if !f.Type.matches(wf.Type) {
+				return false
+			}
+		}
+	case ArrayTag:
+		return sig.Items.matches(wire.Items)
+	case MapTag:
+		return sig.Keys.matches(wire.Keys) && sig.Items.matches(wire.Items)
+	case UnionTag:
+		if len(sig.Variants) != len(wire.Variants) {
+			return false
+		}
+		for i, v := range sig.Variants {
+			if !v.matches(wire.Variants[i]) {
+				return false
+			}
+		}
+	case EnumTag:
+		syms := wire.Symbols
+		if len(syms) > 0 && syms[0] == "" {
+			syms = syms[1:]
+		}
+		if len(sig.Symbols) != len(syms) {
+			return false
+		}
+		for i, s := range sig.Symbols {
+			if s != syms[i] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
 func Field(n string, t *Signature, opt bool) *FieldSignature {
 	return &FieldSignature{Name: n, Type: t, optional: opt}
 }
@@ -302,18 +364,21 @@ func DecodeVarint(in io.Reader) (int, error) {
 }
 
 //
-// TypeSignature returns a Signature for the type of the given data. Reflection is used.
+// TypeSignature returns a Signature for the type of the given data. Reflection is used, once per
+// type: the signature is cached and shared, and must not be modified.
 //
 func TypeSignature(val interface{}) *Signature {
 	t := reflect.TypeOf(val)
 	return buildTypeSignature(t)
 }
 
+// buildTypeSignature returns the signature of the type from its plan, it is computed once per type
+// and must not be modified.
 func buildTypeSignature(t reflect.Type) *Signature {
-	//	return buildTypeSignature2(t, false)
-	//}
+	return planOf(t).sig
+}
 
-	//func buildTypeSignature2(t reflect.Type, optional bool) *Signature {
+func compileTypeSignature(t reflect.Type, p *typePlan) *Signature {
 	typeName := t.String()
 	switch typeName {
 	case "rdl.UUID":
@@ -330,50 +395,23 @@ func buildTypeSignature(t reflect.Type) *Signature {
 	k := t.Kind()
 	switch k {
 	case reflect.Struct:
-		nfields := t.NumField()
-		if nfields > 0 {
-			ft := t.Field(0)
-			ftag := ft.Tag.Get("rdl")
-			if ftag == "union" {
-				var variants []*Signature
-				for i := 1; i < nfields; i++ {
-					f := t.Field(i)
-					variant := buildTypeSignature(f.Type)
-					variants = append(variants, variant)
-				}
-				return Union(variants...)
+		if isUnionType(t) {
+			var variants []*Signature
+			for _, f := range p.fields {
+				variants = append(variants, f.plan.sig)
 			}
+			return Union(variants...)
 		}
 		//a regular struct with fields
 		var fields []*FieldSignature
-		for i := 0; i < nfields; i++ {
-			f := t.Field(i)
-			fn := f.Name
-			ftag := f.Tag.Get("json")
-			if ftag != "" {
-				if ftag == "-" {
-					//should never happen with rdl models.
-					continue
-				}
-				ftags := strings.Split(ftag, ",")
-				n := ftags[0]
-				if n != "" {
-					fn = n
-				}
-			}
-			ftag = f.Tag.Get("rdl")
-			opt := (ftag == "optional")
-			ft := buildTypeSignature(f.Type)
-			if ft != nil {
-				fields = append(fields, Field(fn, ft, opt))
-			}
+		for _, f := range p.fields {
+			fields = append(fields, Field(f.name, f.plan.sig, f.optional))
 		}
 		return Struct(fields...)
 	case reflect.Slice:
-		items := buildTypeSignature(t.Elem())
-		return Array(items)
+		return Array(p.elem.sig)
 	case reflect.Ptr:
-		return buildTypeSignature(t.Elem())
+		return p.elem.sig
 	case reflect.String:
 		return String
 	case reflect.Bool:
@@ -397,9 +435,7 @@ func buildTypeSignature(t reflect.Type) *Signature {
 	case reflect.Float64:
 		return Float64
 	case reflect.Map:
-		keys := buildTypeSignature(t.Key())
-		items := buildTypeSignature(t.Elem())
-		return Map(keys, items)
+		return Map(p.key.sig, p.elem.sig)
 	case reflect.Interface:
 		return Any
 	default:
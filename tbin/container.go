@@ -0,0 +1,471 @@
+// Copyright 2015 Yahoo Inc.
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package tbin
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"sort"
+)
+
+// A container wraps a TBin stream, or any data, in compressed blocks:
+//
+//	"TBNC" version(1 byte) codec(1 byte) uvarint(blockSize) block* end index trailer
+//
+// Each block is "uvarint(rawLen) uvarint(storedLen) byte[storedLen] crc32(raw)", the data compressed
+// with the codec of the container, and the checksum of its uncompressed data in 4 big endian bytes.
+// The end is a block with no data. The index locates the blocks, "uvarint(count) (uvarint(offset delta)
+// uvarint(rawLen))* crc32(index)", and the trailer, "offset(8 bytes big endian) TBNX", locates the index,
+// so that a reader can seek to any block without reading the others. Offsets are relative to the start
+// of the container.
+//
+// Seeking only makes sense for a TBin stream at the start of a block, where a new stream is started: to
+// write a container with random access, Flush the Encoder and the ContainerWriter, then Reset the
+// Encoder, at each point to seek to.
+
+// ContainerVersion - the version of the container format written.
+const ContainerVersion = 1
+
+// the codecs that compress the blocks of a container
+const (
+	CodecNone    = 0
+	CodecDeflate = 1
+)
+
+// DefaultBlockSize - the uncompressed size of the blocks of a container, unless specified.
+const DefaultBlockSize = 64 << 10
+
+// maxBlockSize bounds the size of a block, so that a corrupt container doesn't exhaust the memory.
+const maxBlockSize = 64 << 20
+
+var containerMagic = []byte("TBNC")
+var containerIndexMagic = []byte("TBNX")
+
+const containerTrailerSize = 12
+
+// containerBlock is the entry of a block in the index of a container.
+type containerBlock struct {
+	offset int64 // the offset of the block in the container
+	start  int64 // the offset of its data in the uncompressed stream
+	size   int64 // the size of its data
+}
+
+// ContainerWriter - an io.WriteCloser that writes a container, e.g. the output of an Encoder.
+type ContainerWriter struct {
+	out       io.Writer
+	codec     int
+	blockSize int
+	buf       []byte
+	stored    bytes.Buffer
+	deflater  *flate.Writer
+	offset    int64
+	blocks    []containerBlock
+	closed    bool
+	err       error
+}
+
+// NewContainerWriter - create a ContainerWriter writing to w, with the codec and the uncompressed size
+// of its blocks, DefaultBlockSize if zero. The header is written immediately.
+func NewContainerWriter(w io.Writer, codec int, blockSize int) (*ContainerWriter, error) {
+	if blockSize == 0 {
+		blockSize = DefaultBlockSize
+	}
+	if blockSize < 0 || blockSize > maxBlockSize {
+		return nil, fmt.Errorf("Bad container block size: %d", blockSize)
+	}
+	cw := &ContainerWriter{out: w, codec: codec, blockSize: blockSize}
+	switch codec {
+	case CodecNone:
+	case CodecDeflate:
+		cw.deflater, _ = flate.NewWriter(nil, flate.DefaultCompression)
+	default:
+		return nil, fmt.Errorf("Unsupported container codec: %d", codec)
+	}
+	hdr := append(append([]byte{}, containerMagic...), ContainerVersion, byte(codec))
+	hdr = binary.AppendUvarint(hdr, uint64(blockSize))
+	cw.write(hdr)
+	return cw, cw.err
+}
+
+func (cw *ContainerWriter) write(b []byte) {
+	if cw.err == nil {
+		var n int
+		n, cw.err = cw.out.Write(b)
+		cw.offset += int64(n)
+	}
+}
+
+// Write - write data to the container, it is compressed and written a block at a time.
+func (cw *ContainerWriter) Write(p []byte) (int, error) {
+	if cw.closed && cw.err == nil {
+		cw.err = fmt.Errorf("Write to a closed container")
+	}
+	written := 0
+	for len(p) > 0 && cw.err == nil {
+		n := cw.blockSize - len(cw.buf)
+		if n > len(p) {
+			n = len(p)
+		}
+		cw.buf = append(cw.buf, p[:n]...)
+		p = p[n:]
+		written += n
+		if len(cw.buf) == cw.blockSize {
+			cw.writeBlock()
+		}
+	}
+	return written, cw.err
+}
+
+func (cw *ContainerWriter) writeBlock() {
+	if cw.err != nil || len(cw.buf) == 0 {
+		return
+	}
+	stored := cw.buf
+	if cw.codec == CodecDeflate {
+		cw.stored.Reset()
+		cw.deflater.Reset(&cw.stored)
+		cw.deflater.Write(cw.buf)
+		if cw.err = cw.deflater.Close(); cw.err != nil {
+			return
+		}
+		stored = cw.stored.Bytes()
+	}
+	block := containerBlock{offset: cw.offset, size: int64(len(cw.buf))}
+	if n := len(cw.blocks); n > 0 {
+		block.start = cw.blocks[n-1].start + cw.blocks[n-1].size
+	}
+	cw.blocks = append(cw.blocks, block)
+	hdr := binary.AppendUvarint(nil, uint64(len(cw.buf)))
+	hdr = binary.AppendUvarint(hdr, uint64(len(stored)))
+	cw.write(hdr)
+	cw.write(stored)
+	cw.write(binary.BigEndian.AppendUint32(nil, crc32.ChecksumIEEE(cw.buf)))
+	cw.buf = cw.buf[:0]
+}
+
+// Flush - end the current block and write it, so that the data written so far can be read, and a
+// reader can seek to what is written next. If the underlying writer is buffered, it is flushed too.
+func (cw *ContainerWriter) Flush() error {
+	cw.writeBlock()
+	if f, ok := cw.out.(interface {
+		Flush() error
+	}); ok && cw.err == nil {
+		cw.err = f.Flush()
+	}
+	return cw.err
+}
+
+// Close - write the last block, the end of the blocks and the index. The underlying writer is flushed
+// if it is buffered, but not closed.
+func (cw *ContainerWriter) Close() error {
+	if cw.closed {
+		return cw.err
+	}
+	cw.writeBlock()
+	cw.closed = true
+	cw.write([]byte{0, 0, 0, 0, 0, 0})
+	indexOffset := cw.offset
+	index := binary.AppendUvarint(nil, uint64(len(cw.blocks)))
+	var prev int64
+	for _, b := range cw.blocks {
+		index = binary.AppendUvarint(index, uint64(b.offset-prev))
+		index = binary.AppendUvarint(index, uint64(b.size))
+		prev = b.offset
+	}
+	index = binary.BigEndian.AppendUint32(index, crc32.ChecksumIEEE(index))
+	index = binary.BigEndian.AppendUint64(index, uint64(indexOffset))
+	cw.write(append(index, containerIndexMagic...))
+	return cw.Flush()
+}
+
+// ContainerReader - an io.ReadSeeker that reads the data of a container, e.g. the input of a Decoder.
+// Seeking requires the underlying reader to be an io.ReadSeeker.
+type ContainerReader struct {
+	in        *bufio.Reader
+	src       io.Reader
+	base      int64 // the offset of the container in src, when it is seekable
+	codec     int
+	blockSize int
+	inflater  io.ReadCloser
+	stored    []byte
+	block     []byte // the data of the current block
+	pos       int    // the position in the current block
+	offset    int64  // the offset of the current block in the uncompressed stream
+	blocks    []containerBlock
+	done      bool
+	err       error
+}
+
+// NewContainerReader - create a ContainerReader reading from r, the header is read immediately.
+func NewContainerReader(r io.Reader) (*ContainerReader, error) {
+	cr := &ContainerReader{in: bufio.NewReader(r), src: r}
+	if s, ok := r.(io.Seeker); ok {
+		cr.base, _ = s.Seek(0, io.SeekCurrent)
+	}
+	hdr := make([]byte, len(containerMagic)+2)
+	if _, err := io.ReadFull(cr.in, hdr); err != nil || !bytes.Equal(hdr[:len(containerMagic)], containerMagic) {
+		return nil, fmt.Errorf("Not a TBin container")
+	}
+	if hdr[4] != ContainerVersion {
+		return nil, fmt.Errorf("TBin container version not supported: %d", hdr[4])
+	}
+	cr.codec = int(hdr[5])
+	switch cr.codec {
+	case CodecNone:
+	case CodecDeflate:
+		cr.inflater = flate.NewReader(nil)
+	default:
+		return nil, fmt.Errorf("Unsupported container codec: %d", cr.codec)
+	}
+	size, err := binary.ReadUvarint(cr.in)
+	if err != nil || size == 0 || size > maxBlockSize {
+		return nil, fmt.Errorf("Bad container block size")
+	}
+	cr.blockSize = int(size)
+	return cr, nil
+}
+
+// Read - read the uncompressed data. At the end of the container, it returns io.EOF, or
+// io.ErrUnexpectedEOF if the container is truncated.
+func (cr *ContainerReader) Read(p []byte) (int, error) {
+	for cr.pos == len(cr.block) {
+		if cr.err != nil {
+			return 0, cr.err
+		}
+		if cr.done {
+			return 0, io.EOF
+		}
+		cr.offset += int64(len(cr.block))
+		cr.readBlock()
+	}
+	n := copy(p, cr.block[cr.pos:])
+	cr.pos += n
+	return n, nil
+}
+
+// readBlock reads and checks the next block. A truncated block is an io.ErrUnexpectedEOF.
+func (cr *ContainerReader) readBlock() {
+	cr.block, cr.pos = cr.block[:0], 0
+	rawLen, err := binary.ReadUvarint(cr.in)
+	if err == nil {
+		var storedLen uint64
+		if storedLen, err = binary.ReadUvarint(cr.in); err == nil {
+			err = cr.readBlockData(rawLen, storedLen)
+		}
+	}
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	cr.err = err
+}
+
+func (cr *ContainerReader) readBlockData(rawLen, storedLen uint64) error {
+	if rawLen > uint64(cr.blockSize) || storedLen > uint64(cr.blockSize)+uint64(cr.blockSize)/8+64 {
+		return fmt.Errorf("Bad container block size")
+	}
+	if cap(cr.stored) < int(storedLen) {
+		cr.stored = make([]byte, storedLen)
+	}
+	stored := cr.stored[:storedLen]
+	if _, err := io.ReadFull(cr.in, stored); err != nil {
+		return err
+	}
+	var sum [4]byte
+	if _, err := io.ReadFull(cr.in, sum[:]); err != nil {
+		return err
+	}
+	if rawLen == 0 {
+		cr.done = true
+		return nil
+	}
+	if cap(cr.block) < int(rawLen) {
+		cr.block = make([]byte, rawLen)
+	}
+	cr.block = cr.block[:rawLen]
+	switch cr.codec {
+	case CodecNone:
+		if storedLen != rawLen {
+			return fmt.Errorf("Bad container block size")
+		}
+		copy(cr.block, stored)
+	case CodecDeflate:
+		cr.inflater.(flate.Resetter).Reset(bytes.NewReader(stored), nil)
+		if _, err := io.ReadFull(cr.inflater, cr.block); err != nil {
+			return fmt.Errorf("Bad compressed container block: %v", err)
+		}
+	}
+	if binary.BigEndian.Uint32(sum[:]) != crc32.ChecksumIEEE(cr.block) {
+		return fmt.Errorf("TBin container checksum mismatch")
+	}
+	return nil
+}
+
+// BlockOffsets - return the offsets in the uncompressed data of the blocks of the container, the
+// positions that Seek reaches without decompressing anything before them.
+func (cr *ContainerReader) BlockOffsets() ([]int64, error) {
+	if err := cr.loadIndex(); err != nil {
+		return nil, err
+	}
+	offsets := make([]int64, len(cr.blocks))
+	for i, b := range cr.blocks {
+		offsets[i] = b.start
+	}
+	return offsets, nil
+}
+
+// Seek - set the offset in the uncompressed data of the next Read, only the block that contains it is
+// decompressed. The underlying reader must be an io.ReadSeeker.
+func (cr *ContainerReader) Seek(offset int64, whence int) (int64, error) {
+	if err := cr.loadIndex(); err != nil {
+		return 0, err
+	}
+	var size int64
+	if n := len(cr.blocks); n > 0 {
+		size = cr.blocks[n-1].start + cr.blocks[n-1].size
+	}
+	switch whence {
+	case io.SeekCurrent:
+		offset += cr.offset + int64(cr.pos)
+	case io.SeekEnd:
+		offset += size
+	}
+	if offset < 0 {
+		return 0, fmt.Errorf("Seek to a negative offset: %d", offset)
+	}
+	i := sort.Search(len(cr.blocks), func(i int) bool { return cr.blocks[i].start+cr.blocks[i].size > offset })
+	cr.block, cr.pos, cr.done, cr.err = cr.block[:0], 0, false, nil
+	if i == len(cr.blocks) {
+		//at or past the end, there is nothing to read
+		cr.offset, cr.done = offset, true
+		return offset, nil
+	}
+	block := cr.blocks[i]
+	if _, err := cr.src.(io.Seeker).Seek(cr.base+block.offset, io.SeekStart); err != nil {
+		return 0, err
+	}
+	cr.in.Reset(cr.src)
+	cr.offset = block.start
+	if cr.readBlock(); cr.err != nil {
+		return 0, cr.err
+	}
+	cr.pos = int(offset - block.start)
+	return offset, nil
+}
+
+// loadIndex reads the index of the blocks, at the end of the container. Without an index, e.g. when
+// the container is truncated, the blocks are located by reading their headers.
+func (cr *ContainerReader) loadIndex() error {
+	if cr.blocks != nil {
+		return nil
+	}
+	src, ok := cr.src.(io.ReadSeeker)
+	if !ok {
+		return fmt.Errorf("Cannot seek in a container without an io.ReadSeeker")
+	}
+	pos, err := src.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	end, err := src.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	blocks, err := readContainerIndex(src, cr.base, end)
+	if err != nil {
+		blocks, err = scanContainerBlocks(src, cr.base)
+	}
+	if err != nil {
+		return err
+	}
+	cr.blocks = blocks
+	//back to where the data buffered so far was read from
+	_, err = src.Seek(pos, io.SeekStart)
+	return err
+}
+
+func readContainerIndex(src io.ReadSeeker, base, end int64) ([]containerBlock, error) {
+	trailer := make([]byte, containerTrailerSize)
+	if _, err := src.Seek(end-containerTrailerSize, io.SeekStart); err != nil {
+		return nil, err
+	}
+	if _, err := io.ReadFull(src, trailer); err != nil || !bytes.Equal(trailer[8:], containerIndexMagic) {
+		return nil, fmt.Errorf("TBin container has no index")
+	}
+	start := base + int64(binary.BigEndian.Uint64(trailer))
+	if start < base || start > end-containerTrailerSize-4 || end-start > maxBlockSize {
+		return nil, fmt.Errorf("Bad TBin container index")
+	}
+	index := make([]byte, end-containerTrailerSize-start)
+	if _, err := src.Seek(start, io.SeekStart); err != nil {
+		return nil, err
+	}
+	if _, err := io.ReadFull(src, index); err != nil {
+		return nil, err
+	}
+	body := index[:len(index)-4]
+	if binary.BigEndian.Uint32(index[len(body):]) != crc32.ChecksumIEEE(body) {
+		return nil, fmt.Errorf("TBin container index checksum mismatch")
+	}
+	in := bytes.NewReader(body)
+	count, err := binary.ReadUvarint(in)
+	if err != nil || count > uint64(len(body)) {
+		return nil, fmt.Errorf("Bad TBin container index")
+	}
+	blocks := make([]containerBlock, 0, count)
+	var offset, next int64
+	for i := uint64(0); i < count; i++ {
+		delta, err1 := binary.ReadUvarint(in)
+		size, err2 := binary.ReadUvarint(in)
+		if err1 != nil || err2 != nil || size > maxBlockSize {
+			return nil, fmt.Errorf("Bad TBin container index")
+		}
+		offset += int64(delta)
+		blocks = append(blocks, containerBlock{offset: offset, start: next, size: int64(size)})
+		next += int64(size)
+	}
+	return blocks, nil
+}
+
+// scanContainerBlocks locates the blocks by reading their headers, skipping their data.
+func scanContainerBlocks(src io.ReadSeeker, base int64) ([]containerBlock, error) {
+	if _, err := src.Seek(base+int64(len(containerMagic))+2, io.SeekStart); err != nil {
+		return nil, err
+	}
+	in := bufio.NewReader(src)
+	if _, err := binary.ReadUvarint(in); err != nil {
+		return nil, err
+	}
+	offset, _ := src.Seek(0, io.SeekCurrent)
+	offset -= int64(in.Buffered()) + base
+	blocks := []containerBlock{}
+	var next int64
+	for {
+		rawLen, err1 := binary.ReadUvarint(in)
+		storedLen, err2 := binary.ReadUvarint(in)
+		if err1 != nil || err2 != nil || rawLen == 0 {
+			//the end, or a truncated block
+			return blocks, nil
+		}
+		if rawLen > maxBlockSize || storedLen > 2*maxBlockSize {
+			return nil, fmt.Errorf("Bad container block size")
+		}
+		blocks = append(blocks, containerBlock{offset: offset, start: next, size: int64(rawLen)})
+		next += int64(rawLen)
+		if _, err := in.Discard(int(storedLen) + 4); err != nil {
+			//a truncated block
+			return blocks[:len(blocks)-1], nil
+		}
+		offset += int64(uvarintLen(rawLen)+uvarintLen(storedLen)) + int64(storedLen) + 4
+	}
+}
+
+func uvarintLen(n uint64) int {
+	var b [binary.MaxVarintLen64]byte
+	return binary.PutUvarint(b[:], n)
+}
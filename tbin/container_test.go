@@ -0,0 +1,142 @@
+// Copyright 2015 Yahoo Inc.
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package tbin
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+// writeContainer writes the lines as a container, each one a separate TBin stream starting a block.
+func writeContainer(test *testing.T, codec int, count int) []byte {
+	var out bytes.Buffer
+	cw, err := NewContainerWriter(&out, codec, 256)
+	if err != nil {
+		test.Fatalf("Cannot create container: %v", err)
+	}
+	enc := NewEncoder(cw)
+	for i := 0; i < count; i++ {
+		line := polyline()
+		line.Points[0].X = int32(i)
+		if err := enc.Encode(line); err != nil {
+			test.Fatalf("Cannot encode: %v", err)
+		}
+		enc.Flush()
+		cw.Flush()
+		enc.Reset(cw)
+	}
+	if err := cw.Close(); err != nil {
+		test.Fatalf("Cannot close container: %v", err)
+	}
+	return out.Bytes()
+}
+
+func TestContainer(test *testing.T) {
+	tdata, _ := Marshal(polyline())
+	for _, codec := range []int{CodecNone, CodecDeflate} {
+		cdata := writeContainer(test, codec, 20)
+		cr, err := NewContainerReader(bytes.NewReader(cdata))
+		if err != nil {
+			test.Fatalf("Cannot read container: %v", err)
+		}
+		all, err := io.ReadAll(cr)
+		if err != nil || len(all) != 20*len(tdata) {
+			test.Fatalf("Cannot read the data of the container: %d bytes, %v", len(all), err)
+		}
+
+		//each block starts a stream, that can be read after seeking to it
+		offsets, err := cr.BlockOffsets()
+		if err != nil || len(offsets) != 20 {
+			test.Fatalf("Expected 20 blocks, got %v, %v", offsets, err)
+		}
+		dec := NewDecoder(cr)
+		for _, i := range []int{7, 3, 19} {
+			if _, err := cr.Seek(offsets[i], io.SeekStart); err != nil {
+				test.Fatalf("Cannot seek to block %d: %v", i, err)
+			}
+			dec.Reset(cr)
+			var line Polyline
+			if err := dec.Decode(&line); err != nil || line.Points[0].X != int32(i) {
+				test.Errorf("Unexpected value of block %d: %v, %v", i, line, err)
+			}
+		}
+		if pos, err := cr.Seek(-3, io.SeekEnd); err != nil || pos != int64(len(all)-3) {
+			test.Errorf("Cannot seek from the end: %d, %v", pos, err)
+		}
+		if rest, _ := io.ReadAll(cr); !bytes.Equal(rest, all[len(all)-3:]) {
+			test.Errorf("Unexpected data at the end: %x", rest)
+		}
+
+		//without the index, the blocks are found from their headers
+		cr, _ = NewContainerReader(bytes.NewReader(cdata[:len(cdata)-1]))
+		if offsets2, err := cr.BlockOffsets(); err != nil || len(offsets2) != 20 || offsets2[19] != offsets[19] {
+			test.Errorf("Cannot find the blocks without the index: %v, %v", offsets2, err)
+		}
+	}
+}
+
+func TestContainerStream(test *testing.T) {
+	var sizes []int
+	for _, codec := range []int{CodecNone, CodecDeflate} {
+		//a single stream across many blocks
+		var out bytes.Buffer
+		cw, _ := NewContainerWriter(&out, codec, 1024)
+		enc := NewEncoder(cw)
+		for i := 0; i < 100; i++ {
+			enc.Encode(polyline())
+		}
+		enc.Flush()
+		cw.Close()
+		sizes = append(sizes, out.Len())
+		cr, _ := NewContainerReader(&out)
+		dec := NewDecoder(cr)
+		for i := 0; i < 100; i++ {
+			var line Polyline
+			if err := dec.Decode(&line); err != nil || len(line.Points) != 13 {
+				test.Fatalf("Cannot decode value %d: %v", i, err)
+			}
+		}
+		var data interface{}
+		if err := dec.Decode(&data); err != io.EOF {
+			test.Errorf("Expected io.EOF at the end of the container, got %v", err)
+		}
+	}
+	if sizes[1]*4 >= sizes[0] {
+		test.Errorf("Expected the repetitive data to be compressed: %v", sizes)
+	}
+}
+
+func TestContainerErrors(test *testing.T) {
+	cdata := writeContainer(test, CodecDeflate, 3)
+	cr, _ := NewContainerReader(bytes.NewReader(cdata))
+	offsets, _ := cr.BlockOffsets()
+
+	corrupt := append([]byte{}, cdata...)
+	corrupt[20]++
+	cr, _ = NewContainerReader(bytes.NewReader(corrupt))
+	if _, err := io.ReadAll(cr); err == nil || err == io.ErrUnexpectedEOF {
+		test.Errorf("Expected a corrupt block error, got %v", err)
+	}
+
+	for _, size := range []int{len(cdata) / 2, len(cdata) - 30} {
+		cr, _ = NewContainerReader(bytes.NewReader(cdata[:size]))
+		if _, err := io.ReadAll(cr); err != io.ErrUnexpectedEOF {
+			test.Errorf("Expected io.ErrUnexpectedEOF reading a truncated container, got %v", err)
+		}
+	}
+
+	if _, err := NewContainerReader(bytes.NewReader([]byte("TBIN"))); err == nil {
+		test.Errorf("Expected an error reading something else than a container")
+	}
+	if _, err := NewContainerWriter(io.Discard, 9, 0); err == nil {
+		test.Errorf("Expected an error for an unknown codec")
+	}
+
+	//seeking requires an io.Seeker
+	cr, _ = NewContainerReader(io.MultiReader(bytes.NewReader(cdata)))
+	if _, err := cr.Seek(offsets[1], io.SeekStart); err == nil {
+		test.Errorf("Expected an error seeking without an io.Seeker")
+	}
+}
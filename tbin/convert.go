@@ -0,0 +1,115 @@
+// Copyright 2015 Yahoo Inc.
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package tbin
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+
+	"github.com/ardielle/ardielle-go/rdl"
+)
+
+// ToJSON - convert every value of the TBin stream to a line of JSON. If a schema is given, the values
+// are decoded as the named type with a SchemaDecoder, which omits absent optional fields and wraps
+// union values in their variant name. Otherwise they are decoded generically.
+func ToJSON(in io.Reader, out io.Writer, schema *rdl.Schema, typeName rdl.TypeName) error {
+	var dec *Decoder
+	decode := func() (interface{}, error) {
+		var data interface{}
+		err := dec.Decode(&data)
+		return data, err
+	}
+	if schema != nil {
+		sdec, err := NewSchemaDecoder(in, schema, typeName)
+		if err != nil {
+			return err
+		}
+		dec = sdec.Decoder
+		decode = sdec.Decode
+	} else {
+		dec = NewDecoder(in)
+	}
+	if dec.err != nil {
+		return dec.err
+	}
+	jenc := json.NewEncoder(out)
+	for {
+		if _, err := dec.in.Peek(1); err == io.EOF {
+			return nil
+		}
+		data, err := decode()
+		if err != nil {
+			return err
+		}
+		if err := jenc.Encode(data); err != nil {
+			return err
+		}
+	}
+}
+
+// FromJSON - convert every value of the JSON stream to TBin, in a single TBin session. If a schema is
+// given, the values are encoded as the named type with a SchemaEncoder, with its exact numeric types.
+// Otherwise, and for the values of Any type, they are encoded generically, integers as Int32 or Int64
+// depending on their size, and other numbers as Float64.
+func FromJSON(in io.Reader, out io.Writer, schema *rdl.Schema, typeName rdl.TypeName) error {
+	var enc *Encoder
+	encode := func(data interface{}) error {
+		return enc.Encode(genericNumbers(data))
+	}
+	if schema != nil {
+		senc, err := NewSchemaEncoder(out, schema, typeName)
+		if err != nil {
+			return err
+		}
+		enc = senc.Encoder
+		encode = func(data interface{}) error {
+			return senc.Encode(genericNumbers(data))
+		}
+	} else {
+		enc = NewEncoder(out)
+	}
+	jdec := json.NewDecoder(in)
+	jdec.UseNumber()
+	for {
+		var data interface{}
+		err := jdec.Decode(&data)
+		if err == io.EOF {
+			return enc.Flush()
+		}
+		if err != nil {
+			return err
+		}
+		if err := encode(data); err != nil {
+			return err
+		}
+		if err := enc.Flush(); err != nil {
+			return err
+		}
+	}
+}
+
+// genericNumbers replaces the json.Numbers in the generic data by the smallest fitting TBin number.
+func genericNumbers(data interface{}) interface{} {
+	switch v := data.(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			if n >= math.MinInt32 && n <= math.MaxInt32 {
+				return int32(n)
+			}
+			return n
+		}
+		f, _ := v.Float64() //out of range numbers are infinite
+		return f
+	case map[string]interface{}:
+		for k, item := range v {
+			v[k] = genericNumbers(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = genericNumbers(item)
+		}
+	}
+	return data
+}
@@ -0,0 +1,116 @@
+// Copyright 2015 Yahoo Inc.
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package tbin
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func readTestFile(test *testing.T, name string) []byte {
+	data, err := os.ReadFile("../testdata/" + name)
+	if err != nil {
+		test.Fatalf("Cannot read %s: %v", name, err)
+	}
+	return data
+}
+
+func TestToJSON(test *testing.T) {
+	var expected interface{}
+	json.Unmarshal(readTestFile(test, "test.json"), &expected)
+	for _, name := range []string{"test.tbin", "test_generic.tbin"} {
+		var out bytes.Buffer
+		if err := ToJSON(bytes.NewReader(readTestFile(test, name)), &out, nil, ""); err != nil {
+			test.Fatalf("Cannot convert %s to JSON: %v", name, err)
+		}
+		var data interface{}
+		if err := json.Unmarshal(out.Bytes(), &data); err != nil {
+			test.Fatalf("Cannot parse JSON converted from %s: %v", name, err)
+		}
+		if !reflect.DeepEqual(data, expected) {
+			test.Errorf("JSON converted from %s doesn't match test.json:\n got %s", name, out.String())
+		}
+	}
+	var out bytes.Buffer
+	if err := ToJSON(bytes.NewReader(readTestFile(test, "test.tbin")), &out, loadSchema(test, "polyline.rdl"), "Polyline"); err != nil {
+		test.Errorf("Cannot convert test.tbin to JSON with the schema: %v", err)
+	}
+}
+
+func TestFromJSON(test *testing.T) {
+	expected := readTestFile(test, "test.tbin")
+	var out bytes.Buffer
+	if err := FromJSON(bytes.NewReader(readTestFile(test, "test.json")), &out, loadSchema(test, "polyline.rdl"), "Polyline"); err != nil {
+		test.Fatalf("Cannot convert test.json to TBin: %v", err)
+	}
+	if !bytes.Equal(out.Bytes(), expected) {
+		test.Errorf("Converted TBin doesn't match test.tbin:\n got %x\nwant %x", out.Bytes(), expected)
+	}
+
+	//values of Any type are encoded generically too
+	in := `{"extra":{"list":[1.5,12345678901],"n":42},"shapes":[],"zoom":2}` + "\n"
+	out.Reset()
+	if err := FromJSON(strings.NewReader(in), &out, loadSchema(test, "shapes.rdl"), "Drawing"); err != nil {
+		test.Fatalf("Cannot convert JSON to TBin with the schema: %v", err)
+	}
+	var j bytes.Buffer
+	if err := ToJSON(&out, &j, loadSchema(test, "shapes.rdl"), "Drawing"); err != nil {
+		test.Fatalf("Cannot convert TBin to JSON with the schema: %v", err)
+	}
+	if j.String() != in {
+		test.Errorf("JSON round trip with the schema failed:\n got %s\nwant %s", j.String(), in)
+	}
+
+	//several generic values, in a single session, back to the same JSON
+	in = `{"a":1,"b":[true,"x"]}` + "\n" + `[1.5,12345678901,null]` + "\n"
+	out.Reset()
+	if err := FromJSON(strings.NewReader(in), &out, nil, ""); err != nil {
+		test.Fatalf("Cannot convert generic JSON to TBin: %v", err)
+	}
+	j.Reset()
+	if err := ToJSON(&out, &j, nil, ""); err != nil {
+		test.Fatalf("Cannot convert generic TBin to JSON: %v", err)
+	}
+	if j.String() != in {
+		test.Errorf("Generic JSON round trip failed:\n got %s\nwant %s", j.String(), in)
+	}
+}
+
+func TestDump(test *testing.T) {
+	var out bytes.Buffer
+	if err := Dump(bytes.NewReader(readTestFile(test, "test.tbin")), &out); err != nil {
+		test.Fatalf("Cannot dump test.tbin: %v", err)
+	}
+	for _, expected := range []string{
+		"00000000  18                         version 1",
+		"00000001  40                         typedef 0x40",
+		"00000002  13 02                        DefStruct, 2 fields",
+		`00000004  01 78 04                       field "x" Int32`,
+		"00000019  0d                           points: Array<Struct{x:Int32,y:Int32}>, 13 items",
+		"0000003e  d2 e5 b7 b2 02                   x: int32 321321321",
+	} {
+		if !strings.Contains(out.String(), expected+"\n") {
+			test.Errorf("Expected the dump to contain %q, got:\n%s", expected, out.String())
+		}
+	}
+	for _, name := range []string{"test_generic.tbin", "rdl_schema.tbin"} {
+		out.Reset()
+		if err := Dump(bytes.NewReader(readTestFile(test, name)), &out); err != nil {
+			test.Errorf("Cannot dump %s: %v", name, err)
+		}
+	}
+
+	//a truncated stream is dumped up to the error
+	out.Reset()
+	tdata := readTestFile(test, "test.tbin")
+	if err := Dump(bytes.NewReader(tdata[:len(tdata)-1]), &out); err == nil {
+		test.Errorf("Expected an error dumping truncated data")
+	} else if !strings.Contains(out.String(), "[11]") {
+		test.Errorf("Expected the truncated dump to reach the last points, got:\n%s", out.String())
+	}
+}
@@ -10,9 +10,11 @@ package tbin
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"math"
 	"reflect"
 	"strings"
+	"time"
 
 	"github.com/ardielle/ardielle-go/rdl"
 )
@@ -21,18 +23,23 @@ func (d *Decoder) readHeader() error {
 	tag := int(d.ParseUnsigned())
 	if (tag & VersionTagMask) == VersionTag {
 		d.dataVersion = (tag & VersionDataMask) + 1
-		if d.dataVersion != CurrentVersion {
+		if d.dataVersion > LatestVersion {
 			d.err = fmt.Errorf("TBin version not supported: %d", d.dataVersion)
 			return d.err
 		}
-		d.types = make([]*Signature, 0)
+		d.types = d.types[:0]
 		return nil
 	}
 	d.err = fmt.Errorf("not a valid tbin file")
 	return d.err
 }
 
-func (d *Decoder) Decode(data interface{}) error {
+func (d *Decoder) Decode(data interface{}) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = d.reflectMismatch(r, data)
+		}
+	}()
 	rv := reflect.ValueOf(data)
 	if !rv.IsNil() && rv.Kind() == reflect.Ptr {
 		v := rv.Elem()
@@ -42,7 +49,11 @@ func (d *Decoder) Decode(data interface{}) error {
 			if err != nil {
 				return err
 			}
-			v.Set(reflect.ValueOf(d))
+			if d == nil {
+				v.Set(reflect.Zero(v.Type()))
+			} else {
+				v.Set(reflect.ValueOf(d))
+			}
 			return nil
 		}
 		if v.Kind() != reflect.Ptr && v.Type().Name() != "" && v.CanAddr() {
@@ -61,6 +72,23 @@ func (d *Decoder) Decode(data interface{}) error {
 	return fmt.Errorf("Cannot decode into this: %v", data)
 }
 
+// reflectMismatch turns the panic of the reflect package when the stream doesn't match the Go type being
+// decoded into an error. Other panics are bugs, and are not recovered.
+func (d *Decoder) reflectMismatch(r interface{}, data interface{}) error {
+	if _, ok := r.(*reflect.ValueError); !ok {
+		if s, ok := r.(string); !ok || !strings.HasPrefix(s, "reflect") {
+			panic(r)
+		}
+	}
+	d.err = fmt.Errorf("Cannot decode into %T: %v", data, r)
+	return d.err
+}
+
+// Version - return the version of the format of the stream.
+func (d *Decoder) Version() int {
+	return d.dataVersion
+}
+
 func (d *Decoder) Error() error {
 	return d.err
 }
@@ -70,6 +98,10 @@ func (d *Decoder) CurrentCount() int {
 }
 
 func (d *Decoder) parseType() *Signature {
+	if !d.enter() {
+		return nil
+	}
+	defer d.leave()
 	tag := d.ParseUnsigned()
 	if tag >= FirstUserTag {
 		idx := int(tag - FirstUserTag)
@@ -80,41 +112,46 @@ func (d *Decoder) parseType() *Signature {
 		return d.types[idx]
 	}
 	switch tag {
-	case ArrayTag:
-		//all arrays get typedef'd now, this code may be dead
+	case ArrayTag, DefArrayTag:
+		//all arrays get typedef'd now, the ArrayTag case may be dead
 		itemsType := d.parseType()
+		if itemsType == nil {
+			return nil
+		}
 		return Array(itemsType)
-	case MapTag:
-		//all maps get typedef'd now, this code may be dead
-		keysType := d.parseType()
-		itemsType := d.parseType()
-		return Map(keysType, itemsType)
-	case DefArrayTag:
-		itemType := d.parseType()
-		return Array(itemType)
-	case DefMapTag:
+	case MapTag, DefMapTag:
+		//all maps get typedef'd now, the MapTag case may be dead
 		keysType := d.parseType()
 		itemsType := d.parseType()
+		if keysType == nil || itemsType == nil {
+			return nil
+		}
 		return Map(keysType, itemsType)
 	case DefStructTag:
-		size := int(d.ParseUnsigned())
-		fields := make([]*FieldSignature, size)
+		size := d.ReadSize()
+		fields := make([]*FieldSignature, 0, capHint(size))
 		for i := 0; i < size; i++ {
 			fname, _ := d.ParseString()
 			ftype := d.parseType()
-			fields[i] = Field(fname, ftype, (ftype == Any))
+			if ftype == nil {
+				return nil
+			}
+			fields = append(fields, Field(fname, ftype, (ftype == Any)))
 		}
 		return Struct(fields...)
 	case DefUnionTag:
-		size := int(d.ParseUnsigned())
+		size := d.ReadSize()
 		var variants []*Signature
 		for i := 0; i < size; i++ {
 			variantType := d.parseType()
+			if variantType == nil {
+				return nil
+			}
 			variants = append(variants, variantType)
 		}
 		return Union(variants...)
 	case DefEnumTag:
-		size := int(d.ParseUnsigned())
+		size := d.ReadSize()
 		syms := []string{""}
 		for i := 0; i < size; i++ {
 			sym, err := d.ParseString()
@@ -141,6 +178,10 @@ func (d *Decoder) parseType() *Signature {
 		return Float64
 	case StringTag:
 		return String
+	case SymbolTag:
+		return Symbol
+	case BytesTag:
+		return Bytes
 	case UUIDTag:
 		return UUID
 	case TimestampTag:
@@ -156,6 +197,27 @@ func (d *Decoder) parseType() *Signature {
 	}
 }
 
+// defineType reads the type definition that follows the first use of a user tag.
+func (d *Decoder) defineType(tag uint) bool {
+	if int(tag-FirstUserTag) != len(d.types) {
+		d.err = fmt.Errorf("ref to a undefined tag: 0x%02x", tag)
+		return false
+	}
+	if max := d.options.MaxUserTags; max > 0 && len(d.types) >= max {
+		d.err = &LimitError{Limit: "MaxUserTags", Max: int64(max)}
+		return false
+	}
+	ttype := d.parseType()
+	if ttype == nil {
+		if d.err == nil {
+			d.err = fmt.Errorf("First use of a user tag must be followed by a typedef.")
+		}
+		return false
+	}
+	d.types = append(d.types, ttype)
+	return true
+}
+
 func (d *Decoder) decode() (interface{}, error) {
 again:
 	tag := d.ParseUnsigned()
@@ -168,12 +230,9 @@ again:
 			ttype := d.types[idx]
 			return d.decodeType(ttype)
 		}
-		ttype := d.parseType()
-		if ttype == nil {
-			d.err = fmt.Errorf("First use of a user tag must be followed by a typedef.")
+		if !d.defineType(tag) {
 			return nil, d.err
 		}
-		d.types = append(d.types, ttype)
 		goto again
 	} else {
 		if (tag & TinyStrTagMask) == TinyStrTag {
@@ -232,9 +291,13 @@ again:
 }
 
 func (d *Decoder) DecodeStruct() (map[string]interface{}, error) {
-	nfields := int(d.ParseUnsigned())
+	if !d.enter() {
+		return nil, d.err
+	}
+	defer d.leave()
+	nfields := d.ReadSize()
 	result := make(map[string]interface{})
-	for i := 0; i < nfields; i++ {
+	for i := 0; i < nfields && d.err == nil; i++ {
 		name, _ := d.ParseSymbol()
 		val, _ := d.decode()
 		result[name] = val
@@ -243,9 +306,13 @@ func (d *Decoder) DecodeStruct() (map[string]interface{}, error) {
 }
 
 func (d *Decoder) DecodeArray() ([]interface{}, error) {
-	count := int(d.ParseUnsigned())
+	if !d.enter() {
+		return nil, d.err
+	}
+	defer d.leave()
+	count := d.ReadSize()
 	var result []interface{}
-	for i := 0; i < count; i++ {
+	for i := 0; i < count && d.err == nil; i++ {
 		val, _ := d.decode()
 		result = append(result, val)
 	}
@@ -253,9 +320,13 @@ func (d *Decoder) DecodeArray() ([]interface{}, error) {
 }
 
 func (d *Decoder) DecodeMap() (map[string]interface{}, error) {
-	count := int(d.ParseUnsigned())
+	if !d.enter() {
+		return nil, d.err
+	}
+	defer d.leave()
+	count := d.ReadSize()
 	result := make(map[string]interface{})
-	for i := 0; i < count; i++ {
+	for i := 0; i < count && d.err == nil; i++ {
 		key, _ := d.decode()
 		val, _ := d.decode()
 		skey, ok := key.(string)
@@ -269,6 +340,13 @@ func (d *Decoder) DecodeMap() (map[string]interface{}, error) {
 }
 
 func (d *Decoder) decodeType(tt *Signature) (interface{}, error) {
+	switch tt.Tag {
+	case StructTag, MapTag, ArrayTag, UnionTag:
+		if !d.enter() {
+			return nil, d.err
+		}
+		defer d.leave()
+	}
 	switch tt.Tag {
 	case StructTag:
 		result := make(map[string]interface{}, 0)
@@ -281,7 +359,7 @@ func (d *Decoder) decodeType(tt *Signature) (interface{}, error) {
 		}
 		return result, nil
 	case MapTag:
-		mlen := int(d.ParseUnsigned())
+		mlen := d.ReadSize()
 		if d.err != nil {
 			return nil, d.err
 		}
@@ -305,20 +383,20 @@ func (d *Decoder) decodeType(tt *Signature) (interface{}, error) {
 		}
 		return result, nil
 	case ArrayTag:
-		alen := int(d.ParseUnsigned())
-		if d.err != nil {
+		alen := d.ReadSize()
+		if !d.checkEmptyElements(tt.Items, alen) {
 			return nil, d.err
 		}
 		items := tt.Items
 		switch items.Tag {
 		default:
-			result := make([]interface{}, alen)
+			result := make([]interface{}, 0, capHint(alen))
 			for i := 0; i < alen; i++ {
 				tmp, err := d.decodeType(items)
 				if err != nil {
 					return nil, err
 				}
-				result[i] = tmp
+				result = append(result, tmp)
 			}
 			return result, nil
 		}
@@ -326,10 +404,22 @@ func (d *Decoder) decodeType(tt *Signature) (interface{}, error) {
 		return d.decode()
 	case EnumTag:
 		nsym := d.ParseInt()
-		return tt.Symbols[nsym], d.err
+		if d.err == nil && (nsym < 0 || nsym >= len(tt.Symbols)) {
+			d.err = fmt.Errorf("Enum value out of range for %v: %d", tt, nsym)
+		}
+		if d.err != nil {
+			return nil, d.err
+		}
+		return tt.Symbols[nsym], nil
 	case UnionTag:
-		nvariant := d.ParseUnsigned() - 1
-		return d.decodeType(tt.Variants[nvariant])
+		nvariant := d.ReadUnsigned()
+		if d.err == nil && (nvariant < 1 || nvariant > len(tt.Variants)) {
+			d.err = fmt.Errorf("Variant id out of range for %v: %d", tt, nvariant)
+		}
+		if d.err != nil {
+			return nil, d.err
+		}
+		return d.decodeType(tt.Variants[nvariant-1])
 	case Int8Tag:
 		n := int8(d.ParseInt())
 		return n, d.err
@@ -348,6 +438,10 @@ func (d *Decoder) decodeType(tt *Signature) (interface{}, error) {
 		return d.ParseFloat64()
 	case StringTag:
 		return d.ParseString()
+	case SymbolTag:
+		return d.ParseSymbol()
+	case BytesTag:
+		return d.ParseBytes()
 	case TimestampTag:
 		return d.ParseTimestamp()
 	case UUIDTag:
@@ -417,9 +511,11 @@ func (d *Decoder) ParseSymbol() (string, error) {
 					d.syms = append(d.syms, name)
 					return name, nil
 				}
-			} else {
+			} else if int(id) < len(d.syms) {
 				name := d.syms[id]
 				return name, nil
+			} else {
+				d.err = fmt.Errorf("ref to an undefined symbol: %d", id)
 			}
 		}
 	}
@@ -560,24 +656,73 @@ func (d *Decoder) readBytes(buf []byte) error {
 }
 
 func (d *Decoder) ParseBytes() ([]byte, error) {
-	if d.err != nil {
-		return nil, d.err
+	buf, err := d.parseData()
+	if err != nil {
+		return nil, err
 	}
-	n := d.ParseUnsigned()
-	buf := make([]byte, n)
-	err := d.readBytes(buf)
-	return buf, err
+	if _, ok := d.in.(*sliceReader); ok && d.zeroCopy {
+		return buf[:len(buf):len(buf)], nil
+	}
+	return append(make([]byte, 0, len(buf)), buf...), nil
 }
 
 func (d *Decoder) ParseString() (string, error) {
-	buf, err := d.ParseBytes()
+	buf, err := d.parseData()
 	if err != nil {
 		return "", err
 	}
 	return string(buf), nil
 }
 
+// parseData reads the length and data of a bytes or string value, in a buffer only valid until the next
+// read: the input itself when decoding a byte slice, otherwise the scratch buffer of the decoder.
+func (d *Decoder) parseData() ([]byte, error) {
+	if d.err != nil {
+		return nil, d.err
+	}
+	n := d.ParseUnsigned()
+	if !d.checkLength(n) {
+		return nil, d.err
+	}
+	if src, ok := d.in.(*sliceReader); ok {
+		buf, err := src.next(n)
+		if err != nil {
+			d.err = err
+		}
+		return buf, err
+	}
+	if n > 1<<16 {
+		//don't trust the length to allocate the buffer ahead
+		buf, err := io.ReadAll(io.LimitReader(d.in, int64(n)))
+		if err == nil && len(buf) < int(n) {
+			err = io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			d.err = err
+			return nil, err
+		}
+		return buf, nil
+	}
+	if uint(cap(d.scratch)) < n {
+		d.scratch = make([]byte, n)
+	}
+	buf := d.scratch[:n]
+	err := d.readBytes(buf)
+	return buf, err
+}
+
 func (d *Decoder) ParseTimestamp() (rdl.Timestamp, error) {
+	if d.dataVersion >= 2 {
+		secs := d.ParseInt64()
+		nanos := d.ParseUnsigned()
+		if d.err == nil && nanos >= 1e9 {
+			d.err = fmt.Errorf("Bad timestamp nanoseconds: %d", nanos)
+		}
+		if d.err != nil {
+			return rdl.Timestamp{}, d.err
+		}
+		return rdl.Timestamp{Time: time.Unix(secs, int64(nanos)).UTC()}, nil
+	}
 	secs, err := d.ParseFloat64()
 	if err != nil {
 		var ts rdl.Timestamp
@@ -603,8 +748,218 @@ func (d *Decoder) ReadInt() int {
 func (d *Decoder) ReadUnsigned() int {
 	return int(d.ParseUnsigned())
 }
+
+// ReadSize - reads the size of an array, map or struct, checking it against the MaxCollectionSize limit
 func (d *Decoder) ReadSize() int {
-	return d.ReadUnsigned()
+	n := d.ReadUnsigned()
+	if max := d.options.MaxCollectionSize; max > 0 && n > max && d.err == nil {
+		d.err = &LimitError{Limit: "MaxCollectionSize", Max: int64(max)}
+		return 0
+	}
+	return n
+}
+
+// ReadBool - reads a packed bool value
+func (d *Decoder) ReadBool() bool {
+	return d.ParseUnsigned() != 0
+}
+
+// ReadInt8 - reads a packed signed 8 bit integer
+func (d *Decoder) ReadInt8() int8 {
+	return int8(d.ParseInt())
+}
+
+// ReadInt16 - reads a packed signed 16 bit integer
+func (d *Decoder) ReadInt16() int16 {
+	return int16(d.ParseInt())
+}
+
+// ReadInt64 - reads a packed signed 64 bit integer
+func (d *Decoder) ReadInt64() int64 {
+	return d.ParseInt64()
+}
+
+// ReadFloat32 - reads a packed 32 bit float
+func (d *Decoder) ReadFloat32() float32 {
+	n, _ := d.ParseFloat32()
+	return n
+}
+
+// ReadFloat64 - reads a packed 64 bit float
+func (d *Decoder) ReadFloat64() float64 {
+	n, _ := d.ParseFloat64()
+	return n
+}
+
+// ReadString - reads a packed string
+func (d *Decoder) ReadString() string {
+	s, _ := d.ParseString()
+	return s
+}
+
+// ReadSymbol - reads a packed symbol, returning its name
+func (d *Decoder) ReadSymbol() string {
+	s, _ := d.ParseSymbol()
+	return s
+}
+
+// ReadTimestamp - reads a packed timestamp
+func (d *Decoder) ReadTimestamp() rdl.Timestamp {
+	ts, _ := d.ParseTimestamp()
+	return ts
+}
+
+// ReadUUID - reads a packed UUID
+func (d *Decoder) ReadUUID() rdl.UUID {
+	u, _ := d.ParseUUID()
+	return u
+}
+
+// ReadNull - if the next value in the stream is a null, consume it and return true. Otherwise
+// nothing is consumed. Optional struct fields are tagged values, this tests for absent ones.
+func (d *Decoder) ReadNull() bool {
+	if d.err != nil {
+		return false
+	}
+	b, err := d.in.Peek(1)
+	if err != nil {
+		d.err = err
+		return false
+	}
+	if b[0] != NullTag {
+		return false
+	}
+	d.in.ReadByte()
+	return true
+}
+
+// readOptionalTag reads the tag of an optional (tagged) value, returning false if it is null.
+func (d *Decoder) readOptionalTag(expected int) bool {
+	tag := int(d.ParseUnsigned())
+	if d.err != nil || tag == NullTag {
+		return false
+	}
+	if tag != expected {
+		d.err = fmt.Errorf("Expected %s value, found tag 0x%02x", TagName(expected), tag)
+		return false
+	}
+	return true
+}
+
+// ReadOptionalBool - reads a tagged bool value, or nil if it is null
+func (d *Decoder) ReadOptionalBool() *bool {
+	if d.readOptionalTag(BoolTag) {
+		b := d.ReadBool()
+		return &b
+	}
+	return nil
+}
+
+// ReadOptionalInt8 - reads a tagged 8 bit integer, or nil if it is null
+func (d *Decoder) ReadOptionalInt8() *int8 {
+	if d.readOptionalTag(Int8Tag) {
+		n := d.ReadInt8()
+		return &n
+	}
+	return nil
+}
+
+// ReadOptionalInt16 - reads a tagged 16 bit integer, or nil if it is null
+func (d *Decoder) ReadOptionalInt16() *int16 {
+	if d.readOptionalTag(Int16Tag) {
+		n := d.ReadInt16()
+		return &n
+	}
+	return nil
+}
+
+// ReadOptionalInt32 - reads a tagged 32 bit integer, or nil if it is null
+func (d *Decoder) ReadOptionalInt32() *int32 {
+	if d.readOptionalTag(Int32Tag) {
+		n := d.ReadInt32()
+		return &n
+	}
+	return nil
+}
+
+// ReadOptionalInt64 - reads a tagged 64 bit integer, or nil if it is null
+func (d *Decoder) ReadOptionalInt64() *int64 {
+	if d.readOptionalTag(Int64Tag) {
+		n := d.ReadInt64()
+		return &n
+	}
+	return nil
+}
+
+// ReadOptionalFloat32 - reads a tagged 32 bit float, or nil if it is null
+func (d *Decoder) ReadOptionalFloat32() *float32 {
+	if d.readOptionalTag(Float32Tag) {
+		n := d.ReadFloat32()
+		return &n
+	}
+	return nil
+}
+
+// ReadOptionalFloat64 - reads a tagged 64 bit float, or nil if it is null
+func (d *Decoder) ReadOptionalFloat64() *float64 {
+	if d.readOptionalTag(Float64Tag) {
+		n := d.ReadFloat64()
+		return &n
+	}
+	return nil
+}
+
+// ReadOptionalString - reads a tagged string, or the empty string if it is null
+func (d *Decoder) ReadOptionalString() string {
+	tag := int(d.ParseUnsigned())
+	if d.err != nil || tag == NullTag {
+		return ""
+	}
+	if (tag & TinyStrTagMask) == TinyStrTag {
+		buf := make([]byte, tag&TinyStrDataMask)
+		d.readBytes(buf)
+		return string(buf)
+	}
+	if tag != StringTag {
+		d.err = fmt.Errorf("Expected String value, found tag 0x%02x", tag)
+		return ""
+	}
+	return d.ReadString()
+}
+
+// ReadOptionalSymbol - reads a tagged symbol, or the empty symbol if it is null
+func (d *Decoder) ReadOptionalSymbol() rdl.Symbol {
+	if d.readOptionalTag(SymbolTag) {
+		return rdl.Symbol(d.ReadSymbol())
+	}
+	return ""
+}
+
+// ReadOptionalTimestamp - reads a tagged timestamp, or nil if it is null
+func (d *Decoder) ReadOptionalTimestamp() *rdl.Timestamp {
+	if d.readOptionalTag(TimestampTag) {
+		ts := d.ReadTimestamp()
+		return &ts
+	}
+	return nil
+}
+
+// ReadOptionalUUID - reads a tagged UUID, or nil if it is null
+func (d *Decoder) ReadOptionalUUID() *rdl.UUID {
+	if d.readOptionalTag(UUIDTag) {
+		u := d.ReadUUID()
+		return &u
+	}
+	return nil
+}
+
+// ReadOptionalBytes - reads a tagged byte array, or nil if it is null
+func (d *Decoder) ReadOptionalBytes() []byte {
+	if d.readOptionalTag(BytesTag) {
+		b, _ := d.ParseBytes()
+		return b
+	}
+	return nil
 }
 
 func (d *Decoder) ReadType() (*Signature, error) {
@@ -621,17 +976,34 @@ again:
 	if idx < len(d.types) {
 		ttype = d.types[idx]
 	} else {
-		ttype = d.parseType()
-		if ttype == nil {
-			d.err = fmt.Errorf("First use of a user tag must be followed by a typedef.")
+		if !d.defineType(tag) {
 			return nil, d.err
 		}
-		d.types = append(d.types, ttype)
 		goto again
 	}
 	return ttype, nil
 }
 
+// ExpectType reads a type from the stream, and reports whether it matches the expected signature. If it
+// does, the caller decodes the packed value that follows. Otherwise the value is decoded into data, which
+// must be a pointer, using reflection. This is what generated UnmarshalTBin methods use, so that data
+// written with a different version of a type can still be read.
+func (d *Decoder) ExpectType(sig *Signature, data interface{}) (bool, error) {
+	wire, err := d.ReadType()
+	if err != nil {
+		return false, err
+	}
+	if sig.matches(wire) {
+		return true, nil
+	}
+	v := reflect.ValueOf(data)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		d.err = fmt.Errorf("Cannot decode %v into this: %v", wire, data)
+		return false, d.err
+	}
+	return false, d.decodeTypeReflect(wire, v.Elem())
+}
+
 func (d *Decoder) buildTypeSignature(t *Signature, out *bytes.Buffer) error {
 	var err error
 	tag := t.Tag
@@ -682,12 +1054,9 @@ again:
 			ttype := d.types[idx]
 			return d.decodeTypeReflect(ttype, v)
 		}
-		ttype := d.parseType()
-		if ttype == nil {
-			d.err = fmt.Errorf("First use of a user tag must be followed by a typedef.")
+		if !d.defineType(uint(tag)) {
 			return d.err
 		}
-		d.types = append(d.types, ttype)
 		goto again
 	} else {
 		if (tag & TinyStrTagMask) == TinyStrTag {
@@ -707,7 +1076,10 @@ again:
 		}
 		switch tag {
 		case NullTag:
-			//already zeroed
+			//the zero value, the target may be reused rather than new
+			if v.CanSet() {
+				v.SetZero()
+			}
 			return nil
 		case BoolTag:
 			b := d.ParseBool()
@@ -778,6 +1150,14 @@ again:
 			}
 			return err
 		case BytesTag:
+			if v.Kind() == reflect.Slice && !v.IsNil() && !d.zeroCopy {
+				//reuse the existing slice
+				b, err := d.parseData()
+				if err == nil {
+					v.SetBytes(append(v.Bytes()[:0], b...))
+				}
+				return err
+			}
 			b, err := d.ParseBytes()
 			if err == nil {
 				if v.Kind() == reflect.Interface {
@@ -851,28 +1231,21 @@ func (d *Decoder) reflectField(v reflect.Value, fname string) (reflect.Value, er
 		v = v.Elem()
 		t = t.Elem()
 	}
-	size := t.NumField()
-	for i := 0; i < size; i++ {
-		field := t.Field(i)
-		fn := field.Name
-		ftag := field.Tag.Get("json")
-		if ftag != "" {
-			//if ftag == "-" { continue } //should never happen with rdl models. If it does, our count was wrong!
-			ftags := strings.Split(ftag, ",")
-			n := ftags[0]
-			if n != "" {
-				fn = n
-			}
-		}
-		if strings.EqualFold(fname, fn) {
-			return v.Field(i), nil
-		}
+	if i, ok := planOf(t).lookupField(fname); ok {
+		return v.Field(i), nil
 	}
 	var junk reflect.Value
 	return junk, fmt.Errorf("No such field: %v", fname)
 }
 
 func (d *Decoder) decodeTypeReflect(tt *Signature, v reflect.Value) error {
+	switch tt.Tag {
+	case StructTag, MapTag, ArrayTag, UnionTag:
+		if !d.enter() {
+			return d.err
+		}
+		defer d.leave()
+	}
 	switch tt.Tag {
 	case StructTag:
 		if !v.CanSet() {
@@ -906,7 +1279,7 @@ func (d *Decoder) decodeTypeReflect(tt *Signature, v reflect.Value) error {
 		vv.Set(v)
 		return nil
 	case MapTag:
-		mlen := int(d.ParseUnsigned())
+		mlen := d.ReadSize()
 		if d.err != nil {
 			return d.err
 		}
@@ -919,17 +1292,17 @@ func (d *Decoder) decodeTypeReflect(tt *Signature, v reflect.Value) error {
 			d.err = fmt.Errorf("Cannot set array element")
 			return d.err
 		}
-		v.Set(reflect.MakeMap(reflect.MapOf(keyType, itemType)))
+		resetMap(v)
+		//the entries are decoded into the same key and item, SetMapIndex copies them
+		keyV := reflect.New(keyType).Elem() //we don't want a pointer
+		itemV := reflect.New(itemType).Elem()
 		for i := 0; i < mlen; i++ {
-			keyV := reflect.New(keyType).Elem()    //we don't want a pointer
+			keyV.SetZero()
 			err := d.decodeTypeReflect(keys, keyV) //? need a reflect.Value, not a reflect.Type
 			if err != nil {
 				return err
 			}
-			itemV := reflect.New(itemType)
-			if itemV.Kind() != reflect.Struct {
-				itemV = itemV.Elem()
-			}
+			itemV.SetZero()
 			err = d.decodeTypeReflect(items, itemV)
 			if err != nil {
 				return err
@@ -938,21 +1311,20 @@ func (d *Decoder) decodeTypeReflect(tt *Signature, v reflect.Value) error {
 		}
 		return nil
 	case ArrayTag:
-		alen := int(d.ParseUnsigned())
-		if d.err != nil {
+		alen := d.ReadSize()
+		if !d.checkEmptyElements(tt.Items, alen) {
 			return d.err
 		}
 		items := tt.Items
 		switch items.Tag {
 		default:
-			t := v.Type()
-			itemType := t.Elem()
 			if !v.CanSet() {
 				d.err = fmt.Errorf("Cannot set array element")
 				return d.err
 			}
-			v.Set(reflect.MakeSlice(reflect.SliceOf(itemType), alen, alen))
+			resetSlice(v, alen)
 			for i := 0; i < alen; i++ {
+				growSlice(v, i)
 				item := v.Index(i)
 				itemType := item.Type()
 				if item.Kind() == reflect.Ptr {
@@ -972,6 +1344,7 @@ func (d *Decoder) decodeTypeReflect(tt *Signature, v reflect.Value) error {
 					return d.err
 				}
 			}
+			v.SetLen(alen)
 			return nil
 		}
 	case BoolTag:
@@ -1066,6 +1439,9 @@ func (d *Decoder) decodeTypeReflect(tt *Signature, v reflect.Value) error {
 			}
 			return d.DecodeReflect(v)
 		}
+		if d.err == nil && v.CanSet() {
+			v.SetZero()
+		}
 		return d.err
 	}
 	d.err = fmt.Errorf("decode unhandled type (0x%02x): %v", tt.Tag, tt)
@@ -1073,7 +1449,11 @@ func (d *Decoder) decodeTypeReflect(tt *Signature, v reflect.Value) error {
 }
 
 func (d *Decoder) DecodeStructReflect(v reflect.Value) error {
-	count := int(d.ParseUnsigned())
+	if !d.enter() {
+		return d.err
+	}
+	defer d.leave()
+	count := d.ReadSize()
 	t := v.Type()
 	if v.Kind() == reflect.Ptr {
 		v = v.Elem()
@@ -1102,15 +1482,18 @@ func (d *Decoder) DecodeStructReflect(v reflect.Value) error {
 }
 
 func (d *Decoder) DecodeArrayReflect(v reflect.Value) error {
-	count := int(d.ParseUnsigned())
-	t := v.Type()
-	itemType := t.Elem()
+	if !d.enter() {
+		return d.err
+	}
+	defer d.leave()
+	count := d.ReadSize()
 	if !v.CanSet() {
 		d.err = fmt.Errorf("Cannot set array element")
 		return d.err
 	}
-	v.Set(reflect.MakeSlice(reflect.SliceOf(itemType), count, count))
+	resetSlice(v, count)
 	for i := 0; i < count; i++ {
+		growSlice(v, i)
 		item := v.Index(i)
 		itemType := item.Type()
 		if item.Kind() == reflect.Ptr {
@@ -1130,11 +1513,16 @@ func (d *Decoder) DecodeArrayReflect(v reflect.Value) error {
 			return d.err
 		}
 	}
+	v.SetLen(count)
 	return d.err
 }
 
 func (d *Decoder) DecodeMapReflect(v reflect.Value) error {
-	count := int(d.ParseUnsigned())
+	if !d.enter() {
+		return d.err
+	}
+	defer d.leave()
+	count := d.ReadSize()
 	t := v.Type()
 	keyType := t.Key()
 	itemType := t.Elem()
@@ -1142,7 +1530,7 @@ func (d *Decoder) DecodeMapReflect(v reflect.Value) error {
 		d.err = fmt.Errorf("Cannot set mapentry")
 		return d.err
 	}
-	v.Set(reflect.MakeMap(reflect.MapOf(keyType, itemType)))
+	resetMap(v)
 	for i := 0; i < count; i++ {
 		key := reflect.New(keyType)
 		d.DecodeReflect(key.Elem())
@@ -1178,3 +1566,36 @@ func (d *Decoder) DecodeMapReflect(v reflect.Value) error {
 	}
 	return d.err
 }
+
+// resetSlice prepares the slice being decoded: an existing slice is reused, its elements are decoded
+// into in place. A new slice doesn't get the untrusted size in the stream allocated ahead.
+func resetSlice(v reflect.Value, n int) {
+	if v.IsNil() {
+		v.Set(reflect.MakeSlice(v.Type(), 0, capHint(n)))
+	}
+}
+
+// growSlice makes element i of the slice being decoded available, appending a zero element if needed.
+// Reused pointer elements are cleared, so that the values they point to are not modified.
+func growSlice(v reflect.Value, i int) {
+	switch {
+	case i < v.Len():
+		if item := v.Index(i); item.Kind() == reflect.Ptr {
+			item.SetZero()
+		}
+	case i < v.Cap():
+		v.SetLen(i + 1)
+		v.Index(i).SetZero()
+	default:
+		v.Set(reflect.Append(v, reflect.Zero(v.Type().Elem())))
+	}
+}
+
+// resetMap prepares the map being decoded: an existing map is cleared and reused.
+func resetMap(v reflect.Value) {
+	if v.IsNil() {
+		v.Set(reflect.MakeMap(v.Type()))
+	} else {
+		v.Clear()
+	}
+}
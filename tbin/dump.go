@@ -0,0 +1,397 @@
+// Copyright 2015 Yahoo Inc.
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package tbin
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/ardielle/ardielle-go/rdl"
+)
+
+// dumpHexWidth is the number of bytes shown on each line of a dump, longer items are elided.
+const dumpHexWidth = 8
+
+// dumpStringWidth is the maximum length of the strings shown in a dump, longer ones are elided.
+const dumpStringWidth = 64
+
+// Dump - write a structured dump of the TBin stream, like xxd but aware of the format: each line has the
+// byte offset of an item, its first bytes, and a description indented by nesting depth. Type definitions,
+// symbol table entries, and the tags of all values are shown, and packed values are annotated with
+// their field names. If the stream is malformed, everything up to the problem is dumped before the error
+// is returned.
+func Dump(in io.Reader, out io.Writer) error {
+	data, err := io.ReadAll(in)
+	if err != nil {
+		return err
+	}
+	d := &dumper{data: data, out: out}
+	d.header()
+	for d.err == nil && d.pos < len(d.data) {
+		d.value(0, "")
+	}
+	return d.err
+}
+
+type dumper struct {
+	data    []byte
+	pos     int
+	out     io.Writer
+	types   []*Signature
+	syms    []string
+	version int
+	err     error
+}
+
+func (d *dumper) fail(format string, args ...interface{}) {
+	if d.err == nil {
+		d.err = fmt.Errorf("offset 0x%x: %s", d.pos, fmt.Sprintf(format, args...))
+	}
+}
+
+// line writes the line for the item that started at the given offset and ends at the current one.
+func (d *dumper) line(start int, depth int, label string, format string, args ...interface{}) {
+	if d.err != nil {
+		return
+	}
+	raw := d.data[start:d.pos]
+	var hex []string
+	for i, b := range raw {
+		if i == dumpHexWidth {
+			hex = append(hex, "..")
+			break
+		}
+		hex = append(hex, fmt.Sprintf("%02x", b))
+	}
+	if label != "" {
+		label += ": "
+	}
+	_, err := fmt.Fprintf(d.out, "%08x  %-26s %s%s%s\n", start, strings.Join(hex, " "), strings.Repeat("  ", depth), label, fmt.Sprintf(format, args...))
+	if err != nil {
+		d.err = err
+	}
+}
+
+func (d *dumper) bytes(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n < 0 || d.pos+n > len(d.data) {
+		d.fail("unexpected end of data, %d bytes needed", n)
+		return nil
+	}
+	b := d.data[d.pos : d.pos+n]
+	d.pos += n
+	return b
+}
+
+func (d *dumper) unsigned() uint64 {
+	var n uint64
+	for shift := uint(0); shift < 64; shift += 7 {
+		b := d.bytes(1)
+		if b == nil {
+			return 0
+		}
+		n |= uint64(b[0]&127) << shift
+		if b[0]&0x80 == 0 {
+			return n
+		}
+	}
+	d.fail("bad int encoding")
+	return 0
+}
+
+func (d *dumper) signed() int64 {
+	n := d.unsigned()
+	return int64(n>>1) ^ -int64(n&1)
+}
+
+func (d *dumper) size() int {
+	n := d.unsigned()
+	if n > uint64(len(d.data)) {
+		d.fail("count %d larger than the data", n)
+		return 0
+	}
+	return int(n)
+}
+
+func (d *dumper) string() string {
+	return string(d.bytes(d.size()))
+}
+
+func quoted(s string) string {
+	if len(s) > dumpStringWidth {
+		return fmt.Sprintf("%q...", s[:dumpStringWidth])
+	}
+	return fmt.Sprintf("%q", s)
+}
+
+func (d *dumper) header() {
+	tag := int(d.unsigned())
+	if d.err == nil && (tag&VersionTagMask) != VersionTag {
+		d.fail("not a valid tbin stream")
+	}
+	d.version = (tag & VersionDataMask) + 1
+	d.line(0, 0, "", "version %d", d.version)
+}
+
+func (d *dumper) symbol(start int, depth int, label string) {
+	id := d.size()
+	if id == len(d.syms) {
+		name := d.string()
+		d.syms = append(d.syms, name)
+		d.line(start, depth, label, "symbol #%d = %s (new)", id, quoted(name))
+	} else if id < len(d.syms) {
+		d.line(start, depth, label, "symbol #%d = %s", id, quoted(d.syms[id]))
+	} else {
+		d.fail("undefined symbol #%d", id)
+	}
+}
+
+// userType resolves a user tag, dumping the type definitions that precede the first use of a tag. It
+// returns the type and the offset of the tag that refers to it.
+func (d *dumper) userType(start int, tag int, depth int) (*Signature, int) {
+	for d.err == nil {
+		idx := tag - FirstUserTag
+		if idx < len(d.types) {
+			return d.types[idx], start
+		}
+		if idx > len(d.types) {
+			d.fail("reference to undefined tag 0x%02x", tag)
+			break
+		}
+		d.line(start, depth, "", "typedef 0x%02x", tag)
+		sig := d.typedef(depth + 1)
+		if sig == nil {
+			break
+		}
+		d.types = append(d.types, sig)
+		start = d.pos
+		tag = int(d.unsigned())
+		if tag < FirstUserTag {
+			d.fail("a type definition must be followed by a user tag, found 0x%02x", tag)
+		}
+	}
+	return nil, start
+}
+
+// typeRef reads the tag referring to a type inside a type definition.
+func (d *dumper) typeRef() *Signature {
+	tag := int(d.unsigned())
+	if d.err != nil {
+		return nil
+	}
+	if tag >= FirstUserTag {
+		idx := tag - FirstUserTag
+		if idx >= len(d.types) {
+			d.fail("reference to undefined tag 0x%02x", tag)
+			return nil
+		}
+		return d.types[idx]
+	}
+	switch tag {
+	case BoolTag, Int8Tag, Int16Tag, Int32Tag, Int64Tag, Float32Tag, Float64Tag, BytesTag, StringTag, TimestampTag, SymbolTag, UUIDTag, AnyTag, StructTag:
+		return &Signature{Tag: tag}
+	case ArrayTag:
+		return Array(d.typeRef())
+	case MapTag:
+		keys := d.typeRef()
+		return Map(keys, d.typeRef())
+	}
+	d.fail("unexpected type tag 0x%02x", tag)
+	return nil
+}
+
+func (d *dumper) typedef(depth int) *Signature {
+	start := d.pos
+	def := int(d.unsigned())
+	switch def {
+	case DefStructTag:
+		n := d.size()
+		d.line(start, depth, "", "DefStruct, %d fields", n)
+		fields := make([]*FieldSignature, 0, n)
+		for i := 0; i < n && d.err == nil; i++ {
+			fstart := d.pos
+			name := d.string()
+			ftype := d.typeRef()
+			d.line(fstart, depth+1, "", "field %s %v", quoted(name), ftype)
+			fields = append(fields, Field(name, ftype, ftype != nil && ftype.Tag == AnyTag))
+		}
+		return Struct(fields...)
+	case DefArrayTag:
+		items := d.typeRef()
+		d.line(start, depth, "", "DefArray of %v", items)
+		return Array(items)
+	case DefMapTag:
+		keys := d.typeRef()
+		items := d.typeRef()
+		d.line(start, depth, "", "DefMap of %v to %v", keys, items)
+		return Map(keys, items)
+	case DefUnionTag:
+		n := d.size()
+		var variants []*Signature
+		for i := 0; i < n && d.err == nil; i++ {
+			variants = append(variants, d.typeRef())
+		}
+		sig := Union(variants...)
+		d.line(start, depth, "", "DefUnion %v", sig)
+		return sig
+	case DefEnumTag:
+		n := d.size()
+		syms := []string{""}
+		for i := 0; i < n && d.err == nil; i++ {
+			syms = append(syms, d.string())
+		}
+		d.line(start, depth, "", "DefEnum %s", strings.Join(syms[1:], ","))
+		return Enum(syms...)
+	}
+	d.fail("unexpected type definition 0x%02x", def)
+	return nil
+}
+
+// value dumps a tagged value.
+func (d *dumper) value(depth int, label string) {
+	start := d.pos
+	tag := int(d.unsigned())
+	if d.err != nil {
+		return
+	}
+	if tag >= FirstUserTag {
+		sig, start := d.userType(start, tag, depth)
+		if sig != nil {
+			d.packed(sig, start, depth, label, fmt.Sprintf("0x%02x ", d.data[start]))
+		}
+		return
+	}
+	if tag&TinyStrTagMask == TinyStrTag {
+		d.bytes(tag & TinyStrDataMask)
+		d.line(start, depth, label, "tinystr %s", quoted(string(d.data[start+1:d.pos])))
+		return
+	}
+	if tag&VersionTagMask == VersionTag {
+		d.line(start, depth, label, "version %d", (tag&VersionDataMask)+1)
+		return
+	}
+	switch tag {
+	case NullTag:
+		d.line(start, depth, label, "null")
+	case StructTag:
+		n := d.size()
+		d.line(start, depth, label, "struct, %d fields", n)
+		for i := 0; i < n && d.err == nil; i++ {
+			d.symbol(d.pos, depth+1, "name")
+			d.value(depth+1, "value")
+		}
+	case ArrayTag:
+		n := d.size()
+		d.line(start, depth, label, "array, %d items", n)
+		for i := 0; i < n && d.err == nil; i++ {
+			d.value(depth+1, fmt.Sprintf("[%d]", i))
+		}
+	case MapTag:
+		n := d.size()
+		d.line(start, depth, label, "map, %d entries", n)
+		for i := 0; i < n && d.err == nil; i++ {
+			d.value(depth+1, "key")
+			d.value(depth+1, "value")
+		}
+	case AnyTag, DefArrayTag, DefMapTag, DefStructTag, DefUnionTag, DefEnumTag, UnionTag, EnumTag:
+		d.fail("unexpected tag %s (0x%02x)", TagName(tag), tag)
+	default:
+		d.packed(&Signature{Tag: tag}, start, depth, label, "")
+	}
+}
+
+// packed dumps a value without a tag, as described by the signature. If the value is tagged, start is
+// the offset of its tag, and the prefix names the user tag, if any.
+func (d *dumper) packed(sig *Signature, start int, depth int, label string, prefix string) {
+	switch sig.Tag {
+	case AnyTag:
+		d.value(depth, label)
+	case BoolTag:
+		d.line(start, depth, label, "%sbool %v", prefix, d.unsigned() != 0)
+	case Int8Tag, Int16Tag, Int32Tag:
+		d.line(start, depth, label, "%s%s %d", prefix, strings.ToLower(TagName(sig.Tag)), d.signed())
+	case Int64Tag:
+		d.line(start, depth, label, "%sint64 %d", prefix, d.signed())
+	case Float32Tag:
+		b := d.bytes(4)
+		if b != nil {
+			d.line(start, depth, label, "%sfloat32 %v", prefix, math.Float32frombits(binary.BigEndian.Uint32(b)))
+		}
+	case Float64Tag:
+		b := d.bytes(8)
+		if b != nil {
+			d.line(start, depth, label, "%sfloat64 %v", prefix, math.Float64frombits(binary.BigEndian.Uint64(b)))
+		}
+	case TimestampTag:
+		if d.version >= 2 {
+			secs := d.signed()
+			nanos := d.unsigned()
+			d.line(start, depth, label, "%stimestamp %v", prefix, rdl.Timestamp{Time: time.Unix(secs, int64(nanos)).UTC()})
+			break
+		}
+		b := d.bytes(8)
+		if b != nil {
+			ts := rdl.TimestampFromEpoch(math.Float64frombits(binary.BigEndian.Uint64(b)))
+			d.line(start, depth, label, "%stimestamp %v", prefix, ts)
+		}
+	case UUIDTag:
+		b := d.bytes(16)
+		if b != nil {
+			d.line(start, depth, label, "%suuid %v", prefix, rdl.UUID(b))
+		}
+	case StringTag:
+		s := d.string()
+		d.line(start, depth, label, "%sstring %s", prefix, quoted(s))
+	case BytesTag:
+		b := d.bytes(d.size())
+		d.line(start, depth, label, "%sbytes, %d bytes", prefix, len(b))
+	case SymbolTag:
+		d.symbol(start, depth, label)
+	case StructTag:
+		if sig.Fields == nil {
+			d.fail("generic struct in a type definition")
+			return
+		}
+		d.line(start, depth, label, "%s%v", prefix, sig)
+		for _, f := range sig.Fields {
+			d.packed(f.Type, d.pos, depth+1, f.Name, "")
+		}
+	case ArrayTag:
+		n := d.size()
+		d.line(start, depth, label, "%s%v, %d items", prefix, sig, n)
+		for i := 0; i < n && d.err == nil; i++ {
+			d.packed(sig.Items, d.pos, depth+1, fmt.Sprintf("[%d]", i), "")
+		}
+	case MapTag:
+		n := d.size()
+		d.line(start, depth, label, "%s%v, %d entries", prefix, sig, n)
+		for i := 0; i < n && d.err == nil; i++ {
+			d.packed(sig.Keys, d.pos, depth+1, "key", "")
+			d.packed(sig.Items, d.pos, depth+1, "value", "")
+		}
+	case EnumTag:
+		n := int(d.signed())
+		if n < 1 || n >= len(sig.Symbols) {
+			d.fail("bad enum value %d", n)
+			return
+		}
+		d.line(start, depth, label, "%senum %d = %s", prefix, n, sig.Symbols[n])
+	case UnionTag:
+		n := d.size()
+		if n < 1 || n > len(sig.Variants) {
+			d.fail("bad union variant %d", n)
+			return
+		}
+		d.line(start, depth, label, "%sunion variant %d", prefix, n)
+		d.packed(sig.Variants[n-1], d.pos, depth+1, "", "")
+	default:
+		d.fail("unexpected type %v", sig)
+	}
+}
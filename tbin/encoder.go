@@ -58,8 +58,9 @@ func (enc *Encoder) encode(data interface{}, useMarshallable bool) error {
 //
 func (enc *Encoder) Flush() error {
 	if enc.err == nil && enc.out != nil {
-		enc.out.Write(enc.buf.Bytes())
+		_, enc.err = enc.out.Write(enc.buf.Bytes())
 		enc.buf.Reset()
+		enc.flushed = true
 	}
 	return enc.err
 }
@@ -293,140 +294,24 @@ func (enc *Encoder) EncodeStruct(val rdl.Struct, useMarshallable bool) error {
 }
 
 func (enc *Encoder) encodeReflectedStruct(v reflect.Value, useMarshallable bool) error {
-	signature := TypeSignature(v.Interface())
-	enc.WriteType(signature) //usually just writes the tag, but may write typedefs as a side-effect
-	return enc.encodeValue(v, useMarshallable)
+	plan := planOf(v.Type())
+	enc.WriteType(plan.sig) //usually just writes the tag, but may write typedefs as a side-effect
+	return enc.encodePlanned(plan, v, useMarshallable)
 }
 
 func (enc *Encoder) encodeReflectedEnum(v reflect.Value, useMarshallable bool) error {
-	signature := TypeSignature(v.Interface())
-	enc.WriteType(signature) //usually just writes the tag, but may write typedefs as a side-effect
-	return enc.encodeValue(v, useMarshallable)
+	plan := planOf(v.Type())
+	enc.WriteType(plan.sig) //usually just writes the tag, but may write typedefs as a side-effect
+	return enc.encodePlanned(plan, v, useMarshallable)
 }
 
+// encodeValue encodes the value packed, with the plan compiled for its type.
 func (enc *Encoder) encodeValue(v reflect.Value, useMarshallable bool) error {
-	k := v.Kind()
-	if k == reflect.Invalid {
+	if !v.IsValid() {
 		enc.err = fmt.Errorf("cannot encode value %v", v)
 		return enc.err
 	}
-	if useMarshallable {
-		if v.CanInterface() {
-			data := v.Interface()
-			m, ok := data.(TBinMarshallable)
-			if ok {
-				return m.MarshalTBin(enc) //burden on the app, but can be faster
-			}
-		}
-	}
-	t := v.Type()
-	typeName := t.String()
-	switch typeName {
-	case "rdl.UUID":
-		return enc.WriteUUID(v.Interface().(rdl.UUID))
-	case "rdl.Timestamp":
-		return enc.WriteTimestamp(v.Interface().(rdl.Timestamp))
-	case "rdl.Symbol":
-		return enc.WriteSymbol(string(v.Interface().(rdl.Symbol)))
-	case "rdl.Struct":
-		//return enc.EncodeStruct(d, useMarshallable)
-		panic("rdl.Struct -> fix me")
-	}
-	var err error
-	switch k {
-	case reflect.Struct:
-		nfields := t.NumField()
-		//first check if it is a union (`rdl:union`)
-		if nfields > 0 {
-			ft := t.Field(0)
-			ftag := ft.Tag.Get("rdl")
-			if ftag == "union" {
-				nvar := int(v.Field(0).Int())
-				enc.WriteUnsigned(nvar)
-				//note: an uninitialized union has its tag set to zero. Emit nothing after the tag in that case.
-				if nvar > 0 {
-					enc.tagged = true //ensure the next WriteType doesn't actually do anything
-					return enc.encodeValue(v.Field(nvar), useMarshallable)
-				}
-				enc.err = fmt.Errorf("Cannot marshal uninitialized union type %s in %v", t.Name(), v)
-				return enc.err
-			}
-		}
-		for i := 0; i < nfields; i++ {
-			f := v.Field(i)
-			ft := t.Field(i)
-			ftag := ft.Tag.Get("rdl")
-			if ftag == "optional" {
-				ddd := f.Interface()
-				if IsZero(f) {
-					err = enc.EncodeNull()
-				} else {
-					err = enc.encodeData(ddd, useMarshallable)
-				}
-			} else {
-				fk := f.Kind()
-				if fk == reflect.Ptr {
-					if f.IsNil() {
-						enc.err = fmt.Errorf("Cannot marshal null pointer for required field %v in %v", ft.Name, f)
-						return enc.err
-					}
-					err = enc.encodeValue(f.Elem(), useMarshallable)
-				} else {
-					err = enc.encodeValue(f, useMarshallable)
-				}
-			}
-		}
-	case reflect.Map:
-		n := v.Len()
-		enc.WriteUnsigned(n)
-		for _, k := range v.MapKeys() {
-			enc.encodeValue(k, useMarshallable)
-			enc.encodeValue(v.MapIndex(k), useMarshallable)
-		}
-		err = enc.err
-	case reflect.Slice:
-		n := v.Len()
-		enc.WriteUnsigned(n)
-		for i := 0; i < n; i++ {
-			vv := v.Index(i)
-			if vv.Kind() == reflect.Ptr {
-				vv = vv.Elem()
-			}
-			enc.encodeValue(vv, useMarshallable)
-		}
-		err = enc.err
-	case reflect.Ptr:
-		//without context (of a field def, for example), we must assume "optional" for any pointer, because it can be nil
-		if v.IsNil() {
-			err = enc.EncodeNull()
-		} else {
-			err = enc.encode(v.Elem().Interface(), useMarshallable)
-		}
-	case reflect.Int8:
-		return enc.WriteInt8(int8(v.Int()))
-	case reflect.Int16:
-		return enc.WriteInt16(int16(v.Int()))
-	case reflect.Int:
-		return enc.WriteInt32(int32(v.Int()))
-	case reflect.Int32:
-		return enc.WriteInt32(int32(v.Int()))
-	case reflect.Int64:
-		return enc.WriteInt64(v.Int())
-	case reflect.Float32:
-		return enc.WriteFloat32(float32(v.Float()))
-	case reflect.Float64:
-		return enc.WriteFloat64(v.Float())
-	case reflect.String:
-		return enc.WriteString(v.String())
-	case reflect.Bool:
-		return enc.WriteBool(v.Bool())
-	case reflect.Interface: //any type
-		return enc.encodeData(v.Interface(), useMarshallable)
-	default:
-		err = fmt.Errorf("Cannot determine type signature for reflect kind: '%v'", k)
-		enc.err = err
-	}
-	return err
+	return enc.encodePlanned(planOf(v.Type()), v, useMarshallable)
 }
 
 func pointerMeansOptional(v reflect.Type) bool {
@@ -456,9 +341,9 @@ func (enc *Encoder) encodeReflectedArray(v reflect.Value, useMarshallable bool)
 		return enc.EncodeBytes(b)
 	}
 	//since we know the array type statically, use a typedef for it to make it more compact
-	signature := buildTypeSignature(t)
-	enc.WriteType(signature)
-	return enc.encodeValue(v, useMarshallable)
+	plan := planOf(t)
+	enc.WriteType(plan.sig)
+	return enc.encodePlanned(plan, v, useMarshallable)
 }
 
 func ValidMapKey(key interface{}) bool {
@@ -507,9 +392,9 @@ func (enc *Encoder) encodeReflectedMap(v reflect.Value, useMarshallable bool) er
 		}
 		return enc.err
 	}
-	signature := buildTypeSignature(t)
-	enc.WriteType(signature)
-	return enc.encodeValue(v, useMarshallable)
+	plan := planOf(t)
+	enc.WriteType(plan.sig)
+	return enc.encodePlanned(plan, v, useMarshallable)
 }
 
 //------------------------- low level encoding
@@ -835,8 +720,13 @@ func (enc *Encoder) WriteUUID(u rdl.UUID) error {
 	return enc.err
 }
 
+// WriteTimestamp - writes a packed timestamp, with nanosecond precision from version 2
 func (enc *Encoder) WriteTimestamp(val rdl.Timestamp) error {
-	return enc.WriteFloat64(val.SecondsSinceEpoch())
+	if enc.version < 2 {
+		return enc.WriteFloat64(val.SecondsSinceEpoch())
+	}
+	enc.WriteInt64(val.Unix())
+	return enc.WriteUnsigned(val.Nanosecond())
 }
 
 func (enc *Encoder) WriteBytes(b []byte) error {
@@ -885,7 +775,30 @@ func (enc *Encoder) WriteSymbol(name string) error {
 
 func (enc *Encoder) writeHeader() error {
 	if enc.err == nil {
-		enc.writeUnsigned(CurVersionTag)
+		enc.writeUnsigned(VersionTag + enc.version - 1)
+	}
+	return enc.err
+}
+
+// SetVersion - select the version of the format to write, CurrentVersion by default. This must be
+// called before anything is encoded.
+func (enc *Encoder) SetVersion(version int) error {
+	if enc.err != nil {
+		return enc.err
+	}
+	if version < 1 || version > LatestVersion {
+		enc.err = fmt.Errorf("TBin version not supported: %d", version)
+	} else if enc.buf.Len() != 1 || enc.flushed {
+		enc.err = fmt.Errorf("TBin version must be set before encoding")
+	} else {
+		enc.version = version
+		enc.buf.Reset()
+		enc.writeHeader()
 	}
 	return enc.err
 }
+
+// Version - return the version of the format written.
+func (enc *Encoder) Version() int {
+	return enc.version
+}
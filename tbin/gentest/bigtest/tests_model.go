@@ -0,0 +1,1047 @@
+//
+// Code generated by go generate DO NOT EDIT.
+//
+
+package bigtest
+
+import (
+	"encoding/json"
+	"fmt"
+	rdl "github.com/ardielle/ardielle-go/rdl"
+	"github.com/ardielle/ardielle-go/tbin"
+)
+
+var _ = rdl.Version
+var _ = json.Marshal
+var _ = fmt.Printf
+
+// Options - options comment
+type Options int
+
+// Options constants
+const (
+	_ Options = iota
+	ONE
+	TWO
+	THREE
+)
+
+var namesOptions = []string{
+	ONE:   "ONE",
+	TWO:   "TWO",
+	THREE: "THREE",
+}
+
+// NewOptions - return a string representation of the enum
+func NewOptions(init ...interface{}) Options {
+	if len(init) == 1 {
+		switch v := init[0].(type) {
+		case Options:
+			return v
+		case int:
+			return Options(v)
+		case int32:
+			return Options(v)
+		case string:
+			for i, s := range namesOptions {
+				if s == v {
+					return Options(i)
+				}
+			}
+		default:
+			panic("Bad init value for Options enum")
+		}
+	}
+	return Options(0) //default to the first enum value
+}
+
+// String - return a string representation of the enum
+func (e Options) String() string {
+	return namesOptions[e]
+}
+
+// SymbolSet - return an array of all valid string representations (symbols) of the enum
+func (e Options) SymbolSet() []string {
+	return namesOptions
+}
+
+// MarshalJSON is defined for proper JSON encoding of a Options
+func (e Options) MarshalJSON() ([]byte, error) {
+	return json.Marshal(e.String())
+}
+
+// UnmarshalJSON is defined for proper JSON decoding of a Options
+func (e *Options) UnmarshalJSON(b []byte) error {
+	var j string
+	err := json.Unmarshal(b, &j)
+	if err == nil {
+		s := string(j)
+		for v, s2 := range namesOptions {
+			if s == s2 {
+				*e = Options(v)
+				return nil
+			}
+		}
+		err = fmt.Errorf("Bad enum symbol for type Options: %s", s)
+	}
+	return err
+}
+
+var tbinOptionsSignature = tbin.Enum("ONE", "TWO", "THREE")
+
+// MarshalTBin is defined for TBin encoding of a Options without reflection
+func (e *Options) MarshalTBin(enc *tbin.Encoder) error {
+	enc.WriteType(tbinOptionsSignature)
+	return enc.WriteInt32(int32(*e))
+}
+
+// UnmarshalTBin is defined for TBin decoding of a Options without reflection
+func (e *Options) UnmarshalTBin(dec *tbin.Decoder) error {
+	if ok, err := dec.ExpectType(tbinOptionsSignature, e); !ok {
+		return err
+	}
+	*e = Options(dec.ReadInt32())
+	return dec.Error()
+}
+
+// StringTest -
+type StringTest struct {
+	Name   string   `json:"name"`
+	Parent string   `json:"parent"`
+	Names  []string `json:"names,omitempty" rdl:"optional"`
+	Enc    string   `json:"enc,omitempty" rdl:"optional"`
+}
+
+// NewStringTest - creates an initialized StringTest instance, returns a pointer to it
+func NewStringTest(init ...*StringTest) *StringTest {
+	var o *StringTest
+	if len(init) == 1 {
+		o = init[0]
+	} else {
+		o = new(StringTest)
+	}
+	return o
+}
+
+type rawStringTest StringTest
+
+// UnmarshalJSON is defined for proper JSON decoding of a StringTest
+func (self *StringTest) UnmarshalJSON(b []byte) error {
+	var m rawStringTest
+	err := json.Unmarshal(b, &m)
+	if err == nil {
+		o := StringTest(m)
+		*self = o
+		err = self.Validate()
+	}
+	return err
+}
+
+// Validate - checks for missing required fields, etc
+func (self *StringTest) Validate() error {
+	if self.Name == "" {
+		return fmt.Errorf("StringTest.name is missing but is a required field")
+	} else {
+		val := rdl.Validate(TestsSchema(), "SimpleName", self.Name)
+		if !val.Valid {
+			return fmt.Errorf("StringTest.name does not contain a valid SimpleName (%v)", val.Error)
+		}
+	}
+	if self.Parent == "" {
+		return fmt.Errorf("StringTest.parent is missing but is a required field")
+	} else {
+		val := rdl.Validate(TestsSchema(), "CompoundName", self.Parent)
+		if !val.Valid {
+			return fmt.Errorf("StringTest.parent does not contain a valid CompoundName (%v)", val.Error)
+		}
+	}
+	if self.Enc != "" {
+		val := rdl.Validate(TestsSchema(), "YEncoded", self.Enc)
+		if !val.Valid {
+			return fmt.Errorf("StringTest.enc does not contain a valid YEncoded (%v)", val.Error)
+		}
+	}
+	return nil
+}
+
+var tbinStringTestNamesSignature = tbin.Array(tbin.String)
+
+var tbinStringTestSignature = tbin.Struct(
+	tbin.Field("name", tbin.String, false),
+	tbin.Field("parent", tbin.String, false),
+	tbin.Field("names", tbin.Array(tbin.String), true),
+	tbin.Field("enc", tbin.String, true),
+)
+
+// MarshalTBin is defined for TBin encoding of a StringTest without reflection
+func (self *StringTest) MarshalTBin(enc *tbin.Encoder) error {
+	enc.WriteType(tbinStringTestSignature)
+	return self.writeTBin(enc)
+}
+
+func (self *StringTest) writeTBin(enc *tbin.Encoder) error {
+	if self == nil {
+		return fmt.Errorf("Cannot marshal null StringTest")
+	}
+	enc.WriteString(self.Name)
+	enc.WriteString(self.Parent)
+	if self.Names == nil {
+		enc.EncodeNull()
+	} else {
+		enc.WriteType(tbinStringTestNamesSignature)
+		enc.WriteSize(len(self.Names))
+		for _, item1 := range self.Names {
+			enc.WriteString(item1)
+		}
+	}
+	if self.Enc == "" {
+		enc.EncodeNull()
+	} else {
+		enc.EncodeString(self.Enc)
+	}
+	return enc.Error()
+}
+
+// UnmarshalTBin is defined for TBin decoding of a StringTest without reflection
+func (self *StringTest) UnmarshalTBin(dec *tbin.Decoder) error {
+	if ok, err := dec.ExpectType(tbinStringTestSignature, self); !ok {
+		return err
+	}
+	return self.readTBin(dec)
+}
+
+func (self *StringTest) readTBin(dec *tbin.Decoder) error {
+	self.Name = dec.ReadString()
+	self.Parent = dec.ReadString()
+	if !dec.ReadNull() {
+		ok, err := dec.ExpectType(tbinStringTestNamesSignature, &self.Names)
+		if err != nil {
+			return err
+		}
+		if ok {
+			if self.Names == nil {
+				self.Names = make([]string, 0)
+			} else {
+				self.Names = self.Names[:0]
+			}
+			for i1, n1 := 0, dec.ReadSize(); i1 < n1 && dec.Error() == nil; i1++ {
+				var v1 string
+				v1 = dec.ReadString()
+				self.Names = append(self.Names, v1)
+			}
+		}
+	}
+	self.Enc = dec.ReadOptionalString()
+	return dec.Error()
+}
+
+// MapTest -
+type MapTest struct {
+	Locations map[string]int32 `json:"locations"`
+}
+
+// NewMapTest - creates an initialized MapTest instance, returns a pointer to it
+func NewMapTest(init ...*MapTest) *MapTest {
+	var o *MapTest
+	if len(init) == 1 {
+		o = init[0]
+	} else {
+		o = new(MapTest)
+	}
+	return o.Init()
+}
+
+// Init - sets up the instance according to its default field values, if any
+func (self *MapTest) Init() *MapTest {
+	if self.Locations == nil {
+		self.Locations = make(map[string]int32)
+	}
+	return self
+}
+
+type rawMapTest MapTest
+
+// UnmarshalJSON is defined for proper JSON decoding of a MapTest
+func (self *MapTest) UnmarshalJSON(b []byte) error {
+	var m rawMapTest
+	err := json.Unmarshal(b, &m)
+	if err == nil {
+		o := MapTest(m)
+		*self = *((&o).Init())
+		err = self.Validate()
+	}
+	return err
+}
+
+// Validate - checks for missing required fields, etc
+func (self *MapTest) Validate() error {
+	if self.Locations == nil {
+		return fmt.Errorf("MapTest: Missing required field: locations")
+	}
+	return nil
+}
+
+var tbinMapTestSignature = tbin.Struct(
+	tbin.Field("locations", tbin.Map(tbin.String, tbin.Int32), false),
+)
+
+// MarshalTBin is defined for TBin encoding of a MapTest without reflection
+func (self *MapTest) MarshalTBin(enc *tbin.Encoder) error {
+	enc.WriteType(tbinMapTestSignature)
+	return self.writeTBin(enc)
+}
+
+func (self *MapTest) writeTBin(enc *tbin.Encoder) error {
+	if self == nil {
+		return fmt.Errorf("Cannot marshal null MapTest")
+	}
+	enc.WriteSize(len(self.Locations))
+	for k1, v1 := range self.Locations {
+		enc.WriteString(k1)
+		enc.WriteInt32(v1)
+	}
+	return enc.Error()
+}
+
+// UnmarshalTBin is defined for TBin decoding of a MapTest without reflection
+func (self *MapTest) UnmarshalTBin(dec *tbin.Decoder) error {
+	if ok, err := dec.ExpectType(tbinMapTestSignature, self); !ok {
+		return err
+	}
+	return self.readTBin(dec)
+}
+
+func (self *MapTest) readTBin(dec *tbin.Decoder) error {
+	if self.Locations == nil {
+		self.Locations = make(map[string]int32)
+	} else {
+		clear(self.Locations)
+	}
+	for i1, n1 := 0, dec.ReadSize(); i1 < n1 && dec.Error() == nil; i1++ {
+		var k1 string
+		k1 = dec.ReadString()
+		var v1 int32
+		v1 = dec.ReadInt32()
+		self.Locations[k1] = v1
+	}
+	return dec.Error()
+}
+
+// ArrayOfInt -
+type ArrayOfInt []int32
+
+var tbinArrayOfIntSignature = tbin.Array(tbin.Int32)
+
+// MarshalTBin is defined for TBin encoding of a ArrayOfInt without reflection
+func (self *ArrayOfInt) MarshalTBin(enc *tbin.Encoder) error {
+	enc.WriteType(tbinArrayOfIntSignature)
+	enc.WriteSize(len(*self))
+	for _, item1 := range *self {
+		enc.WriteInt32(item1)
+	}
+	return enc.Error()
+}
+
+// UnmarshalTBin is defined for TBin decoding of a ArrayOfInt without reflection
+func (self *ArrayOfInt) UnmarshalTBin(dec *tbin.Decoder) error {
+	if ok, err := dec.ExpectType(tbinArrayOfIntSignature, self); !ok {
+		return err
+	}
+	if *self == nil {
+		*self = make([]int32, 0)
+	} else {
+		*self = (*self)[:0]
+	}
+	for i1, n1 := 0, dec.ReadSize(); i1 < n1 && dec.Error() == nil; i1++ {
+		var v1 int32
+		v1 = dec.ReadInt32()
+		*self = append(*self, v1)
+	}
+	return dec.Error()
+}
+
+// MapArrayTest -
+type MapArrayTest struct {
+	Locations map[string]ArrayOfInt `json:"locations"`
+}
+
+// NewMapArrayTest - creates an initialized MapArrayTest instance, returns a pointer to it
+func NewMapArrayTest(init ...*MapArrayTest) *MapArrayTest {
+	var o *MapArrayTest
+	if len(init) == 1 {
+		o = init[0]
+	} else {
+		o = new(MapArrayTest)
+	}
+	return o.Init()
+}
+
+// Init - sets up the instance according to its default field values, if any
+func (self *MapArrayTest) Init() *MapArrayTest {
+	if self.Locations == nil {
+		self.Locations = make(map[string]ArrayOfInt)
+	}
+	return self
+}
+
+type rawMapArrayTest MapArrayTest
+
+// UnmarshalJSON is defined for proper JSON decoding of a MapArrayTest
+func (self *MapArrayTest) UnmarshalJSON(b []byte) error {
+	var m rawMapArrayTest
+	err := json.Unmarshal(b, &m)
+	if err == nil {
+		o := MapArrayTest(m)
+		*self = *((&o).Init())
+		err = self.Validate()
+	}
+	return err
+}
+
+// Validate - checks for missing required fields, etc
+func (self *MapArrayTest) Validate() error {
+	if self.Locations == nil {
+		return fmt.Errorf("MapArrayTest: Missing required field: locations")
+	}
+	return nil
+}
+
+var tbinMapArrayTestSignature = tbin.Struct(
+	tbin.Field("locations", tbin.Map(tbin.String, tbinArrayOfIntSignature), false),
+)
+
+// MarshalTBin is defined for TBin encoding of a MapArrayTest without reflection
+func (self *MapArrayTest) MarshalTBin(enc *tbin.Encoder) error {
+	enc.WriteType(tbinMapArrayTestSignature)
+	return self.writeTBin(enc)
+}
+
+func (self *MapArrayTest) writeTBin(enc *tbin.Encoder) error {
+	if self == nil {
+		return fmt.Errorf("Cannot marshal null MapArrayTest")
+	}
+	enc.WriteSize(len(self.Locations))
+	for k1, v1 := range self.Locations {
+		enc.WriteString(k1)
+		enc.WriteSize(len(v1))
+		for _, item2 := range v1 {
+			enc.WriteInt32(item2)
+		}
+	}
+	return enc.Error()
+}
+
+// UnmarshalTBin is defined for TBin decoding of a MapArrayTest without reflection
+func (self *MapArrayTest) UnmarshalTBin(dec *tbin.Decoder) error {
+	if ok, err := dec.ExpectType(tbinMapArrayTestSignature, self); !ok {
+		return err
+	}
+	return self.readTBin(dec)
+}
+
+func (self *MapArrayTest) readTBin(dec *tbin.Decoder) error {
+	if self.Locations == nil {
+		self.Locations = make(map[string]ArrayOfInt)
+	} else {
+		clear(self.Locations)
+	}
+	for i1, n1 := 0, dec.ReadSize(); i1 < n1 && dec.Error() == nil; i1++ {
+		var k1 string
+		k1 = dec.ReadString()
+		var v1 ArrayOfInt
+		v1 = make(ArrayOfInt, 0)
+		for i2, n2 := 0, dec.ReadSize(); i2 < n2 && dec.Error() == nil; i2++ {
+			var v2 int32
+			v2 = dec.ReadInt32()
+			v1 = append(v1, v2)
+		}
+		self.Locations[k1] = v1
+	}
+	return dec.Error()
+}
+
+// IntOOBTest -
+type IntOOBTest struct {
+	Theyear int32 `json:"theyear"`
+}
+
+// NewIntOOBTest - creates an initialized IntOOBTest instance, returns a pointer to it
+func NewIntOOBTest(init ...*IntOOBTest) *IntOOBTest {
+	var o *IntOOBTest
+	if len(init) == 1 {
+		o = init[0]
+	} else {
+		o = new(IntOOBTest)
+	}
+	return o
+}
+
+type rawIntOOBTest IntOOBTest
+
+// UnmarshalJSON is defined for proper JSON decoding of a IntOOBTest
+func (self *IntOOBTest) UnmarshalJSON(b []byte) error {
+	var m rawIntOOBTest
+	err := json.Unmarshal(b, &m)
+	if err == nil {
+		o := IntOOBTest(m)
+		*self = o
+		err = self.Validate()
+	}
+	return err
+}
+
+// Validate - checks for missing required fields, etc
+func (self *IntOOBTest) Validate() error {
+	return nil
+}
+
+var tbinIntOOBTestSignature = tbin.Struct(
+	tbin.Field("theyear", tbin.Int32, false),
+)
+
+// MarshalTBin is defined for TBin encoding of a IntOOBTest without reflection
+func (self *IntOOBTest) MarshalTBin(enc *tbin.Encoder) error {
+	enc.WriteType(tbinIntOOBTestSignature)
+	return self.writeTBin(enc)
+}
+
+func (self *IntOOBTest) writeTBin(enc *tbin.Encoder) error {
+	if self == nil {
+		return fmt.Errorf("Cannot marshal null IntOOBTest")
+	}
+	enc.WriteInt32(self.Theyear)
+	return enc.Error()
+}
+
+// UnmarshalTBin is defined for TBin decoding of a IntOOBTest without reflection
+func (self *IntOOBTest) UnmarshalTBin(dec *tbin.Decoder) error {
+	if ok, err := dec.ExpectType(tbinIntOOBTestSignature, self); !ok {
+		return err
+	}
+	return self.readTBin(dec)
+}
+
+func (self *IntOOBTest) readTBin(dec *tbin.Decoder) error {
+	self.Theyear = dec.ReadInt32()
+	return dec.Error()
+}
+
+// NegativeNumberTest -
+type NegativeNumberTest struct {
+	Mylatitude float64 `json:"mylatitude"`
+}
+
+// NewNegativeNumberTest - creates an initialized NegativeNumberTest instance, returns a pointer to it
+func NewNegativeNumberTest(init ...*NegativeNumberTest) *NegativeNumberTest {
+	var o *NegativeNumberTest
+	if len(init) == 1 {
+		o = init[0]
+	} else {
+		o = new(NegativeNumberTest)
+	}
+	return o
+}
+
+type rawNegativeNumberTest NegativeNumberTest
+
+// UnmarshalJSON is defined for proper JSON decoding of a NegativeNumberTest
+func (self *NegativeNumberTest) UnmarshalJSON(b []byte) error {
+	var m rawNegativeNumberTest
+	err := json.Unmarshal(b, &m)
+	if err == nil {
+		o := NegativeNumberTest(m)
+		*self = o
+		err = self.Validate()
+	}
+	return err
+}
+
+// Validate - checks for missing required fields, etc
+func (self *NegativeNumberTest) Validate() error {
+	return nil
+}
+
+var tbinNegativeNumberTestSignature = tbin.Struct(
+	tbin.Field("mylatitude", tbin.Float64, false),
+)
+
+// MarshalTBin is defined for TBin encoding of a NegativeNumberTest without reflection
+func (self *NegativeNumberTest) MarshalTBin(enc *tbin.Encoder) error {
+	enc.WriteType(tbinNegativeNumberTestSignature)
+	return self.writeTBin(enc)
+}
+
+func (self *NegativeNumberTest) writeTBin(enc *tbin.Encoder) error {
+	if self == nil {
+		return fmt.Errorf("Cannot marshal null NegativeNumberTest")
+	}
+	enc.WriteFloat64(self.Mylatitude)
+	return enc.Error()
+}
+
+// UnmarshalTBin is defined for TBin decoding of a NegativeNumberTest without reflection
+func (self *NegativeNumberTest) UnmarshalTBin(dec *tbin.Decoder) error {
+	if ok, err := dec.ExpectType(tbinNegativeNumberTestSignature, self); !ok {
+		return err
+	}
+	return self.readTBin(dec)
+}
+
+func (self *NegativeNumberTest) readTBin(dec *tbin.Decoder) error {
+	self.Mylatitude = dec.ReadFloat64()
+	return dec.Error()
+}
+
+// UUIDTest -
+type UUIDTest struct {
+	Myid rdl.UUID `json:"myid"`
+}
+
+// NewUUIDTest - creates an initialized UUIDTest instance, returns a pointer to it
+func NewUUIDTest(init ...*UUIDTest) *UUIDTest {
+	var o *UUIDTest
+	if len(init) == 1 {
+		o = init[0]
+	} else {
+		o = new(UUIDTest)
+	}
+	return o
+}
+
+type rawUUIDTest UUIDTest
+
+// UnmarshalJSON is defined for proper JSON decoding of a UUIDTest
+func (self *UUIDTest) UnmarshalJSON(b []byte) error {
+	var m rawUUIDTest
+	err := json.Unmarshal(b, &m)
+	if err == nil {
+		o := UUIDTest(m)
+		*self = o
+		err = self.Validate()
+	}
+	return err
+}
+
+// Validate - checks for missing required fields, etc
+func (self *UUIDTest) Validate() error {
+	if self.Myid == nil {
+		return fmt.Errorf("UUIDTest: Missing required field: myid")
+	}
+	return nil
+}
+
+var tbinUUIDTestSignature = tbin.Struct(
+	tbin.Field("myid", tbin.UUID, false),
+)
+
+// MarshalTBin is defined for TBin encoding of a UUIDTest without reflection
+func (self *UUIDTest) MarshalTBin(enc *tbin.Encoder) error {
+	enc.WriteType(tbinUUIDTestSignature)
+	return self.writeTBin(enc)
+}
+
+func (self *UUIDTest) writeTBin(enc *tbin.Encoder) error {
+	if self == nil {
+		return fmt.Errorf("Cannot marshal null UUIDTest")
+	}
+	enc.WriteUUID(self.Myid)
+	return enc.Error()
+}
+
+// UnmarshalTBin is defined for TBin decoding of a UUIDTest without reflection
+func (self *UUIDTest) UnmarshalTBin(dec *tbin.Decoder) error {
+	if ok, err := dec.ExpectType(tbinUUIDTestSignature, self); !ok {
+		return err
+	}
+	return self.readTBin(dec)
+}
+
+func (self *UUIDTest) readTBin(dec *tbin.Decoder) error {
+	self.Myid = dec.ReadUUID()
+	return dec.Error()
+}
+
+// TimestampTest -
+type TimestampTest struct {
+	Mytime rdl.Timestamp `json:"mytime"`
+}
+
+// NewTimestampTest - creates an initialized TimestampTest instance, returns a pointer to it
+func NewTimestampTest(init ...*TimestampTest) *TimestampTest {
+	var o *TimestampTest
+	if len(init) == 1 {
+		o = init[0]
+	} else {
+		o = new(TimestampTest)
+	}
+	return o
+}
+
+type rawTimestampTest TimestampTest
+
+// UnmarshalJSON is defined for proper JSON decoding of a TimestampTest
+func (self *TimestampTest) UnmarshalJSON(b []byte) error {
+	var m rawTimestampTest
+	err := json.Unmarshal(b, &m)
+	if err == nil {
+		o := TimestampTest(m)
+		*self = o
+		err = self.Validate()
+	}
+	return err
+}
+
+// Validate - checks for missing required fields, etc
+func (self *TimestampTest) Validate() error {
+	if self.Mytime.IsZero() {
+		return fmt.Errorf("TimestampTest: Missing required field: mytime")
+	}
+	return nil
+}
+
+var tbinTimestampTestSignature = tbin.Struct(
+	tbin.Field("mytime", tbin.Timestamp, false),
+)
+
+// MarshalTBin is defined for TBin encoding of a TimestampTest without reflection
+func (self *TimestampTest) MarshalTBin(enc *tbin.Encoder) error {
+	enc.WriteType(tbinTimestampTestSignature)
+	return self.writeTBin(enc)
+}
+
+func (self *TimestampTest) writeTBin(enc *tbin.Encoder) error {
+	if self == nil {
+		return fmt.Errorf("Cannot marshal null TimestampTest")
+	}
+	enc.WriteTimestamp(self.Mytime)
+	return enc.Error()
+}
+
+// UnmarshalTBin is defined for TBin decoding of a TimestampTest without reflection
+func (self *TimestampTest) UnmarshalTBin(dec *tbin.Decoder) error {
+	if ok, err := dec.ExpectType(tbinTimestampTestSignature, self); !ok {
+		return err
+	}
+	return self.readTBin(dec)
+}
+
+func (self *TimestampTest) readTBin(dec *tbin.Decoder) error {
+	self.Mytime = dec.ReadTimestamp()
+	return dec.Error()
+}
+
+// BigStruct -
+type BigStruct struct {
+	MyName           string           `json:"myName"`
+	MyUtfname        string           `json:"myUtfname"`
+	MyBool           bool             `json:"myBool"`
+	MyByte           int8             `json:"myByte"`
+	MyShort          int16            `json:"myShort"`
+	MyInt            int32            `json:"myInt"`
+	MyLong           int64            `json:"myLong"`
+	MyFloat          float32          `json:"myFloat"`
+	MyDouble         float64          `json:"myDouble"`
+	MyIntArray       []int32          `json:"myIntArray"`
+	MyStringArray    []string         `json:"myStringArray"`
+	MyMap            map[string]int32 `json:"myMap"`
+	MyUuid           rdl.UUID         `json:"myUuid"`
+	MyStringSubtype  string           `json:"myStringSubtype"`
+	MyInt32Subtype   int32            `json:"myInt32Subtype"`
+	MyFloat64Subtype float64          `json:"myFloat64Subtype"`
+	MyTime           rdl.Timestamp    `json:"myTime"`
+}
+
+// NewBigStruct - creates an initialized BigStruct instance, returns a pointer to it
+func NewBigStruct(init ...*BigStruct) *BigStruct {
+	var o *BigStruct
+	if len(init) == 1 {
+		o = init[0]
+	} else {
+		o = new(BigStruct)
+	}
+	return o.Init()
+}
+
+// Init - sets up the instance according to its default field values, if any
+func (self *BigStruct) Init() *BigStruct {
+	if self.MyIntArray == nil {
+		self.MyIntArray = make([]int32, 0)
+	}
+	if self.MyStringArray == nil {
+		self.MyStringArray = make([]string, 0)
+	}
+	if self.MyMap == nil {
+		self.MyMap = make(map[string]int32)
+	}
+	return self
+}
+
+type rawBigStruct BigStruct
+
+// UnmarshalJSON is defined for proper JSON decoding of a BigStruct
+func (self *BigStruct) UnmarshalJSON(b []byte) error {
+	var m rawBigStruct
+	err := json.Unmarshal(b, &m)
+	if err == nil {
+		o := BigStruct(m)
+		*self = *((&o).Init())
+		err = self.Validate()
+	}
+	return err
+}
+
+// Validate - checks for missing required fields, etc
+func (self *BigStruct) Validate() error {
+	if self.MyName == "" {
+		return fmt.Errorf("BigStruct.myName is missing but is a required field")
+	} else {
+		val := rdl.Validate(TestsSchema(), "String", self.MyName)
+		if !val.Valid {
+			return fmt.Errorf("BigStruct.myName does not contain a valid String (%v)", val.Error)
+		}
+	}
+	if self.MyUtfname == "" {
+		return fmt.Errorf("BigStruct.myUtfname is missing but is a required field")
+	} else {
+		val := rdl.Validate(TestsSchema(), "String", self.MyUtfname)
+		if !val.Valid {
+			return fmt.Errorf("BigStruct.myUtfname does not contain a valid String (%v)", val.Error)
+		}
+	}
+	if self.MyIntArray == nil {
+		return fmt.Errorf("BigStruct: Missing required field: myIntArray")
+	}
+	if self.MyStringArray == nil {
+		return fmt.Errorf("BigStruct: Missing required field: myStringArray")
+	}
+	if self.MyMap == nil {
+		return fmt.Errorf("BigStruct: Missing required field: myMap")
+	}
+	if self.MyUuid == nil {
+		return fmt.Errorf("BigStruct: Missing required field: myUuid")
+	}
+	if self.MyStringSubtype == "" {
+		return fmt.Errorf("BigStruct.myStringSubtype is missing but is a required field")
+	} else {
+		val := rdl.Validate(TestsSchema(), "azAZ", self.MyStringSubtype)
+		if !val.Valid {
+			return fmt.Errorf("BigStruct.myStringSubtype does not contain a valid azAZ (%v)", val.Error)
+		}
+	}
+	if self.MyTime.IsZero() {
+		return fmt.Errorf("BigStruct: Missing required field: myTime")
+	}
+	return nil
+}
+
+var tbinBigStructSignature = tbin.Struct(
+	tbin.Field("myName", tbin.String, false),
+	tbin.Field("myUtfname", tbin.String, false),
+	tbin.Field("myBool", tbin.Bool, false),
+	tbin.Field("myByte", tbin.Int8, false),
+	tbin.Field("myShort", tbin.Int16, false),
+	tbin.Field("myInt", tbin.Int32, false),
+	tbin.Field("myLong", tbin.Int64, false),
+	tbin.Field("myFloat", tbin.Float32, false),
+	tbin.Field("myDouble", tbin.Float64, false),
+	tbin.Field("myIntArray", tbin.Array(tbin.Int32), false),
+	tbin.Field("myStringArray", tbin.Array(tbin.String), false),
+	tbin.Field("myMap", tbin.Map(tbin.String, tbin.Int32), false),
+	tbin.Field("myUuid", tbin.UUID, false),
+	tbin.Field("myStringSubtype", tbin.String, false),
+	tbin.Field("myInt32Subtype", tbin.Int32, false),
+	tbin.Field("myFloat64Subtype", tbin.Float64, false),
+	tbin.Field("myTime", tbin.Timestamp, false),
+)
+
+// MarshalTBin is defined for TBin encoding of a BigStruct without reflection
+func (self *BigStruct) MarshalTBin(enc *tbin.Encoder) error {
+	enc.WriteType(tbinBigStructSignature)
+	return self.writeTBin(enc)
+}
+
+func (self *BigStruct) writeTBin(enc *tbin.Encoder) error {
+	if self == nil {
+		return fmt.Errorf("Cannot marshal null BigStruct")
+	}
+	enc.WriteString(self.MyName)
+	enc.WriteString(self.MyUtfname)
+	enc.WriteBool(self.MyBool)
+	enc.WriteInt8(self.MyByte)
+	enc.WriteInt16(self.MyShort)
+	enc.WriteInt32(self.MyInt)
+	enc.WriteInt64(self.MyLong)
+	enc.WriteFloat32(self.MyFloat)
+	enc.WriteFloat64(self.MyDouble)
+	enc.WriteSize(len(self.MyIntArray))
+	for _, item1 := range self.MyIntArray {
+		enc.WriteInt32(item1)
+	}
+	enc.WriteSize(len(self.MyStringArray))
+	for _, item1 := range self.MyStringArray {
+		enc.WriteString(item1)
+	}
+	enc.WriteSize(len(self.MyMap))
+	for k1, v1 := range self.MyMap {
+		enc.WriteString(k1)
+		enc.WriteInt32(v1)
+	}
+	enc.WriteUUID(self.MyUuid)
+	enc.WriteString(self.MyStringSubtype)
+	enc.WriteInt32(self.MyInt32Subtype)
+	enc.WriteFloat64(self.MyFloat64Subtype)
+	enc.WriteTimestamp(self.MyTime)
+	return enc.Error()
+}
+
+// UnmarshalTBin is defined for TBin decoding of a BigStruct without reflection
+func (self *BigStruct) UnmarshalTBin(dec *tbin.Decoder) error {
+	if ok, err := dec.ExpectType(tbinBigStructSignature, self); !ok {
+		return err
+	}
+	return self.readTBin(dec)
+}
+
+func (self *BigStruct) readTBin(dec *tbin.Decoder) error {
+	self.MyName = dec.ReadString()
+	self.MyUtfname = dec.ReadString()
+	self.MyBool = dec.ReadBool()
+	self.MyByte = dec.ReadInt8()
+	self.MyShort = dec.ReadInt16()
+	self.MyInt = dec.ReadInt32()
+	self.MyLong = dec.ReadInt64()
+	self.MyFloat = dec.ReadFloat32()
+	self.MyDouble = dec.ReadFloat64()
+	if self.MyIntArray == nil {
+		self.MyIntArray = make([]int32, 0)
+	} else {
+		self.MyIntArray = self.MyIntArray[:0]
+	}
+	for i1, n1 := 0, dec.ReadSize(); i1 < n1 && dec.Error() == nil; i1++ {
+		var v1 int32
+		v1 = dec.ReadInt32()
+		self.MyIntArray = append(self.MyIntArray, v1)
+	}
+	if self.MyStringArray == nil {
+		self.MyStringArray = make([]string, 0)
+	} else {
+		self.MyStringArray = self.MyStringArray[:0]
+	}
+	for i1, n1 := 0, dec.ReadSize(); i1 < n1 && dec.Error() == nil; i1++ {
+		var v1 string
+		v1 = dec.ReadString()
+		self.MyStringArray = append(self.MyStringArray, v1)
+	}
+	if self.MyMap == nil {
+		self.MyMap = make(map[string]int32)
+	} else {
+		clear(self.MyMap)
+	}
+	for i1, n1 := 0, dec.ReadSize(); i1 < n1 && dec.Error() == nil; i1++ {
+		var k1 string
+		k1 = dec.ReadString()
+		var v1 int32
+		v1 = dec.ReadInt32()
+		self.MyMap[k1] = v1
+	}
+	self.MyUuid = dec.ReadUUID()
+	self.MyStringSubtype = dec.ReadString()
+	self.MyInt32Subtype = dec.ReadInt32()
+	self.MyFloat64Subtype = dec.ReadFloat64()
+	self.MyTime = dec.ReadTimestamp()
+	return dec.Error()
+}
+
+// BigTest -
+type BigTest struct {
+	Stuff []*BigStruct `json:"stuff"`
+}
+
+// NewBigTest - creates an initialized BigTest instance, returns a pointer to it
+func NewBigTest(init ...*BigTest) *BigTest {
+	var o *BigTest
+	if len(init) == 1 {
+		o = init[0]
+	} else {
+		o = new(BigTest)
+	}
+	return o.Init()
+}
+
+// Init - sets up the instance according to its default field values, if any
+func (self *BigTest) Init() *BigTest {
+	if self.Stuff == nil {
+		self.Stuff = make([]*BigStruct, 0)
+	}
+	return self
+}
+
+type rawBigTest BigTest
+
+// UnmarshalJSON is defined for proper JSON decoding of a BigTest
+func (self *BigTest) UnmarshalJSON(b []byte) error {
+	var m rawBigTest
+	err := json.Unmarshal(b, &m)
+	if err == nil {
+		o := BigTest(m)
+		*self = *((&o).Init())
+		err = self.Validate()
+	}
+	return err
+}
+
+// Validate - checks for missing required fields, etc
+func (self *BigTest) Validate() error {
+	if self.Stuff == nil {
+		return fmt.Errorf("BigTest: Missing required field: stuff")
+	}
+	return nil
+}
+
+var tbinBigTestSignature = tbin.Struct(
+	tbin.Field("stuff", tbin.Array(tbinBigStructSignature), false),
+)
+
+// MarshalTBin is defined for TBin encoding of a BigTest without reflection
+func (self *BigTest) MarshalTBin(enc *tbin.Encoder) error {
+	enc.WriteType(tbinBigTestSignature)
+	return self.writeTBin(enc)
+}
+
+func (self *BigTest) writeTBin(enc *tbin.Encoder) error {
+	if self == nil {
+		return fmt.Errorf("Cannot marshal null BigTest")
+	}
+	enc.WriteSize(len(self.Stuff))
+	for _, item1 := range self.Stuff {
+		if err := item1.writeTBin(enc); err != nil {
+			return err
+		}
+	}
+	return enc.Error()
+}
+
+// UnmarshalTBin is defined for TBin decoding of a BigTest without reflection
+func (self *BigTest) UnmarshalTBin(dec *tbin.Decoder) error {
+	if ok, err := dec.ExpectType(tbinBigTestSignature, self); !ok {
+		return err
+	}
+	return self.readTBin(dec)
+}
+
+func (self *BigTest) readTBin(dec *tbin.Decoder) error {
+	if self.Stuff == nil {
+		self.Stuff = make([]*BigStruct, 0)
+	} else {
+		self.Stuff = self.Stuff[:0]
+	}
+	for i1, n1 := 0, dec.ReadSize(); i1 < n1 && dec.Error() == nil; i1++ {
+		var v1 *BigStruct
+		v1 = new(BigStruct)
+		if err := v1.readTBin(dec); err != nil {
+			return err
+		}
+		self.Stuff = append(self.Stuff, v1)
+	}
+	return dec.Error()
+}
@@ -0,0 +1,150 @@
+//
+// Code generated by go generate DO NOT EDIT.
+//
+
+package bigtest
+
+import (
+	"log"
+
+	rdl "github.com/ardielle/ardielle-go/rdl"
+)
+
+var schema *rdl.Schema
+
+func init() {
+	sb := rdl.NewSchemaBuilder("tests")
+	sb.Version(1)
+
+	tSimpleName := rdl.NewStringTypeBuilder("SimpleName")
+	tSimpleName.Pattern("[a-zA-Z_][a-zA-Z_0-9]*")
+	sb.AddType(tSimpleName.Build())
+
+	tCompoundName := rdl.NewStringTypeBuilder("CompoundName")
+	tCompoundName.Pattern("([a-zA-Z_][a-zA-Z_0-9]*\\.)*[a-zA-Z_][a-zA-Z_0-9]*")
+	sb.AddType(tCompoundName.Build())
+
+	tOptions := rdl.NewEnumTypeBuilder("Enum", "Options")
+	tOptions.Comment("options comment")
+	tOptions.Element("ONE", "")
+	tOptions.Element("TWO", "")
+	tOptions.Element("THREE", "")
+	sb.AddType(tOptions.Build())
+
+	tComplicatedOptions := rdl.NewStringTypeBuilder("ComplicatedOptions")
+	sb.AddType(tComplicatedOptions.Build())
+
+	tAlphaName := rdl.NewStringTypeBuilder("AlphaName")
+	tAlphaName.Comment("AlphaName def")
+	tAlphaName.Pattern("[a-zA-Z_]+")
+	sb.AddType(tAlphaName.Build())
+
+	tYEncoded := rdl.NewStringTypeBuilder("YEncoded")
+	tYEncoded.Pattern("[a-zA-Z0-9._%=-]*")
+	sb.AddType(tYEncoded.Build())
+
+	tStringTest := rdl.NewStructTypeBuilder("Struct", "StringTest")
+	tStringTest.Field("name", "SimpleName", false, nil, "")
+	tStringTest.Field("parent", "CompoundName", false, nil, "")
+	tStringTest.ArrayField("names", "SimpleName", true, "")
+	tStringTest.Field("enc", "YEncoded", true, nil, "")
+	sb.AddType(tStringTest.Build())
+
+	tAzAZ := rdl.NewStringTypeBuilder("azAZ")
+	tAzAZ.Pattern("[a-zA-Z]+")
+	sb.AddType(tAzAZ.Build())
+
+	tTinyInt := rdl.NewAliasTypeBuilder("Int8", "TinyInt")
+	sb.AddType(tTinyInt.Build())
+
+	tSmallInt := rdl.NewAliasTypeBuilder("Int16", "SmallInt")
+	sb.AddType(tSmallInt.Build())
+
+	tRegularInt := rdl.NewAliasTypeBuilder("Int32", "RegularInt")
+	sb.AddType(tRegularInt.Build())
+
+	tLargeInt := rdl.NewAliasTypeBuilder("Int64", "LargeInt")
+	sb.AddType(tLargeInt.Build())
+
+	tYear := rdl.NewNumberTypeBuilder("Int32", "Year")
+	tYear.Min(1000)
+	tYear.Max(3000)
+	sb.AddType(tYear.Build())
+
+	tLatitude := rdl.NewNumberTypeBuilder("Float64", "Latitude")
+	tLatitude.Min(-90)
+	tLatitude.Max(90)
+	sb.AddType(tLatitude.Build())
+
+	tPi := rdl.NewNumberTypeBuilder("Float64", "Pi")
+	tPi.Min(3)
+	tPi.Max(3.5)
+	sb.AddType(tPi.Build())
+
+	tLongNumber := rdl.NewNumberTypeBuilder("Int64", "LongNumber")
+	tLongNumber.Min(500000)
+	tLongNumber.Max(10000000)
+	sb.AddType(tLongNumber.Build())
+
+	tMapTest := rdl.NewStructTypeBuilder("Struct", "MapTest")
+	tMapTest.MapField("locations", "String", "Int32", false, "")
+	sb.AddType(tMapTest.Build())
+
+	tArrayOfInt := rdl.NewArrayTypeBuilder("Array", "ArrayOfInt")
+	tArrayOfInt.Items("Int32")
+	sb.AddType(tArrayOfInt.Build())
+
+	tMapArrayTest := rdl.NewStructTypeBuilder("Struct", "MapArrayTest")
+	tMapArrayTest.MapField("locations", "String", "ArrayOfInt", false, "")
+	sb.AddType(tMapArrayTest.Build())
+
+	tIntOOBTest := rdl.NewStructTypeBuilder("Struct", "IntOOBTest")
+	tIntOOBTest.Field("theyear", "Year", false, nil, "")
+	sb.AddType(tIntOOBTest.Build())
+
+	tNegativeNumberTest := rdl.NewStructTypeBuilder("Struct", "NegativeNumberTest")
+	tNegativeNumberTest.Field("mylatitude", "Latitude", false, nil, "")
+	sb.AddType(tNegativeNumberTest.Build())
+
+	tUUIDTest := rdl.NewStructTypeBuilder("Struct", "UUIDTest")
+	tUUIDTest.Field("myid", "UUID", false, nil, "")
+	sb.AddType(tUUIDTest.Build())
+
+	tTimestampTest := rdl.NewStructTypeBuilder("Struct", "TimestampTest")
+	tTimestampTest.Field("mytime", "Timestamp", false, nil, "")
+	sb.AddType(tTimestampTest.Build())
+
+	tBigStruct := rdl.NewStructTypeBuilder("Struct", "BigStruct")
+	tBigStruct.Field("myName", "String", false, nil, "")
+	tBigStruct.Field("myUtfname", "String", false, nil, "")
+	tBigStruct.Field("myBool", "Bool", false, nil, "")
+	tBigStruct.Field("myByte", "Int8", false, nil, "")
+	tBigStruct.Field("myShort", "Int16", false, nil, "")
+	tBigStruct.Field("myInt", "Int32", false, nil, "")
+	tBigStruct.Field("myLong", "Int64", false, nil, "")
+	tBigStruct.Field("myFloat", "Float32", false, nil, "")
+	tBigStruct.Field("myDouble", "Float64", false, nil, "")
+	tBigStruct.ArrayField("myIntArray", "Int32", false, "")
+	tBigStruct.ArrayField("myStringArray", "String", false, "")
+	tBigStruct.MapField("myMap", "String", "Int32", false, "")
+	tBigStruct.Field("myUuid", "UUID", false, nil, "")
+	tBigStruct.Field("myStringSubtype", "azAZ", false, nil, "")
+	tBigStruct.Field("myInt32Subtype", "Year", false, nil, "")
+	tBigStruct.Field("myFloat64Subtype", "Pi", false, nil, "")
+	tBigStruct.Field("myTime", "Timestamp", false, nil, "")
+	sb.AddType(tBigStruct.Build())
+
+	tBigTest := rdl.NewStructTypeBuilder("Struct", "BigTest")
+	tBigTest.ArrayField("stuff", "BigStruct", false, "")
+	sb.AddType(tBigTest.Build())
+
+	var err error
+	schema, err = sb.BuildParanoid()
+	if err != nil {
+		log.Fatalf("rdl: schema build failed: %s", err)
+	}
+}
+
+func TestsSchema() *rdl.Schema {
+	return schema
+}
@@ -0,0 +1,9 @@
+// Copyright 2015 Yahoo Inc.
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+// Package gentest checks the TBin methods generated with the gomodel GenerateTBin option against the
+// reflective encoder, and benchmarks them. The models are generated from the tbin test schemas into the
+// polyline, bigtest and shapes subpackages.
+package gentest
+
+//go:generate go run generator.go
@@ -0,0 +1,32 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import (
+	"log"
+
+	"github.com/ardielle/ardielle-go/gen/gomodel"
+	"github.com/ardielle/ardielle-go/rdl"
+)
+
+// Regenerates the models in the subpackages from the tbin test schemas.
+func main() {
+	for ns, path := range map[string]string{"polyline": "../../testdata/polyline.rdl", "bigtest": "../../testdata/bigtest.rdl", "shapes": "../../testdata/shapes.rdl"} {
+		schema, err := rdl.ParseRDLFile(path, false, false, false)
+		if err != nil {
+			log.Fatal(err)
+		}
+		params := &gomodel.GeneratorParams{
+			Outdir:         ns,
+			Banner:         "go generate",
+			Namespace:      ns,
+			LibRdl:         gomodel.DefaultLibRdl,
+			GenerateSchema: true,
+			GenerateTBin:   true,
+		}
+		if err := gomodel.Generate(schema, params); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+// Copyright 2015 Yahoo Inc.
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package gentest
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/ardielle/ardielle-go/rdl"
+	"github.com/ardielle/ardielle-go/tbin"
+	"github.com/ardielle/ardielle-go/tbin/gentest/bigtest"
+	"github.com/ardielle/ardielle-go/tbin/gentest/polyline"
+	"github.com/ardielle/ardielle-go/tbin/gentest/shapes"
+)
+
+const testDataJSON = `{"points":[{"x":1,"y":11},{"x":2,"y":22},{"x":3,"y":33},{"x":10,"y":100},{"x":-23,"y":100},{"x":-23,"y":-33},{"x":10,"y":-33},{"x":103,"y":333},{"x":300,"y":1000},{"x":1234,"y":1234},{"x":12345678,"y":12321312},{"x":321321321,"y":33},{"x":1,"y":11}]}`
+
+const testDataLengthTBinBest = 70
+
+func testPolyline() *polyline.Polyline {
+	var line polyline.Polyline
+	if err := json.Unmarshal([]byte(testDataJSON), &line); err != nil {
+		return nil
+	}
+	return &line
+}
+
+// testBigTest loads the bigtest data, keeping a single entry in each map so that the encodings can be
+// compared byte for byte: map iteration order is random.
+func testBigTest(test testing.TB) *bigtest.BigTest {
+	j, err := os.ReadFile("../../testdata/bigtest.json")
+	if err != nil {
+		test.Fatalf("Cannot read JSON file: %v", err)
+	}
+	var bt bigtest.BigTest
+	if err := json.Unmarshal(j, &bt); err != nil {
+		test.Fatalf("Cannot parse JSON: %v", err)
+	}
+	for _, bs := range bt.Stuff {
+		for k := range bs.MyMap {
+			if len(bs.MyMap) > 1 {
+				delete(bs.MyMap, k)
+			}
+		}
+	}
+	return &bt
+}
+
+func reflectBytes(test *testing.T, data interface{}) []byte {
+	enc := tbin.NewEncoder(nil)
+	if err := enc.EncodeReflect(data); err != nil {
+		test.Fatalf("Cannot encode %T with reflection: %v", data, err)
+	}
+	return enc.Bytes()
+}
+
+// expectIdentical checks that the generated MarshalTBin matches the reflective encoder, and that the
+// generated UnmarshalTBin restores the original value.
+func expectIdentical(test *testing.T, data interface{}) {
+	expected := reflectBytes(test, data)
+	tdata, err := tbin.Marshal(data)
+	if err != nil {
+		test.Errorf("Cannot marshal %T: %v", data, err)
+		return
+	}
+	if !bytes.Equal(expected, tdata) {
+		test.Errorf("Generated encoding of %T differs from reflection:\n got %x\nwant %x", data, tdata, expected)
+	}
+	decoded := reflect.New(reflect.TypeOf(data).Elem())
+	if err := tbin.Unmarshal(tdata, decoded.Interface()); err != nil {
+		test.Errorf("Cannot unmarshal %T: %v", data, err)
+		return
+	}
+	//compare as JSON: decoded timestamps don't have the monotonic clock reading of the originals
+	j1, _ := json.Marshal(data)
+	j2, _ := json.Marshal(decoded.Interface())
+	if !bytes.Equal(j1, j2) {
+		test.Errorf("Decoded %T doesn't match the original:\n got %s\nwant %s", data, j2, j1)
+	}
+}
+
+func TestPolyline(test *testing.T) {
+	line := testPolyline()
+	expectIdentical(test, line)
+	expectIdentical(test, &polyline.Point{X: -1, Y: 1})
+	if tdata, _ := tbin.Marshal(line); len(tdata) != testDataLengthTBinBest {
+		test.Errorf("Expected %d bytes of TBIN encoded data, got %d", testDataLengthTBinBest, len(tdata))
+	}
+}
+
+func TestBigTest(test *testing.T) {
+	bt := testBigTest(test)
+	expectIdentical(test, bt)
+	expectIdentical(test, bt.Stuff[0])
+	options := bigtest.Options(2)
+	expectIdentical(test, &options)
+	expectIdentical(test, &bigtest.ArrayOfInt{1, -2, 3})
+	expectIdentical(test, &bigtest.MapArrayTest{Locations: map[string]bigtest.ArrayOfInt{"here": {1, 2}}})
+	expectIdentical(test, &bigtest.UUIDTest{Myid: rdl.ParseUUID("7829db01-a4ad-11de-0000-090000000179")})
+	expectIdentical(test, &bigtest.TimestampTest{Mytime: rdl.TimestampNow()})
+}
+
+func TestOptionalFields(test *testing.T) {
+	expectIdentical(test, &bigtest.StringTest{Name: "a", Parent: "b"})
+	expectIdentical(test, &bigtest.StringTest{Name: "a", Parent: "b", Names: []string{"x", "y"}, Enc: "eW8="})
+}
+
+func TestUnions(test *testing.T) {
+	circle := &shapes.Shape{Variant: shapes.ShapeVariantCircle, Circle: &shapes.Circle{Center: &shapes.Point{X: 1, Y: 2}, Radius: 3}}
+	color := shapes.GREEN
+	rect := &shapes.Shape{Variant: shapes.ShapeVariantRect, Rect: &shapes.Rect{Corner: &shapes.Point{X: -1, Y: -2}, Width: 10, Height: 20}}
+	zoom := int32(4)
+	now := rdl.TimestampNow()
+	expectIdentical(test, circle)
+	expectIdentical(test, &shapes.Drawing{Shapes: []*shapes.Shape{circle, rect}})
+	expectIdentical(test, &shapes.Drawing{
+		Shapes:     []*shapes.Shape{rect, {Variant: shapes.ShapeVariantColor, Color: &color}},
+		Focus:      circle,
+		Legend:     map[string]shapes.Color{"sky": shapes.BLUE},
+		Background: &color,
+		Zoom:       &zoom,
+		Created:    &now,
+		Icon:       []byte{0, 1, 255},
+		Extra:      "anything",
+	})
+	if _, err := tbin.Marshal(&shapes.Shape{}); err == nil {
+		test.Errorf("Expected an error marshalling an uninitialized union")
+	}
+}
+
+func BenchmarkPolylineJSONMarshal(b *testing.B) {
+	line := testPolyline()
+	for n := 0; n < b.N; n++ {
+		json.Marshal(line)
+	}
+}
+
+func BenchmarkPolylineTBinMarshalReflect(b *testing.B) {
+	line := testPolyline()
+	for n := 0; n < b.N; n++ {
+		enc := tbin.NewEncoder(nil)
+		enc.EncodeReflect(line)
+		enc.Bytes()
+	}
+}
+
+func BenchmarkPolylineTBinMarshalCodeGen(b *testing.B) {
+	line := testPolyline()
+	for n := 0; n < b.N; n++ {
+		tbin.Marshal(line)
+	}
+}
+
+func BenchmarkPolylineJSONUnmarshal(b *testing.B) {
+	jd, _ := json.Marshal(testPolyline())
+	for n := 0; n < b.N; n++ {
+		var line polyline.Polyline
+		json.Unmarshal(jd, &line)
+	}
+}
+
+func BenchmarkPolylineTBinUnmarshalReflect(b *testing.B) {
+	tdata, _ := tbin.Marshal(testPolyline())
+	for n := 0; n < b.N; n++ {
+		var line polyline.Polyline
+		dec := tbin.NewDecoder(bytes.NewBuffer(tdata))
+		dec.DecodeReflect(reflect.ValueOf(&line).Elem())
+	}
+}
+
+func BenchmarkPolylineTBinUnmarshalCodeGen(b *testing.B) {
+	tdata, _ := tbin.Marshal(testPolyline())
+	for n := 0; n < b.N; n++ {
+		var line polyline.Polyline
+		tbin.Unmarshal(tdata, &line)
+	}
+}
+
+func BenchmarkBigTestJSONMarshal(b *testing.B) {
+	bt := testBigTest(b)
+	for n := 0; n < b.N; n++ {
+		json.Marshal(bt)
+	}
+}
+
+func BenchmarkBigTestTBinMarshalReflect(b *testing.B) {
+	bt := testBigTest(b)
+	for n := 0; n < b.N; n++ {
+		enc := tbin.NewEncoder(nil)
+		enc.EncodeReflect(bt)
+		enc.Bytes()
+	}
+}
+
+func BenchmarkBigTestTBinMarshalCodeGen(b *testing.B) {
+	bt := testBigTest(b)
+	for n := 0; n < b.N; n++ {
+		tbin.Marshal(bt)
+	}
+}
+
+func BenchmarkBigTestJSONUnmarshal(b *testing.B) {
+	jd, _ := json.Marshal(testBigTest(b))
+	for n := 0; n < b.N; n++ {
+		var bt bigtest.BigTest
+		json.Unmarshal(jd, &bt)
+	}
+}
+
+func BenchmarkBigTestTBinUnmarshalReflect(b *testing.B) {
+	tdata, _ := tbin.Marshal(testBigTest(b))
+	for n := 0; n < b.N; n++ {
+		var bt bigtest.BigTest
+		dec := tbin.NewDecoder(bytes.NewBuffer(tdata))
+		dec.DecodeReflect(reflect.ValueOf(&bt).Elem())
+	}
+}
+
+func BenchmarkBigTestTBinUnmarshalCodeGen(b *testing.B) {
+	tdata, _ := tbin.Marshal(testBigTest(b))
+	for n := 0; n < b.N; n++ {
+		var bt bigtest.BigTest
+		tbin.Unmarshal(tdata, &bt)
+	}
+}
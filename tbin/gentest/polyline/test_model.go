@@ -0,0 +1,180 @@
+//
+// Code generated by go generate DO NOT EDIT.
+//
+
+package polyline
+
+import (
+	"encoding/json"
+	"fmt"
+	rdl "github.com/ardielle/ardielle-go/rdl"
+	"github.com/ardielle/ardielle-go/tbin"
+)
+
+var _ = rdl.Version
+var _ = json.Marshal
+var _ = fmt.Printf
+
+// Point -
+type Point struct {
+	X int32 `json:"x"`
+	Y int32 `json:"y"`
+}
+
+// NewPoint - creates an initialized Point instance, returns a pointer to it
+func NewPoint(init ...*Point) *Point {
+	var o *Point
+	if len(init) == 1 {
+		o = init[0]
+	} else {
+		o = new(Point)
+	}
+	return o
+}
+
+type rawPoint Point
+
+// UnmarshalJSON is defined for proper JSON decoding of a Point
+func (self *Point) UnmarshalJSON(b []byte) error {
+	var m rawPoint
+	err := json.Unmarshal(b, &m)
+	if err == nil {
+		o := Point(m)
+		*self = o
+		err = self.Validate()
+	}
+	return err
+}
+
+// Validate - checks for missing required fields, etc
+func (self *Point) Validate() error {
+	return nil
+}
+
+var tbinPointSignature = tbin.Struct(
+	tbin.Field("x", tbin.Int32, false),
+	tbin.Field("y", tbin.Int32, false),
+)
+
+// MarshalTBin is defined for TBin encoding of a Point without reflection
+func (self *Point) MarshalTBin(enc *tbin.Encoder) error {
+	enc.WriteType(tbinPointSignature)
+	return self.writeTBin(enc)
+}
+
+func (self *Point) writeTBin(enc *tbin.Encoder) error {
+	if self == nil {
+		return fmt.Errorf("Cannot marshal null Point")
+	}
+	enc.WriteInt32(self.X)
+	enc.WriteInt32(self.Y)
+	return enc.Error()
+}
+
+// UnmarshalTBin is defined for TBin decoding of a Point without reflection
+func (self *Point) UnmarshalTBin(dec *tbin.Decoder) error {
+	if ok, err := dec.ExpectType(tbinPointSignature, self); !ok {
+		return err
+	}
+	return self.readTBin(dec)
+}
+
+func (self *Point) readTBin(dec *tbin.Decoder) error {
+	self.X = dec.ReadInt32()
+	self.Y = dec.ReadInt32()
+	return dec.Error()
+}
+
+// Polyline -
+type Polyline struct {
+	Points []*Point `json:"points"`
+}
+
+// NewPolyline - creates an initialized Polyline instance, returns a pointer to it
+func NewPolyline(init ...*Polyline) *Polyline {
+	var o *Polyline
+	if len(init) == 1 {
+		o = init[0]
+	} else {
+		o = new(Polyline)
+	}
+	return o.Init()
+}
+
+// Init - sets up the instance according to its default field values, if any
+func (self *Polyline) Init() *Polyline {
+	if self.Points == nil {
+		self.Points = make([]*Point, 0)
+	}
+	return self
+}
+
+type rawPolyline Polyline
+
+// UnmarshalJSON is defined for proper JSON decoding of a Polyline
+func (self *Polyline) UnmarshalJSON(b []byte) error {
+	var m rawPolyline
+	err := json.Unmarshal(b, &m)
+	if err == nil {
+		o := Polyline(m)
+		*self = *((&o).Init())
+		err = self.Validate()
+	}
+	return err
+}
+
+// Validate - checks for missing required fields, etc
+func (self *Polyline) Validate() error {
+	if self.Points == nil {
+		return fmt.Errorf("Polyline: Missing required field: points")
+	}
+	return nil
+}
+
+var tbinPolylineSignature = tbin.Struct(
+	tbin.Field("points", tbin.Array(tbinPointSignature), false),
+)
+
+// MarshalTBin is defined for TBin encoding of a Polyline without reflection
+func (self *Polyline) MarshalTBin(enc *tbin.Encoder) error {
+	enc.WriteType(tbinPolylineSignature)
+	return self.writeTBin(enc)
+}
+
+func (self *Polyline) writeTBin(enc *tbin.Encoder) error {
+	if self == nil {
+		return fmt.Errorf("Cannot marshal null Polyline")
+	}
+	enc.WriteSize(len(self.Points))
+	for _, item1 := range self.Points {
+		if err := item1.writeTBin(enc); err != nil {
+			return err
+		}
+	}
+	return enc.Error()
+}
+
+// UnmarshalTBin is defined for TBin decoding of a Polyline without reflection
+func (self *Polyline) UnmarshalTBin(dec *tbin.Decoder) error {
+	if ok, err := dec.ExpectType(tbinPolylineSignature, self); !ok {
+		return err
+	}
+	return self.readTBin(dec)
+}
+
+func (self *Polyline) readTBin(dec *tbin.Decoder) error {
+	if self.Points == nil {
+		self.Points = make([]*Point, 0)
+	} else {
+		self.Points = self.Points[:0]
+	}
+	for i1, n1 := 0, dec.ReadSize(); i1 < n1 && dec.Error() == nil; i1++ {
+		var v1 *Point
+		v1 = new(Point)
+		if err := v1.readTBin(dec); err != nil {
+			return err
+		}
+		self.Points = append(self.Points, v1)
+	}
+	return dec.Error()
+}
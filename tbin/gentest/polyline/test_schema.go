@@ -0,0 +1,36 @@
+//
+// Code generated by go generate DO NOT EDIT.
+//
+
+package polyline
+
+import (
+	"log"
+
+	rdl "github.com/ardielle/ardielle-go/rdl"
+)
+
+var schema *rdl.Schema
+
+func init() {
+	sb := rdl.NewSchemaBuilder("test")
+
+	tPoint := rdl.NewStructTypeBuilder("Struct", "Point")
+	tPoint.Field("x", "Int32", false, nil, "")
+	tPoint.Field("y", "Int32", false, nil, "")
+	sb.AddType(tPoint.Build())
+
+	tPolyline := rdl.NewStructTypeBuilder("Struct", "Polyline")
+	tPolyline.ArrayField("points", "Point", false, "")
+	sb.AddType(tPolyline.Build())
+
+	var err error
+	schema, err = sb.BuildParanoid()
+	if err != nil {
+		log.Fatalf("rdl: schema build failed: %s", err)
+	}
+}
+
+func TestSchema() *rdl.Schema {
+	return schema
+}
@@ -0,0 +1,663 @@
+//
+// Code generated by go generate DO NOT EDIT.
+//
+
+package shapes
+
+import (
+	"encoding/json"
+	"fmt"
+	rdl "github.com/ardielle/ardielle-go/rdl"
+	"github.com/ardielle/ardielle-go/tbin"
+)
+
+var _ = rdl.Version
+var _ = json.Marshal
+var _ = fmt.Printf
+
+// Point -
+type Point struct {
+	X int32 `json:"x"`
+	Y int32 `json:"y"`
+}
+
+// NewPoint - creates an initialized Point instance, returns a pointer to it
+func NewPoint(init ...*Point) *Point {
+	var o *Point
+	if len(init) == 1 {
+		o = init[0]
+	} else {
+		o = new(Point)
+	}
+	return o
+}
+
+type rawPoint Point
+
+// UnmarshalJSON is defined for proper JSON decoding of a Point
+func (self *Point) UnmarshalJSON(b []byte) error {
+	var m rawPoint
+	err := json.Unmarshal(b, &m)
+	if err == nil {
+		o := Point(m)
+		*self = o
+		err = self.Validate()
+	}
+	return err
+}
+
+// Validate - checks for missing required fields, etc
+func (self *Point) Validate() error {
+	return nil
+}
+
+var tbinPointSignature = tbin.Struct(
+	tbin.Field("x", tbin.Int32, false),
+	tbin.Field("y", tbin.Int32, false),
+)
+
+// MarshalTBin is defined for TBin encoding of a Point without reflection
+func (self *Point) MarshalTBin(enc *tbin.Encoder) error {
+	enc.WriteType(tbinPointSignature)
+	return self.writeTBin(enc)
+}
+
+func (self *Point) writeTBin(enc *tbin.Encoder) error {
+	if self == nil {
+		return fmt.Errorf("Cannot marshal null Point")
+	}
+	enc.WriteInt32(self.X)
+	enc.WriteInt32(self.Y)
+	return enc.Error()
+}
+
+// UnmarshalTBin is defined for TBin decoding of a Point without reflection
+func (self *Point) UnmarshalTBin(dec *tbin.Decoder) error {
+	if ok, err := dec.ExpectType(tbinPointSignature, self); !ok {
+		return err
+	}
+	return self.readTBin(dec)
+}
+
+func (self *Point) readTBin(dec *tbin.Decoder) error {
+	self.X = dec.ReadInt32()
+	self.Y = dec.ReadInt32()
+	return dec.Error()
+}
+
+// Circle -
+type Circle struct {
+	Center *Point `json:"center"`
+	Radius int32  `json:"radius"`
+}
+
+// NewCircle - creates an initialized Circle instance, returns a pointer to it
+func NewCircle(init ...*Circle) *Circle {
+	var o *Circle
+	if len(init) == 1 {
+		o = init[0]
+	} else {
+		o = new(Circle)
+	}
+	return o.Init()
+}
+
+// Init - sets up the instance according to its default field values, if any
+func (self *Circle) Init() *Circle {
+	if self.Center == nil {
+		self.Center = NewPoint()
+	}
+	return self
+}
+
+type rawCircle Circle
+
+// UnmarshalJSON is defined for proper JSON decoding of a Circle
+func (self *Circle) UnmarshalJSON(b []byte) error {
+	var m rawCircle
+	err := json.Unmarshal(b, &m)
+	if err == nil {
+		o := Circle(m)
+		*self = *((&o).Init())
+		err = self.Validate()
+	}
+	return err
+}
+
+// Validate - checks for missing required fields, etc
+func (self *Circle) Validate() error {
+	if self.Center == nil {
+		return fmt.Errorf("Circle: Missing required field: center")
+	}
+	return nil
+}
+
+var tbinCircleSignature = tbin.Struct(
+	tbin.Field("center", tbinPointSignature, false),
+	tbin.Field("radius", tbin.Int32, false),
+)
+
+// MarshalTBin is defined for TBin encoding of a Circle without reflection
+func (self *Circle) MarshalTBin(enc *tbin.Encoder) error {
+	enc.WriteType(tbinCircleSignature)
+	return self.writeTBin(enc)
+}
+
+func (self *Circle) writeTBin(enc *tbin.Encoder) error {
+	if self == nil {
+		return fmt.Errorf("Cannot marshal null Circle")
+	}
+	if err := self.Center.writeTBin(enc); err != nil {
+		return err
+	}
+	enc.WriteInt32(self.Radius)
+	return enc.Error()
+}
+
+// UnmarshalTBin is defined for TBin decoding of a Circle without reflection
+func (self *Circle) UnmarshalTBin(dec *tbin.Decoder) error {
+	if ok, err := dec.ExpectType(tbinCircleSignature, self); !ok {
+		return err
+	}
+	return self.readTBin(dec)
+}
+
+func (self *Circle) readTBin(dec *tbin.Decoder) error {
+	self.Center = new(Point)
+	if err := self.Center.readTBin(dec); err != nil {
+		return err
+	}
+	self.Radius = dec.ReadInt32()
+	return dec.Error()
+}
+
+// Color -
+type Color int
+
+// Color constants
+const (
+	_ Color = iota
+	RED
+	GREEN
+	BLUE
+)
+
+var namesColor = []string{
+	RED:   "RED",
+	GREEN: "GREEN",
+	BLUE:  "BLUE",
+}
+
+// NewColor - return a string representation of the enum
+func NewColor(init ...interface{}) Color {
+	if len(init) == 1 {
+		switch v := init[0].(type) {
+		case Color:
+			return v
+		case int:
+			return Color(v)
+		case int32:
+			return Color(v)
+		case string:
+			for i, s := range namesColor {
+				if s == v {
+					return Color(i)
+				}
+			}
+		default:
+			panic("Bad init value for Color enum")
+		}
+	}
+	return Color(0) //default to the first enum value
+}
+
+// String - return a string representation of the enum
+func (e Color) String() string {
+	return namesColor[e]
+}
+
+// SymbolSet - return an array of all valid string representations (symbols) of the enum
+func (e Color) SymbolSet() []string {
+	return namesColor
+}
+
+// MarshalJSON is defined for proper JSON encoding of a Color
+func (e Color) MarshalJSON() ([]byte, error) {
+	return json.Marshal(e.String())
+}
+
+// UnmarshalJSON is defined for proper JSON decoding of a Color
+func (e *Color) UnmarshalJSON(b []byte) error {
+	var j string
+	err := json.Unmarshal(b, &j)
+	if err == nil {
+		s := string(j)
+		for v, s2 := range namesColor {
+			if s == s2 {
+				*e = Color(v)
+				return nil
+			}
+		}
+		err = fmt.Errorf("Bad enum symbol for type Color: %s", s)
+	}
+	return err
+}
+
+var tbinColorSignature = tbin.Enum("RED", "GREEN", "BLUE")
+
+// MarshalTBin is defined for TBin encoding of a Color without reflection
+func (e *Color) MarshalTBin(enc *tbin.Encoder) error {
+	enc.WriteType(tbinColorSignature)
+	return enc.WriteInt32(int32(*e))
+}
+
+// UnmarshalTBin is defined for TBin decoding of a Color without reflection
+func (e *Color) UnmarshalTBin(dec *tbin.Decoder) error {
+	if ok, err := dec.ExpectType(tbinColorSignature, e); !ok {
+		return err
+	}
+	*e = Color(dec.ReadInt32())
+	return dec.Error()
+}
+
+// Rect -
+type Rect struct {
+	Corner *Point `json:"corner"`
+	Width  int32  `json:"width"`
+	Height int32  `json:"height"`
+}
+
+// NewRect - creates an initialized Rect instance, returns a pointer to it
+func NewRect(init ...*Rect) *Rect {
+	var o *Rect
+	if len(init) == 1 {
+		o = init[0]
+	} else {
+		o = new(Rect)
+	}
+	return o.Init()
+}
+
+// Init - sets up the instance according to its default field values, if any
+func (self *Rect) Init() *Rect {
+	if self.Corner == nil {
+		self.Corner = NewPoint()
+	}
+	return self
+}
+
+type rawRect Rect
+
+// UnmarshalJSON is defined for proper JSON decoding of a Rect
+func (self *Rect) UnmarshalJSON(b []byte) error {
+	var m rawRect
+	err := json.Unmarshal(b, &m)
+	if err == nil {
+		o := Rect(m)
+		*self = *((&o).Init())
+		err = self.Validate()
+	}
+	return err
+}
+
+// Validate - checks for missing required fields, etc
+func (self *Rect) Validate() error {
+	if self.Corner == nil {
+		return fmt.Errorf("Rect: Missing required field: corner")
+	}
+	return nil
+}
+
+var tbinRectSignature = tbin.Struct(
+	tbin.Field("corner", tbinPointSignature, false),
+	tbin.Field("width", tbin.Int32, false),
+	tbin.Field("height", tbin.Int32, false),
+)
+
+// MarshalTBin is defined for TBin encoding of a Rect without reflection
+func (self *Rect) MarshalTBin(enc *tbin.Encoder) error {
+	enc.WriteType(tbinRectSignature)
+	return self.writeTBin(enc)
+}
+
+func (self *Rect) writeTBin(enc *tbin.Encoder) error {
+	if self == nil {
+		return fmt.Errorf("Cannot marshal null Rect")
+	}
+	if err := self.Corner.writeTBin(enc); err != nil {
+		return err
+	}
+	enc.WriteInt32(self.Width)
+	enc.WriteInt32(self.Height)
+	return enc.Error()
+}
+
+// UnmarshalTBin is defined for TBin decoding of a Rect without reflection
+func (self *Rect) UnmarshalTBin(dec *tbin.Decoder) error {
+	if ok, err := dec.ExpectType(tbinRectSignature, self); !ok {
+		return err
+	}
+	return self.readTBin(dec)
+}
+
+func (self *Rect) readTBin(dec *tbin.Decoder) error {
+	self.Corner = new(Point)
+	if err := self.Corner.readTBin(dec); err != nil {
+		return err
+	}
+	self.Width = dec.ReadInt32()
+	self.Height = dec.ReadInt32()
+	return dec.Error()
+}
+
+// ShapeVariantTag - generated to support Shape
+type ShapeVariantTag int
+
+// Supporting constants
+const (
+	_ ShapeVariantTag = iota
+	ShapeVariantCircle
+	ShapeVariantColor
+	ShapeVariantRect
+)
+
+// Shape -
+type Shape struct {
+	Variant ShapeVariantTag `json:"-" rdl:"union"`
+	Circle  *Circle         `json:"Circle,omitempty"`
+	Color   *Color          `json:"Color,omitempty"`
+	Rect    *Rect           `json:"Rect,omitempty"`
+}
+
+func (u Shape) String() string {
+	switch u.Variant {
+	case ShapeVariantCircle:
+		return fmt.Sprintf("%v", u.Circle)
+	case ShapeVariantColor:
+		return fmt.Sprintf("%v", u.Color)
+	case ShapeVariantRect:
+		return fmt.Sprintf("%v", u.Rect)
+	default:
+		return "<Shape uninitialized>"
+	}
+}
+
+// Validate for Shape
+func (p *Shape) Validate() error {
+	if p.Circle != nil {
+		p.Variant = ShapeVariantCircle
+	} else if p.Color != nil {
+		p.Variant = ShapeVariantColor
+	} else if p.Rect != nil {
+		p.Variant = ShapeVariantRect
+	} else {
+		return fmt.Errorf("Shape: Missing required variant")
+	}
+	return nil
+}
+
+type rawShape Shape
+
+// UnmarshalJSON for Shape
+func (p *Shape) UnmarshalJSON(b []byte) error {
+	var tmp rawShape
+	if err := json.Unmarshal(b, &tmp); err != nil {
+		return err
+	}
+	*p = Shape(tmp)
+	return p.Validate()
+}
+
+var tbinShapeSignature = tbin.Union(tbinCircleSignature, tbinColorSignature, tbinRectSignature)
+
+// MarshalTBin is defined for TBin encoding of a Shape without reflection
+func (p *Shape) MarshalTBin(enc *tbin.Encoder) error {
+	enc.WriteType(tbinShapeSignature)
+	return p.writeTBin(enc)
+}
+
+func (p *Shape) writeTBin(enc *tbin.Encoder) error {
+	if p == nil {
+		return fmt.Errorf("Cannot marshal null Shape")
+	}
+	enc.WriteUnsigned(int(p.Variant))
+	switch p.Variant {
+	case ShapeVariantCircle:
+		if err := p.Circle.writeTBin(enc); err != nil {
+			return err
+		}
+	case ShapeVariantColor:
+		if p.Color == nil {
+			return fmt.Errorf("Cannot marshal null variant Color of Shape")
+		}
+		enc.WriteInt32(int32(*p.Color))
+	case ShapeVariantRect:
+		if err := p.Rect.writeTBin(enc); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("Cannot marshal uninitialized union type Shape")
+	}
+	return enc.Error()
+}
+
+// UnmarshalTBin is defined for TBin decoding of a Shape without reflection
+func (p *Shape) UnmarshalTBin(dec *tbin.Decoder) error {
+	if ok, err := dec.ExpectType(tbinShapeSignature, p); !ok {
+		return err
+	}
+	return p.readTBin(dec)
+}
+
+func (p *Shape) readTBin(dec *tbin.Decoder) error {
+	p.Variant = ShapeVariantTag(dec.ReadUnsigned())
+	switch p.Variant {
+	case ShapeVariantCircle:
+		p.Circle = new(Circle)
+		if err := p.Circle.readTBin(dec); err != nil {
+			return err
+		}
+	case ShapeVariantColor:
+		var v Color
+		v = Color(dec.ReadInt32())
+		p.Color = &v
+	case ShapeVariantRect:
+		p.Rect = new(Rect)
+		if err := p.Rect.readTBin(dec); err != nil {
+			return err
+		}
+	default:
+		if dec.Error() == nil {
+			return fmt.Errorf("Bad variant %d for union type Shape", p.Variant)
+		}
+	}
+	return dec.Error()
+}
+
+// Drawing -
+type Drawing struct {
+	Shapes     []*Shape         `json:"shapes"`
+	Focus      *Shape           `json:"focus,omitempty" rdl:"optional"`
+	Legend     map[string]Color `json:"legend,omitempty" rdl:"optional"`
+	Background *Color           `json:"background,omitempty" rdl:"optional"`
+	Zoom       *int32           `json:"zoom,omitempty" rdl:"optional"`
+	Created    *rdl.Timestamp   `json:"created,omitempty" rdl:"optional"`
+	Icon       []byte           `json:"icon,omitempty" rdl:"optional"`
+	Extra      interface{}      `json:"extra,omitempty" rdl:"optional"`
+}
+
+// NewDrawing - creates an initialized Drawing instance, returns a pointer to it
+func NewDrawing(init ...*Drawing) *Drawing {
+	var o *Drawing
+	if len(init) == 1 {
+		o = init[0]
+	} else {
+		o = new(Drawing)
+	}
+	return o.Init()
+}
+
+// Init - sets up the instance according to its default field values, if any
+func (self *Drawing) Init() *Drawing {
+	if self.Shapes == nil {
+		self.Shapes = make([]*Shape, 0)
+	}
+	return self
+}
+
+type rawDrawing Drawing
+
+// UnmarshalJSON is defined for proper JSON decoding of a Drawing
+func (self *Drawing) UnmarshalJSON(b []byte) error {
+	var m rawDrawing
+	err := json.Unmarshal(b, &m)
+	if err == nil {
+		o := Drawing(m)
+		*self = *((&o).Init())
+		err = self.Validate()
+	}
+	return err
+}
+
+// Validate - checks for missing required fields, etc
+func (self *Drawing) Validate() error {
+	if self.Shapes == nil {
+		return fmt.Errorf("Drawing: Missing required field: shapes")
+	}
+	return nil
+}
+
+var tbinDrawingLegendSignature = tbin.Map(tbin.String, tbinColorSignature)
+
+var tbinDrawingSignature = tbin.Struct(
+	tbin.Field("shapes", tbin.Array(tbinShapeSignature), false),
+	tbin.Field("focus", tbinShapeSignature, true),
+	tbin.Field("legend", tbin.Map(tbin.String, tbinColorSignature), true),
+	tbin.Field("background", tbinColorSignature, true),
+	tbin.Field("zoom", tbin.Int32, true),
+	tbin.Field("created", tbin.Timestamp, true),
+	tbin.Field("icon", tbin.Array(tbin.Int8), true),
+	tbin.Field("extra", tbin.Any, true),
+)
+
+// MarshalTBin is defined for TBin encoding of a Drawing without reflection
+func (self *Drawing) MarshalTBin(enc *tbin.Encoder) error {
+	enc.WriteType(tbinDrawingSignature)
+	return self.writeTBin(enc)
+}
+
+func (self *Drawing) writeTBin(enc *tbin.Encoder) error {
+	if self == nil {
+		return fmt.Errorf("Cannot marshal null Drawing")
+	}
+	enc.WriteSize(len(self.Shapes))
+	for _, item1 := range self.Shapes {
+		if err := item1.writeTBin(enc); err != nil {
+			return err
+		}
+	}
+	if self.Focus == nil {
+		enc.EncodeNull()
+	} else if err := self.Focus.MarshalTBin(enc); err != nil {
+		return err
+	}
+	if self.Legend == nil {
+		enc.EncodeNull()
+	} else {
+		enc.WriteType(tbinDrawingLegendSignature)
+		enc.WriteSize(len(self.Legend))
+		for k1, v1 := range self.Legend {
+			enc.WriteString(k1)
+			enc.WriteInt32(int32(v1))
+		}
+	}
+	if self.Background == nil {
+		enc.EncodeNull()
+	} else if err := self.Background.MarshalTBin(enc); err != nil {
+		return err
+	}
+	if self.Zoom == nil {
+		enc.EncodeNull()
+	} else {
+		enc.EncodeInt32(*self.Zoom)
+	}
+	if self.Created == nil {
+		enc.EncodeNull()
+	} else {
+		enc.EncodeTimestamp(*self.Created)
+	}
+	if self.Icon == nil {
+		enc.EncodeNull()
+	} else {
+		enc.EncodeBytes(self.Icon)
+	}
+	if self.Extra == nil {
+		enc.EncodeNull()
+	} else if err := enc.Encode(self.Extra); err != nil {
+		return err
+	}
+	return enc.Error()
+}
+
+// UnmarshalTBin is defined for TBin decoding of a Drawing without reflection
+func (self *Drawing) UnmarshalTBin(dec *tbin.Decoder) error {
+	if ok, err := dec.ExpectType(tbinDrawingSignature, self); !ok {
+		return err
+	}
+	return self.readTBin(dec)
+}
+
+func (self *Drawing) readTBin(dec *tbin.Decoder) error {
+	if self.Shapes == nil {
+		self.Shapes = make([]*Shape, 0)
+	} else {
+		self.Shapes = self.Shapes[:0]
+	}
+	for i1, n1 := 0, dec.ReadSize(); i1 < n1 && dec.Error() == nil; i1++ {
+		var v1 *Shape
+		v1 = new(Shape)
+		if err := v1.readTBin(dec); err != nil {
+			return err
+		}
+		self.Shapes = append(self.Shapes, v1)
+	}
+	if !dec.ReadNull() {
+		self.Focus = new(Shape)
+		if err := self.Focus.UnmarshalTBin(dec); err != nil {
+			return err
+		}
+	}
+	if !dec.ReadNull() {
+		ok, err := dec.ExpectType(tbinDrawingLegendSignature, &self.Legend)
+		if err != nil {
+			return err
+		}
+		if ok {
+			if self.Legend == nil {
+				self.Legend = make(map[string]Color)
+			} else {
+				clear(self.Legend)
+			}
+			for i1, n1 := 0, dec.ReadSize(); i1 < n1 && dec.Error() == nil; i1++ {
+				var k1 string
+				k1 = dec.ReadString()
+				var v1 Color
+				v1 = Color(dec.ReadInt32())
+				self.Legend[k1] = v1
+			}
+		}
+	}
+	if !dec.ReadNull() {
+		self.Background = new(Color)
+		if err := self.Background.UnmarshalTBin(dec); err != nil {
+			return err
+		}
+	}
+	self.Zoom = dec.ReadOptionalInt32()
+	self.Created = dec.ReadOptionalTimestamp()
+	self.Icon = dec.ReadOptionalBytes()
+	if err := dec.Decode(&self.Extra); err != nil {
+		return err
+	}
+	return dec.Error()
+}
@@ -0,0 +1,69 @@
+//
+// Code generated by go generate DO NOT EDIT.
+//
+
+package shapes
+
+import (
+	"log"
+
+	rdl "github.com/ardielle/ardielle-go/rdl"
+)
+
+var schema *rdl.Schema
+
+func init() {
+	sb := rdl.NewSchemaBuilder("shapes")
+
+	tPoint := rdl.NewStructTypeBuilder("Struct", "Point")
+	tPoint.Field("x", "Int32", false, nil, "")
+	tPoint.Field("y", "Int32", false, nil, "")
+	sb.AddType(tPoint.Build())
+
+	tCircle := rdl.NewStructTypeBuilder("Struct", "Circle")
+	tCircle.Field("center", "Point", false, nil, "")
+	tCircle.Field("radius", "Int32", false, nil, "")
+	sb.AddType(tCircle.Build())
+
+	tColor := rdl.NewEnumTypeBuilder("Enum", "Color")
+	tColor.Element("RED", "")
+	tColor.Element("GREEN", "")
+	tColor.Element("BLUE", "")
+	sb.AddType(tColor.Build())
+
+	tRect := rdl.NewStructTypeBuilder("Struct", "Rect")
+	tRect.Field("corner", "Point", false, nil, "")
+	tRect.Field("width", "Int32", false, nil, "")
+	tRect.Field("height", "Int32", false, nil, "")
+	sb.AddType(tRect.Build())
+
+	tIcon := rdl.NewAliasTypeBuilder("Bytes", "Icon")
+	sb.AddType(tIcon.Build())
+
+	tShape := rdl.NewUnionTypeBuilder("Union", "Shape")
+	tShape.Variant("Circle")
+	tShape.Variant("Color")
+	tShape.Variant("Rect")
+	sb.AddType(tShape.Build())
+
+	tDrawing := rdl.NewStructTypeBuilder("Struct", "Drawing")
+	tDrawing.ArrayField("shapes", "Shape", false, "")
+	tDrawing.Field("focus", "Shape", true, nil, "")
+	tDrawing.MapField("legend", "String", "Color", true, "")
+	tDrawing.Field("background", "Color", true, nil, "")
+	tDrawing.Field("zoom", "Int32", true, nil, "")
+	tDrawing.Field("created", "Timestamp", true, nil, "")
+	tDrawing.Field("icon", "Icon", true, nil, "")
+	tDrawing.Field("extra", "Any", true, nil, "")
+	sb.AddType(tDrawing.Build())
+
+	var err error
+	schema, err = sb.BuildParanoid()
+	if err != nil {
+		log.Fatalf("rdl: schema build failed: %s", err)
+	}
+}
+
+func ShapesSchema() *rdl.Schema {
+	return schema
+}
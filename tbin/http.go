@@ -0,0 +1,196 @@
+// Copyright 2015 Yahoo Inc.
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package tbin
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ardielle/ardielle-go/rdl"
+)
+
+// The content types of the encodings the HTTP helpers negotiate between.
+const (
+	ContentTypeJSON = "application/json"
+	ContentTypeTBin = "application/tbin"
+)
+
+var supportedContentTypes = []string{ContentTypeJSON, ContentTypeTBin}
+
+// mediaRange is an element of an Accept header.
+type mediaRange struct {
+	mediaType string
+	q         float64
+}
+
+// parseAccept returns the media ranges of an Accept header, in order, including those with q=0 that
+// exclude the types they match.
+func parseAccept(accept string) []mediaRange {
+	var ranges []mediaRange
+	for _, s := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(s))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if s, ok := params["q"]; ok {
+			if f, err := strconv.ParseFloat(s, 64); err == nil {
+				q = f
+			}
+		}
+		ranges = append(ranges, mediaRange{mediaType, q})
+	}
+	return ranges
+}
+
+// specificity returns how specifically the range matches the content type: 3 for the type itself, 2 for
+// its type/*, 1 for */*, and 0 if it doesn't match.
+func (m mediaRange) specificity(contentType string) int {
+	switch {
+	case m.mediaType == "*/*":
+		return 1
+	case strings.HasSuffix(m.mediaType, "/*"):
+		if strings.HasPrefix(contentType, strings.TrimSuffix(m.mediaType, "*")) {
+			return 2
+		}
+	case m.mediaType == contentType:
+		return 3
+	}
+	return 0
+}
+
+// quality returns the q value of the content type, given by the most specific range that matches it as
+// RFC 7231 section 5.3.2 requires, and the position of that range. It is 0 if no range matches.
+func quality(ranges []mediaRange, contentType string) (float64, int) {
+	best, q, pos := 0, 0.0, len(ranges)
+	for i, m := range ranges {
+		if s := m.specificity(contentType); s > best {
+			best, q, pos = s, m.q, i
+		}
+	}
+	return q, pos
+}
+
+// allowedContentTypes returns the supported content types among those declared, in their order, or all of
+// them if there are no declared types.
+func allowedContentTypes(declared []string) []string {
+	if len(declared) == 0 {
+		return supportedContentTypes
+	}
+	var allowed []string
+	for _, s := range declared {
+		if mediaType, _, err := mime.ParseMediaType(s); err == nil {
+			for _, ct := range supportedContentTypes {
+				if mediaType == ct {
+					allowed = append(allowed, ct)
+				}
+			}
+		}
+	}
+	return allowed
+}
+
+// NegotiateResponse - return the content type of the response to the request: the one preferred by its
+// Accept header among those the resource produces, JSON and TBin if it declares none. The q value of a type
+// is given by the most specific media range that matches it, so that "application/json;q=0, */*" excludes
+// JSON. The first allowed type is used without an Accept header. If none is acceptable, the error is a 406
+// rdl.ResourceError. The resource may be nil.
+func NegotiateResponse(req *http.Request, r *rdl.Resource) (string, error) {
+	var produces []string
+	if r != nil {
+		produces = r.Produces
+	}
+	allowed := allowedContentTypes(produces)
+	accept := req.Header.Values("Accept")
+	if len(accept) == 0 {
+		if len(allowed) > 0 {
+			return allowed[0], nil
+		}
+	} else {
+		//the highest q value wins, then the type the client lists first, then the first allowed type
+		ranges := parseAccept(strings.Join(accept, ","))
+		best, bestQ, bestPos := "", 0.0, 0
+		for _, ct := range allowed {
+			if q, pos := quality(ranges, ct); q > bestQ || (q == bestQ && q > 0 && pos < bestPos) {
+				best, bestQ, bestPos = ct, q, pos
+			}
+		}
+		if best != "" {
+			return best, nil
+		}
+	}
+	return "", &rdl.ResourceError{Code: http.StatusNotAcceptable, Message: "Not Acceptable, available: " + strings.Join(allowed, ", ")}
+}
+
+// Response - write the data with the status code, encoded as negotiated by NegotiateResponse. If no
+// encoding is acceptable, a 406 error is written as JSON instead. Like rdl.JSONResponse, there is no body
+// for 204 and 304, and nil data is written as a server error.
+func Response(w http.ResponseWriter, req *http.Request, r *rdl.Resource, code int, data interface{}) {
+	w.Header().Add("Vary", "Accept")
+	contentType, err := NegotiateResponse(req, r)
+	if err != nil {
+		rdl.JSONResponse(w, http.StatusNotAcceptable, err)
+		return
+	}
+	if contentType == ContentTypeJSON {
+		rdl.JSONResponse(w, code, data)
+		return
+	}
+	var b []byte
+	switch code {
+	case http.StatusNoContent, http.StatusNotModified:
+		/* no body */
+	default:
+		if data == nil {
+			data = rdl.ResourceError{Code: code, Message: "Server Error"}
+		}
+		if b, err = Marshal(data); err != nil {
+			code = http.StatusInternalServerError
+			b, _ = Marshal(rdl.ResourceError{Code: code, Message: "Server Error"})
+		}
+	}
+	w.Header().Set("Content-Type", ContentTypeTBin)
+	w.WriteHeader(code)
+	w.Write(b)
+}
+
+// DecodeRequest - decode the request body into data according to its Content-Type, JSON if it has none.
+// The content type must be one the resource consumes, JSON or TBin if it declares none, otherwise the error
+// is a 415 rdl.ResourceError. A body that can't be decoded is a 400 rdl.ResourceError. TBin bodies are
+// decoded with the DefaultDecoderOptions limits. The resource may be nil.
+func DecodeRequest(req *http.Request, r *rdl.Resource, data interface{}) error {
+	contentType := ContentTypeJSON
+	if s := req.Header.Get("Content-Type"); s != "" {
+		mediaType, _, err := mime.ParseMediaType(s)
+		if err != nil {
+			return &rdl.ResourceError{Code: http.StatusUnsupportedMediaType, Message: "Bad Content-Type: " + s}
+		}
+		contentType = mediaType
+	}
+	var consumes []string
+	if r != nil {
+		consumes = r.Consumes
+	}
+	allowed := allowedContentTypes(consumes)
+	supported := false
+	for _, ct := range allowed {
+		supported = supported || ct == contentType
+	}
+	if !supported {
+		return &rdl.ResourceError{Code: http.StatusUnsupportedMediaType, Message: "Unsupported Media Type " + contentType + ", expected: " + strings.Join(allowed, ", ")}
+	}
+	var err error
+	if contentType == ContentTypeTBin {
+		err = NewDecoderWithOptions(req.Body, DefaultDecoderOptions).Decode(data)
+	} else {
+		err = json.NewDecoder(req.Body).Decode(data)
+	}
+	if err != nil {
+		return &rdl.ResourceError{Code: http.StatusBadRequest, Message: "Bad request body: " + err.Error()}
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+// Copyright 2015 Yahoo Inc.
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package tbin
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ardielle/ardielle-go/rdl"
+)
+
+func TestNegotiateResponse(test *testing.T) {
+	tbinOnly := &rdl.Resource{Produces: []string{"application/tbin"}}
+	textOnly := &rdl.Resource{Produces: []string{"text/plain"}}
+	for _, c := range []struct {
+		accept   string
+		resource *rdl.Resource
+		expected string
+	}{
+		{"", nil, ContentTypeJSON},
+		{"application/tbin", nil, ContentTypeTBin},
+		{"application/json;q=0.5, application/tbin", nil, ContentTypeTBin},
+		{"application/tbin;q=0.1, application/*", nil, ContentTypeJSON},
+		{"text/html, */*;q=0.1", nil, ContentTypeJSON},
+		{"application/tbin;q=0", nil, ""},
+		{"text/html", nil, ""},
+		{"application/json;q=0, */*", nil, ContentTypeTBin},
+		{"application/*;q=0, application/json", nil, ContentTypeJSON},
+		{"application/tbin, application/json", nil, ContentTypeTBin},
+		{"application/json;q=0, application/tbin;q=0, */*", nil, ""},
+		{"", tbinOnly, ContentTypeTBin},
+		{"application/json, */*;q=0.1", tbinOnly, ContentTypeTBin},
+		{"application/json", tbinOnly, ""},
+		{"", textOnly, ""},
+	} {
+		req := httptest.NewRequest("GET", "/", nil)
+		if c.accept != "" {
+			req.Header.Set("Accept", c.accept)
+		}
+		ct, err := NegotiateResponse(req, c.resource)
+		if ct != c.expected {
+			test.Errorf("Accept %q: expected %q, got %q", c.accept, c.expected, ct)
+		}
+		if c.expected == "" {
+			if e, ok := err.(*rdl.ResourceError); !ok || e.Code != 406 {
+				test.Errorf("Accept %q: expected a 406 error, got %v", c.accept, err)
+			}
+		}
+	}
+}
+
+func TestResponse(test *testing.T) {
+	point := Point{X: 1, Y: 2}
+	for _, accept := range []string{ContentTypeJSON, ContentTypeTBin} {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Accept", accept)
+		w := httptest.NewRecorder()
+		Response(w, req, nil, 201, point)
+		if w.Code != 201 || w.Header().Get("Content-Type") != accept || w.Header().Get("Vary") != "Accept" {
+			test.Fatalf("Unexpected response: %d %v", w.Code, w.Header())
+		}
+		var decoded Point
+		var err error
+		if accept == ContentTypeTBin {
+			err = Unmarshal(w.Body.Bytes(), &decoded)
+		} else {
+			err = json.Unmarshal(w.Body.Bytes(), &decoded)
+		}
+		if err != nil || decoded != point {
+			test.Errorf("Cannot decode the %s response: %v, %v", accept, decoded, err)
+		}
+
+		w = httptest.NewRecorder()
+		Response(w, req, nil, 304, point)
+		if w.Code != 304 || w.Body.Len() != 0 {
+			test.Errorf("Expected no body for 304, got %x", w.Body)
+		}
+	}
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept", "text/html")
+	w := httptest.NewRecorder()
+	Response(w, req, nil, 200, point)
+	if w.Code != 406 || w.Header().Get("Content-Type") != ContentTypeJSON {
+		test.Errorf("Expected a 406 JSON error, got %d %v", w.Code, w.Header())
+	}
+}
+
+func TestDecodeRequest(test *testing.T) {
+	point := Point{X: 3, Y: 4}
+	tdata, _ := Marshal(point)
+	jdata, _ := json.Marshal(point)
+	jsonOnly := &rdl.Resource{Consumes: []string{"application/json"}}
+	for _, c := range []struct {
+		contentType string
+		body        []byte
+		resource    *rdl.Resource
+		code        int
+	}{
+		{"", jdata, nil, 0},
+		{"application/json; charset=utf-8", jdata, nil, 0},
+		{ContentTypeTBin, tdata, nil, 0},
+		{ContentTypeTBin, tdata, jsonOnly, 415},
+		{"text/plain", jdata, nil, 415},
+		{ContentTypeTBin, jdata, nil, 400},
+		{ContentTypeJSON, []byte("{"), nil, 400},
+	} {
+		req := httptest.NewRequest("POST", "/", bytes.NewReader(c.body))
+		if c.contentType != "" {
+			req.Header.Set("Content-Type", c.contentType)
+		}
+		var decoded Point
+		err := DecodeRequest(req, c.resource, &decoded)
+		if c.code == 0 {
+			if err != nil || decoded != point {
+				test.Errorf("%s: cannot decode the request: %v, %v", c.contentType, decoded, err)
+			}
+		} else if e, ok := err.(*rdl.ResourceError); !ok || e.Code != c.code || !strings.Contains(e.Message, " ") {
+			test.Errorf("%s: expected a %d error, got %v", c.contentType, c.code, err)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright 2015 Yahoo Inc.
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package tbin
+
+import (
+	"fmt"
+	"io"
+)
+
+// DecoderOptions - limits on the resources used to decode a TBin stream, for untrusted input. A zero
+// value means no limit. Without limits, memory is still only allocated as the data is actually read,
+// but a large enough stream can use any amount of it.
+type DecoderOptions struct {
+	MaxBytes          int64 // the total number of bytes read from the input
+	MaxStringLength   int   // the length of a string, bytes or symbol name
+	MaxCollectionSize int   // the number of elements of an array, entries of a map, or fields of a struct
+	MaxDepth          int   // the nesting depth of values and type definitions
+	MaxUserTags       int   // the number of type definitions
+}
+
+// DefaultDecoderOptions - reasonable limits for decoding untrusted input.
+var DefaultDecoderOptions = DecoderOptions{
+	MaxBytes:          64 << 20,
+	MaxStringLength:   16 << 20,
+	MaxCollectionSize: 1 << 20,
+	MaxDepth:          100,
+	MaxUserTags:       1000,
+}
+
+// LimitError - the error returned when a stream exceeds one of the DecoderOptions limits.
+type LimitError struct {
+	Limit string // the name of the option, e.g. "MaxDepth"
+	Max   int64
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("TBin decoding limit exceeded: %s = %d", e.Limit, e.Max)
+}
+
+// NewDecoderWithOptions - create and return a new Decoder, enforcing the limits of the options.
+func NewDecoderWithOptions(r io.Reader, options DecoderOptions) *Decoder {
+	decoder := &Decoder{options: options}
+	decoder.Reset(r)
+	return decoder
+}
+
+// UnmarshalWithOptions - decode the TBin byte array into the specified target entity, as Unmarshal
+// does, enforcing the limits of the options.
+func UnmarshalWithOptions(b []byte, data interface{}, options DecoderOptions) error {
+	decoder := &Decoder{options: options}
+	decoder.ResetBytes(b)
+	return decoder.Decode(data)
+}
+
+// limitReader fails with a LimitError once more than the allowed bytes are read.
+type limitReader struct {
+	r         io.Reader
+	remaining int64
+	max       int64
+}
+
+func (l *limitReader) Read(p []byte) (int, error) {
+	if l.remaining <= 0 {
+		//only an error if there is more data
+		var b [1]byte
+		if n, err := l.r.Read(b[:]); n == 0 {
+			return 0, err
+		}
+		return 0, &LimitError{Limit: "MaxBytes", Max: l.max}
+	}
+	if int64(len(p)) > l.remaining {
+		p = p[:l.remaining]
+	}
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	return n, err
+}
+
+// enter checks the depth limit before decoding a nested value or type, leave must be called after it.
+func (d *Decoder) enter() bool {
+	d.depth++
+	if d.options.MaxDepth > 0 && d.depth > d.options.MaxDepth && d.err == nil {
+		d.err = &LimitError{Limit: "MaxDepth", Max: int64(d.options.MaxDepth)}
+	}
+	return d.err == nil
+}
+
+func (d *Decoder) leave() {
+	d.depth--
+}
+
+// checkLength checks the length of a string or bytes.
+func (d *Decoder) checkLength(n uint) bool {
+	if max := d.options.MaxStringLength; max > 0 && n > uint(max) && d.err == nil {
+		d.err = &LimitError{Limit: "MaxStringLength", Max: int64(max)}
+	}
+	return d.err == nil
+}
+
+// capHint bounds the capacity allocated ahead for a collection, so that it is only allocated as its
+// elements are actually read.
+func capHint(n int) int {
+	if n > 1024 {
+		return 1024
+	}
+	return n
+}
+
+// checkEmptyElements checks an array of values that take no bytes in the stream, such as empty structs.
+// The size of the stream doesn't bound their number, so they are counted against MaxCollectionSize
+// for the whole stream, rather than for each array.
+func (d *Decoder) checkEmptyElements(items *Signature, n int) bool {
+	if max := d.options.MaxCollectionSize; max > 0 && n > 0 && d.err == nil && isEmptyType(items) {
+		d.emptyElements += n
+		if d.emptyElements > max {
+			d.err = &LimitError{Limit: "MaxCollectionSize", Max: int64(max)}
+		}
+	}
+	return d.err == nil
+}
+
+// isEmptyType reports whether packed values of the type take no bytes.
+func isEmptyType(sig *Signature) bool {
+	if sig.Tag != StructTag || sig.Fields == nil {
+		return false
+	}
+	for _, f := range sig.Fields {
+		if !isEmptyType(f.Type) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,115 @@
+// Copyright 2015 Yahoo Inc.
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package tbin
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func expectLimit(test *testing.T, err error, limit string) {
+	var lerr *LimitError
+	if !errors.As(err, &lerr) {
+		test.Errorf("Expected a LimitError for %s, got %v", limit, err)
+	} else if lerr.Limit != limit {
+		test.Errorf("Expected a LimitError for %s, got %v", limit, lerr)
+	}
+}
+
+func TestDecoderLimits(test *testing.T) {
+	tdata := readTestFile(test, "test.tbin")
+	var line Polyline
+	if err := UnmarshalWithOptions(tdata, &line, DefaultDecoderOptions); err != nil || len(line.Points) != 13 {
+		test.Errorf("Cannot decode within the default limits: %v", err)
+	}
+
+	var data interface{}
+	expectLimit(test, UnmarshalWithOptions(tdata, &data, DecoderOptions{MaxBytes: 20}), "MaxBytes")
+	if err := UnmarshalWithOptions(tdata, &data, DecoderOptions{MaxBytes: int64(len(tdata))}); err != nil {
+		test.Errorf("Cannot decode with MaxBytes the size of the data: %v", err)
+	}
+	expectLimit(test, UnmarshalWithOptions(tdata, &line, DecoderOptions{MaxCollectionSize: 12}), "MaxCollectionSize")
+	expectLimit(test, UnmarshalWithOptions(tdata, &data, DecoderOptions{MaxUserTags: 2}), "MaxUserTags")
+	expectLimit(test, UnmarshalWithOptions(tdata, &data, DecoderOptions{MaxDepth: 2}), "MaxDepth")
+
+	tdata, _ = Marshal(strings.Repeat("x", 100))
+	expectLimit(test, UnmarshalWithOptions(tdata, &data, DecoderOptions{MaxStringLength: 99}), "MaxStringLength")
+
+	var nested interface{} = "x"
+	for i := 0; i < 10; i++ {
+		nested = []interface{}{nested}
+	}
+	tdata, _ = Marshal(nested)
+	expectLimit(test, UnmarshalWithOptions(tdata, &data, DecoderOptions{MaxDepth: 9}), "MaxDepth")
+	if err := UnmarshalWithOptions(tdata, &data, DecoderOptions{MaxDepth: 10}); err != nil {
+		test.Errorf("Cannot decode within the depth limit: %v", err)
+	}
+	dec := NewDecoderWithOptions(bytes.NewReader(tdata), DecoderOptions{MaxDepth: 9})
+	var err error
+	for err == nil {
+		_, err = dec.Next()
+	}
+	expectLimit(test, err, "MaxDepth")
+}
+
+func TestDecodeHugeSizes(test *testing.T) {
+	//sizes larger than the data fail without allocating them
+	for _, tdata := range [][]byte{
+		{CurVersionTag, BytesTag, 0xff, 0xff, 0xff, 0xff, 0x0f},
+		{CurVersionTag, ArrayTag, 0xff, 0xff, 0xff, 0xff, 0x0f, NullTag},
+		{CurVersionTag, 0x40, DefArrayTag, Int32Tag, 0x40, 0xff, 0xff, 0xff, 0xff, 0x0f, 0x02},
+	} {
+		var data interface{}
+		if err := Unmarshal(tdata, &data); err != io.ErrUnexpectedEOF && err != io.EOF {
+			test.Errorf("Expected an unexpected EOF decoding %x, got %v", tdata, err)
+		}
+		var ints []int32
+		if err := Unmarshal(tdata, &ints); err == nil {
+			test.Errorf("Expected an error decoding %x into a slice", tdata)
+		}
+	}
+}
+
+func fuzzSeeds(f *testing.F) {
+	files, _ := filepath.Glob("../testdata/*.tbin")
+	for _, file := range files {
+		tdata, err := os.ReadFile(file)
+		if err != nil {
+			f.Fatalf("Cannot read %s: %v", file, err)
+		}
+		f.Add(tdata)
+	}
+	tdata, _ := Marshal(polyline())
+	f.Add(tdata)
+}
+
+func FuzzUnmarshal(f *testing.F) {
+	fuzzSeeds(f)
+	f.Fuzz(func(test *testing.T, tdata []byte) {
+		var data interface{}
+		UnmarshalWithOptions(tdata, &data, DefaultDecoderOptions)
+		var line Polyline
+		UnmarshalWithOptions(tdata, &line, DefaultDecoderOptions)
+		var bt BigTest
+		UnmarshalWithOptions(tdata, &bt, DefaultDecoderOptions)
+	})
+}
+
+func FuzzNext(f *testing.F) {
+	fuzzSeeds(f)
+	f.Fuzz(func(test *testing.T, tdata []byte) {
+		dec := NewDecoderWithOptions(bytes.NewReader(tdata), DefaultDecoderOptions)
+		for i := 0; i < 10000; i++ {
+			if _, err := dec.Next(); err != nil {
+				break
+			}
+		}
+		Dump(bytes.NewReader(tdata), io.Discard)
+	})
+}
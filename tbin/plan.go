@@ -0,0 +1,310 @@
+// Copyright 2015 Yahoo Inc.
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package tbin
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/ardielle/ardielle-go/rdl"
+)
+
+// typePlan is the reflection of a Go type, compiled once and shared by all encoders and decoders.
+// Plans are immutable once compiled, so they are safe for concurrent use.
+type typePlan struct {
+	sig          *Signature
+	kind         reflect.Kind
+	marshallable bool
+	fields       []fieldPlan    // the encoded fields of a struct, or the variants of a union after the tag
+	fieldIndex   map[string]int // the field index of each encoded field name, for decoding
+	elem         *typePlan      // the plan of the element type of a slice, map or pointer
+	key          *typePlan      // the plan of the key type of a map
+	encode       func(enc *Encoder, v reflect.Value, useMarshallable bool) error
+	err          error // why values of the type cannot be encoded, i.e. it is recursive
+}
+
+type fieldPlan struct {
+	index    int
+	name     string
+	optional bool
+	pointer  bool
+	plan     *typePlan // the plan of the field type, or of the pointed to type for a pointer
+}
+
+var typePlans sync.Map // reflect.Type -> *typePlan
+
+var marshallableType = reflect.TypeOf((*TBinMarshallable)(nil)).Elem()
+
+func init() {
+	//the shared signatures cache their string form before they can be used concurrently
+	for _, sig := range []*Signature{Null, Bool, Int8, Int16, Int32, Int64, Float32, Float64, Bytes, String, Timestamp, Symbol, UUID, Any} {
+		_ = sig.String()
+	}
+}
+
+// planOf returns the plan for the type, compiling it the first time the type is seen.
+func planOf(t reflect.Type) *typePlan {
+	return planWithin(t, nil)
+}
+
+// planWithin returns the plan for the type, compiled within the plans of the types being compiled. A type
+// that is among them is recursive, and has no finite signature: its plan, and the plans of the types that
+// refer to it, fail to encode. They can still be decoded.
+func planWithin(t reflect.Type, compiling []reflect.Type) *typePlan {
+	if p, ok := typePlans.Load(t); ok {
+		return p.(*typePlan)
+	}
+	for _, c := range compiling {
+		if c == t {
+			return failedPlan(t, fmt.Errorf("Cannot encode recursive type %v", t))
+		}
+	}
+	p := compilePlan(t, append(compiling, t))
+	actual, _ := typePlans.LoadOrStore(t, p)
+	return actual.(*typePlan)
+}
+
+// failedPlan returns the plan of a type whose values cannot be encoded, for the error.
+func failedPlan(t reflect.Type, err error) *typePlan {
+	p := &typePlan{kind: t.Kind()}
+	p.fail(err)
+	return p
+}
+
+// fail makes the values of the planned type fail to encode with the error. The fields of a struct are
+// kept, to decode it.
+func (p *typePlan) fail(err error) {
+	p.sig, p.err = Any, err
+	p.encode = func(enc *Encoder, _ reflect.Value, _ bool) error {
+		if enc.err == nil {
+			enc.err = err
+		}
+		return enc.err
+	}
+}
+
+func compilePlan(t reflect.Type, compiling []reflect.Type) *typePlan {
+	p := &typePlan{kind: t.Kind(), marshallable: t.Implements(marshallableType)}
+	switch t.String() {
+	case "rdl.UUID", "rdl.Timestamp", "rdl.Symbol", "rdl.Struct":
+		p.encode = encodeRDLValue
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		if p.encode != nil {
+			break
+		}
+		p.fields = structFields(t, compiling)
+		if isUnionType(t) {
+			p.encode = p.encodeUnion
+		} else {
+			p.fieldIndex = make(map[string]int, len(p.fields))
+			for _, f := range p.fields {
+				if _, ok := p.fieldIndex[f.name]; !ok {
+					p.fieldIndex[f.name] = f.index
+				}
+			}
+			p.encode = p.encodeStruct
+		}
+	case reflect.Slice:
+		p.elem = planWithin(t.Elem(), compiling)
+		if p.encode == nil {
+			p.encode = p.encodeSlice
+		}
+	case reflect.Map:
+		p.key, p.elem = planWithin(t.Key(), compiling), planWithin(t.Elem(), compiling)
+		if p.encode == nil {
+			p.encode = p.encodeMap
+		}
+	case reflect.Ptr:
+		p.elem = planWithin(t.Elem(), compiling)
+		p.encode = encodePointer
+	case reflect.Int8:
+		p.encode = func(enc *Encoder, v reflect.Value, _ bool) error { return enc.WriteInt8(int8(v.Int())) }
+	case reflect.Int16:
+		p.encode = func(enc *Encoder, v reflect.Value, _ bool) error { return enc.WriteInt16(int16(v.Int())) }
+	case reflect.Int, reflect.Int32:
+		p.encode = func(enc *Encoder, v reflect.Value, _ bool) error { return enc.WriteInt32(int32(v.Int())) }
+	case reflect.Int64:
+		p.encode = func(enc *Encoder, v reflect.Value, _ bool) error { return enc.WriteInt64(v.Int()) }
+	case reflect.Float32:
+		p.encode = func(enc *Encoder, v reflect.Value, _ bool) error { return enc.WriteFloat32(float32(v.Float())) }
+	case reflect.Float64:
+		p.encode = func(enc *Encoder, v reflect.Value, _ bool) error { return enc.WriteFloat64(v.Float()) }
+	case reflect.String:
+		if p.encode == nil {
+			p.encode = func(enc *Encoder, v reflect.Value, _ bool) error { return enc.WriteString(v.String()) }
+		}
+	case reflect.Bool:
+		p.encode = func(enc *Encoder, v reflect.Value, _ bool) error { return enc.WriteBool(v.Bool()) }
+	case reflect.Interface: //any type
+		p.encode = func(enc *Encoder, v reflect.Value, useMarshallable bool) error {
+			return enc.encodeData(v.Interface(), useMarshallable)
+		}
+	default:
+		p.encode = func(enc *Encoder, v reflect.Value, _ bool) error {
+			enc.err = fmt.Errorf("Cannot determine type signature for reflect kind: '%v'", v.Kind())
+			return enc.err
+		}
+	}
+	for _, sub := range append([]*typePlan{p.elem, p.key}, p.fieldPlans()...) {
+		if sub != nil && sub.err != nil {
+			p.fail(sub.err)
+			return p
+		}
+	}
+	p.sig = compileTypeSignature(t, p)
+	_ = p.sig.String() //computed once here, so that the shared signature is never modified
+	return p
+}
+
+// isUnionType reports whether the struct is an RDL union, its first field is the variant tag (`rdl:"union"`).
+func isUnionType(t reflect.Type) bool {
+	return t.NumField() > 0 && t.Field(0).Tag.Get("rdl") == "union"
+}
+
+func (p *typePlan) fieldPlans() []*typePlan {
+	plans := make([]*typePlan, len(p.fields))
+	for i, f := range p.fields {
+		plans[i] = f.plan
+	}
+	return plans
+}
+
+// structFields returns the plans of the encoded fields of the struct, named by their json tag.
+func structFields(t reflect.Type, compiling []reflect.Type) []fieldPlan {
+	var fields []fieldPlan
+	union := isUnionType(t)
+	for i := 0; i < t.NumField(); i++ {
+		if union && i == 0 {
+			continue
+		}
+		f := t.Field(i)
+		fn := f.Name
+		ftag := f.Tag.Get("json")
+		if ftag != "" {
+			if ftag == "-" {
+				//should never happen with rdl models.
+				continue
+			}
+			if n := strings.Split(ftag, ",")[0]; n != "" {
+				fn = n
+			}
+		}
+		fp := fieldPlan{index: i, name: fn, optional: f.Tag.Get("rdl") == "optional", plan: planWithin(f.Type, compiling)}
+		if f.Type.Kind() == reflect.Ptr && fp.plan.err == nil {
+			fp.pointer = true
+			fp.plan = fp.plan.elem
+		}
+		fields = append(fields, fp)
+	}
+	return fields
+}
+
+// lookupField returns the index of the struct field with the name, matched as reflectField always has,
+// without regard to case.
+func (p *typePlan) lookupField(name string) (int, bool) {
+	if i, ok := p.fieldIndex[name]; ok {
+		return i, true
+	}
+	for _, f := range p.fields {
+		if strings.EqualFold(name, f.name) {
+			return f.index, true
+		}
+	}
+	return 0, false
+}
+
+// encodePlanned encodes the value of the planned type, packed.
+func (enc *Encoder) encodePlanned(p *typePlan, v reflect.Value, useMarshallable bool) error {
+	if useMarshallable && p.marshallable && v.CanInterface() {
+		return v.Interface().(TBinMarshallable).MarshalTBin(enc) //burden on the app, but can be faster
+	}
+	return p.encode(enc, v, useMarshallable)
+}
+
+func encodeRDLValue(enc *Encoder, v reflect.Value, _ bool) error {
+	switch val := v.Interface().(type) {
+	case rdl.UUID:
+		return enc.WriteUUID(val)
+	case rdl.Timestamp:
+		return enc.WriteTimestamp(val)
+	case rdl.Symbol:
+		return enc.WriteSymbol(string(val))
+	}
+	//return enc.EncodeStruct(d, useMarshallable)
+	panic("rdl.Struct -> fix me")
+}
+
+func (p *typePlan) encodeUnion(enc *Encoder, v reflect.Value, useMarshallable bool) error {
+	nvar := int(v.Field(0).Int())
+	enc.WriteUnsigned(nvar)
+	//note: an uninitialized union has its tag set to zero. Emit nothing after the tag in that case.
+	if nvar > 0 {
+		enc.tagged = true //ensure the next WriteType doesn't actually do anything
+		return enc.encodeValue(v.Field(nvar), useMarshallable)
+	}
+	enc.err = fmt.Errorf("Cannot marshal uninitialized union type %s in %v", v.Type().Name(), v)
+	return enc.err
+}
+
+func (p *typePlan) encodeStruct(enc *Encoder, v reflect.Value, useMarshallable bool) error {
+	var err error
+	for _, fp := range p.fields {
+		f := v.Field(fp.index)
+		if fp.optional {
+			if IsZero(f) {
+				err = enc.EncodeNull()
+			} else {
+				err = enc.encodeData(f.Interface(), useMarshallable)
+			}
+		} else if fp.pointer {
+			if f.IsNil() {
+				enc.err = fmt.Errorf("Cannot marshal null pointer for required field %v in %v", v.Type().Field(fp.index).Name, f)
+				return enc.err
+			}
+			err = enc.encodePlanned(fp.plan, f.Elem(), useMarshallable)
+		} else {
+			err = enc.encodePlanned(fp.plan, f, useMarshallable)
+		}
+	}
+	return err
+}
+
+func (p *typePlan) encodeSlice(enc *Encoder, v reflect.Value, useMarshallable bool) error {
+	n := v.Len()
+	enc.WriteUnsigned(n)
+	for i := 0; i < n; i++ {
+		if p.elem.kind == reflect.Ptr {
+			enc.encodeValue(v.Index(i).Elem(), useMarshallable) //packed as the value it points to
+		} else {
+			enc.encodePlanned(p.elem, v.Index(i), useMarshallable)
+		}
+	}
+	return enc.err
+}
+
+func (p *typePlan) encodeMap(enc *Encoder, v reflect.Value, useMarshallable bool) error {
+	enc.WriteUnsigned(v.Len())
+	iter := v.MapRange()
+	for iter.Next() {
+		enc.encodePlanned(p.key, iter.Key(), useMarshallable)
+		if p.elem.kind == reflect.Ptr {
+			enc.encodeValue(iter.Value().Elem(), useMarshallable)
+		} else {
+			enc.encodePlanned(p.elem, iter.Value(), useMarshallable)
+		}
+	}
+	return enc.err
+}
+
+func encodePointer(enc *Encoder, v reflect.Value, useMarshallable bool) error {
+	//without context (of a field def, for example), we must assume "optional" for any pointer, because it can be nil
+	if v.IsNil() {
+		return enc.EncodeNull()
+	}
+	return enc.encode(v.Elem().Interface(), useMarshallable)
+}
@@ -0,0 +1,109 @@
+// Copyright 2015 Yahoo Inc.
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package tbin
+
+import (
+	"bytes"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/ardielle/ardielle-go/rdl"
+)
+
+type planTest struct {
+	Name    string            `json:"name"`
+	Count   int32             `json:"count"`
+	Origin  *Point            `json:"origin"`
+	Comment string            `json:"comment,omitempty" rdl:"optional"`
+	Ignored string            `json:"-"`
+	Tags    map[string]*Point `json:"tags"`
+	Points  []*Point          `json:"points"`
+}
+
+func TestPlanSignatures(test *testing.T) {
+	line := polyline()
+	if TypeSignature(line) != TypeSignature(line) {
+		test.Errorf("Expected the signature of a type to be computed once")
+	}
+	data := planTest{Name: "x", Count: 3, Origin: &Point{X: 5}, Ignored: "y", Tags: map[string]*Point{"a": {X: 1}}, Points: []*Point{{Y: 2}}}
+	expected := "Struct{name:String,count:Int32,origin:Struct{x:Int32,y:Int32},comment:String,tags:Map<String,Struct{x:Int32,y:Int32}>,points:Array<Struct{x:Int32,y:Int32}>}"
+	if sig := TypeSignature(data).String(); sig != expected {
+		test.Errorf("Unexpected signature: %s", sig)
+	}
+	tdata, err := Marshal(data)
+	if err != nil {
+		test.Fatalf("Cannot marshal: %v", err)
+	}
+	var decoded planTest
+	if err := Unmarshal(tdata, &decoded); err != nil {
+		test.Fatalf("Cannot unmarshal: %v", err)
+	}
+	data.Ignored = ""
+	if !reflect.DeepEqual(data, decoded) {
+		test.Errorf("Decoded data doesn't match: %+v", decoded)
+	}
+
+	//field names are matched without regard to case, as they always have been
+	enc := NewEncoder(nil)
+	enc.EncodeStruct(rdl.Struct{"NAME": "z", "Count": int32(4)}, true)
+	decoded = planTest{}
+	if err := Unmarshal(enc.Bytes(), &decoded); err != nil || decoded.Name != "z" || decoded.Count != 4 {
+		test.Errorf("Cannot unmarshal fields by name: %+v, %v", decoded, err)
+	}
+}
+
+func TestPlanConcurrency(test *testing.T) {
+	expected, _ := Marshal(polyline())
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				enc := NewEncoder(nil)
+				if err := enc.EncodeReflect(polyline()); err != nil || !bytes.Equal(enc.Bytes(), expected) {
+					test.Errorf("Unexpected concurrent encoding: %v", err)
+					return
+				}
+				var line Polyline
+				if err := NewDecoder(bytes.NewReader(expected)).DecodeReflect(reflect.ValueOf(&line).Elem()); err != nil || len(line.Points) != 13 {
+					test.Errorf("Unexpected concurrent decoding: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+type planNode struct {
+	Name     string      `json:"name"`
+	Children []*planNode `json:"children,omitempty" rdl:"optional"`
+}
+
+type planTree struct {
+	Root *planNode `json:"root"`
+}
+
+func TestPlanRecursive(test *testing.T) {
+	//recursive types have no finite signature, so their values, and values that refer to them, fail to encode
+	node := &planNode{Name: "a", Children: []*planNode{{Name: "b"}}}
+	for _, data := range []interface{}{node, planTree{Root: node}, []*planNode{node}} {
+		if _, err := Marshal(data); err == nil {
+			test.Errorf("Expected an error encoding the recursive %T", data)
+		}
+	}
+	if _, err := Marshal(planTest{Name: "x", Origin: &Point{}}); err != nil {
+		test.Errorf("Cannot marshal a type that is not recursive: %v", err)
+	}
+
+	//they are decoded as they always have been
+	enc := NewEncoder(nil)
+	enc.EncodeStruct(rdl.Struct{"name": "a", "children": []interface{}{rdl.Struct{"name": "b"}}}, true)
+	var decoded planNode
+	if err := Unmarshal(enc.Bytes(), &decoded); err != nil || decoded.Name != "a" || len(decoded.Children) != 1 || decoded.Children[0].Name != "b" {
+		test.Errorf("Cannot decode a recursive type: %+v, %v", decoded, err)
+	}
+}
@@ -0,0 +1,234 @@
+// Copyright 2015 Yahoo Inc.
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package tbin
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// A record stream is a TBin header followed by any number of records, each one a single TBin value
+// in a frame: "uvarint(len) byte[len] crc32(byte[len])", the checksum being 4 big endian bytes. The
+// records of a stream share their type definitions and symbols, as the values of a TBin session do,
+// so that each type is only defined once. The frames let a reader stop cleanly at the last complete
+// record of a truncated stream, and resume from there when more data is appended, as when following a
+// log file that is being written.
+
+// RecordWriter - write values as the records of a record stream, to an io.Writer such as a file or a
+// pipe. Records are buffered until Flush.
+type RecordWriter struct {
+	enc     *Encoder
+	out     io.Writer
+	buf     bytes.Buffer
+	started bool
+	err     error
+}
+
+// NewRecordWriter - create a RecordWriter writing to w, the stream header is written by the first Flush.
+func NewRecordWriter(w io.Writer) *RecordWriter {
+	return &RecordWriter{enc: NewEncoder(nil), out: w}
+}
+
+// start moves the stream header from the encoder to the output, once the version can no longer change.
+func (rw *RecordWriter) start() {
+	if !rw.started {
+		rw.buf.Write(rw.enc.buf.Next(1))
+		rw.started = true
+	}
+}
+
+// Encoder - return the encoder of the session, to write a record with its methods, e.g. with a
+// TBinMarshallable. Each record must be exactly one value, terminated by a call to EndRecord. The
+// version of the stream can be selected with its SetVersion, before the first record.
+func (rw *RecordWriter) Encoder() *Encoder {
+	return rw.enc
+}
+
+// Write - write the data as the next record.
+func (rw *RecordWriter) Write(data interface{}) error {
+	if rw.err != nil {
+		return rw.err
+	}
+	if err := rw.enc.Encode(data); err != nil {
+		rw.err = err
+		return err
+	}
+	return rw.EndRecord()
+}
+
+// EndRecord - frame the value written to the encoder since the previous record.
+func (rw *RecordWriter) EndRecord() error {
+	if rw.err == nil {
+		rw.err = rw.enc.Error()
+	}
+	if rw.err != nil {
+		return rw.err
+	}
+	rw.start()
+	payload := rw.enc.Bytes()
+	var hdr [binary.MaxVarintLen64]byte
+	rw.buf.Write(hdr[:binary.PutUvarint(hdr[:], uint64(len(payload)))])
+	rw.buf.Write(payload)
+	binary.BigEndian.PutUint32(hdr[:4], crc32.ChecksumIEEE(payload))
+	rw.buf.Write(hdr[:4])
+	rw.enc.buf.Reset()
+	return nil
+}
+
+// Flush - write the buffered records to the underlying writer. If it is buffered itself, it is
+// flushed too, so that the records reach their destination.
+func (rw *RecordWriter) Flush() error {
+	if rw.err != nil {
+		return rw.err
+	}
+	rw.start()
+	if rw.buf.Len() > 0 {
+		if _, err := rw.out.Write(rw.buf.Bytes()); err != nil {
+			rw.err = err
+			return err
+		}
+		rw.buf.Reset()
+	}
+	if f, ok := rw.out.(interface {
+		Flush() error
+	}); ok {
+		rw.err = f.Flush()
+	}
+	return rw.err
+}
+
+// RecordReader - read the records of a record stream.
+type RecordReader struct {
+	in      io.Reader
+	dec     *Decoder
+	pending []byte // the bytes read but not consumed yet
+	started bool
+}
+
+// NewRecordReader - create a RecordReader reading from r, with the DefaultDecoderOptions limits.
+func NewRecordReader(r io.Reader) *RecordReader {
+	return NewRecordReaderWithOptions(r, DefaultDecoderOptions)
+}
+
+// NewRecordReaderWithOptions - create a RecordReader reading from r, enforcing the limits of the options
+// on each record. The size of a record is limited by MaxBytes, the stream as a whole is not.
+func NewRecordReaderWithOptions(r io.Reader, options DecoderOptions) *RecordReader {
+	rr := &RecordReader{in: r}
+	rr.dec = &Decoder{pendingTag: -1, syms: make([]string, 0), types: make([]*Signature, 0), options: options}
+	rr.dec.in = &rr.dec.bytes
+	return rr
+}
+
+// Decoder - return the decoder of the session. After Next returns a record, its value can also be read
+// with the decoder, e.g. with its token API.
+func (rr *RecordReader) Decoder() *Decoder {
+	return rr.dec
+}
+
+// Read - read the next record into data, which must be a pointer, as Decoder.Decode does.
+func (rr *RecordReader) Read(data interface{}) error {
+	if err := rr.Next(); err != nil {
+		return err
+	}
+	if err := rr.dec.Decode(data); err != nil {
+		return err
+	}
+	if rr.dec.in.Buffered() > 0 {
+		rr.dec.err = fmt.Errorf("TBin record has data after its value")
+	}
+	return rr.dec.err
+}
+
+// Next - advance to the next record, which is then read from the decoder. At the end of the stream,
+// Next returns io.EOF, or io.ErrUnexpectedEOF if it ends with an incomplete record. In both cases it
+// can be called again, to resume reading once more data is available.
+func (rr *RecordReader) Next() error {
+	dec := rr.dec
+	if dec.err != nil {
+		return dec.err
+	}
+	if !rr.started {
+		if err := rr.fill(1); err != nil {
+			return err
+		}
+		dec.bytes = sliceReader{b: rr.pending[:1]}
+		if dec.readHeader() != nil {
+			return dec.err
+		}
+		rr.pending = rr.pending[1:]
+		rr.started = true
+	}
+	var size uint64
+	var n int
+	for {
+		size, n = binary.Uvarint(rr.pending)
+		if n < 0 {
+			dec.err = fmt.Errorf("Bad TBin record length")
+			return dec.err
+		}
+		if n > 0 {
+			break
+		}
+		if err := rr.fill(len(rr.pending) + 1); err != nil {
+			return err
+		}
+	}
+	if max := dec.options.MaxBytes; max > 0 && size > uint64(max) {
+		dec.err = &LimitError{"MaxBytes", max}
+		return dec.err
+	}
+	if size > maxRecordSize {
+		dec.err = fmt.Errorf("TBin record too large: %d bytes", size)
+		return dec.err
+	}
+	end := n + int(size) + 4
+	if err := rr.fill(end); err != nil {
+		return err
+	}
+	payload := rr.pending[n : end-4]
+	if binary.BigEndian.Uint32(rr.pending[end-4:end]) != crc32.ChecksumIEEE(payload) {
+		dec.err = fmt.Errorf("TBin record checksum mismatch")
+		return dec.err
+	}
+	rr.pending = rr.pending[end:]
+	dec.bytes = sliceReader{b: payload}
+	dec.stream = dec.stream[:0]
+	return nil
+}
+
+// maxRecordSize bounds the size of a record, so that a corrupt length doesn't exhaust the memory.
+const maxRecordSize = 1 << 30
+
+// fill reads until at least n bytes are pending. At the end of the input, it returns io.EOF if no bytes
+// are pending, and io.ErrUnexpectedEOF otherwise.
+func (rr *RecordReader) fill(n int) error {
+	if len(rr.pending) >= n {
+		return nil
+	}
+	buf := make([]byte, len(rr.pending), n+4096)
+	copy(buf, rr.pending)
+	for len(buf) < n {
+		m, err := rr.in.Read(buf[len(buf):cap(buf)])
+		buf = buf[:len(buf)+m]
+		if err == io.EOF || (err == nil && m == 0) {
+			break
+		}
+		if err != nil {
+			rr.pending = buf
+			rr.dec.err = err
+			return err
+		}
+	}
+	rr.pending = buf
+	if len(buf) >= n {
+		return nil
+	}
+	if len(buf) == 0 {
+		return io.EOF
+	}
+	return io.ErrUnexpectedEOF
+}
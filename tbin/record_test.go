@@ -0,0 +1,154 @@
+// Copyright 2015 Yahoo Inc.
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package tbin
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+)
+
+func testRecords(test *testing.T) ([]Point, []byte) {
+	points := []Point{{X: 1, Y: 2}, {X: -3, Y: 4}, {X: 500, Y: 600}}
+	var out bytes.Buffer
+	w := bufio.NewWriter(&out)
+	rw := NewRecordWriter(w)
+	for _, p := range points {
+		if err := rw.Write(p); err != nil {
+			test.Fatalf("Cannot write record: %v", err)
+		}
+	}
+	if out.Len() != 0 {
+		test.Errorf("Expected the records to be buffered until Flush")
+	}
+	if err := rw.Flush(); err != nil {
+		test.Fatalf("Cannot flush records: %v", err)
+	}
+	return points, out.Bytes()
+}
+
+func TestRecords(test *testing.T) {
+	points, tdata := testRecords(test)
+	//the first record has the type definition, the others are the packed point only
+	if size := len(tdata); size != 1+(1+12+4)+(1+3+4)+(1+5+4) {
+		test.Errorf("Unexpected record stream size: %d bytes", size)
+	}
+	rr := NewRecordReader(bytes.NewReader(tdata))
+	for i, expected := range points {
+		var p Point
+		if err := rr.Read(&p); err != nil {
+			test.Fatalf("Cannot read record %d: %v", i, err)
+		}
+		if p != expected {
+			test.Errorf("Record %d doesn't match: %v", i, p)
+		}
+	}
+	var p Point
+	if err := rr.Read(&p); err != io.EOF {
+		test.Errorf("Expected io.EOF after the last record, got %v", err)
+	}
+
+	//a record written with the encoder, and read with the token API
+	var out bytes.Buffer
+	rw := NewRecordWriter(&out)
+	enc := rw.Encoder()
+	enc.Encode(polyline())
+	rw.EndRecord()
+	rw.Flush()
+	rr = NewRecordReader(&out)
+	if err := rr.Next(); err != nil {
+		test.Fatalf("Cannot read record: %v", err)
+	}
+	if tok, err := rr.Decoder().Next(); err != nil || tok.Kind != StartStruct {
+		test.Errorf("Expected a struct token, got %v, %v", tok, err)
+	}
+}
+
+// growingReader returns the data appended to it so far, like a log file being written.
+type growingReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *growingReader) Read(p []byte) (int, error) {
+	if r.pos == len(r.data) {
+		return 0, io.EOF
+	}
+	n := copy(p, r.data[r.pos:])
+	r.pos += n
+	return n, nil
+}
+
+func TestRecordsTruncated(test *testing.T) {
+	points, tdata := testRecords(test)
+	for size := 0; size < len(tdata); size++ {
+		rr := NewRecordReader(bytes.NewReader(tdata[:size]))
+		count := 0
+		var err error
+		for err == nil {
+			var p Point
+			if err = rr.Read(&p); err == nil {
+				if p != points[count] {
+					test.Fatalf("Record %d of the stream truncated to %d bytes doesn't match: %v", count, size, p)
+				}
+				count++
+			}
+		}
+		if err != io.EOF && err != io.ErrUnexpectedEOF {
+			test.Errorf("Unexpected error reading the stream truncated to %d bytes: %v", size, err)
+		}
+	}
+
+	//resume when more data is appended, one byte at a time
+	in := &growingReader{}
+	rr := NewRecordReader(in)
+	var got []Point
+	for _, b := range tdata {
+		in.data = append(in.data, b)
+		var p Point
+		switch err := rr.Read(&p); err {
+		case nil:
+			got = append(got, p)
+		case io.EOF, io.ErrUnexpectedEOF:
+		default:
+			test.Fatalf("Cannot read growing stream: %v", err)
+		}
+	}
+	if len(got) != len(points) || got[len(got)-1] != points[len(points)-1] {
+		test.Errorf("Expected all records of the growing stream, got %v", got)
+	}
+
+	corrupt := append([]byte{}, tdata...)
+	corrupt[len(corrupt)-6]++
+	rr = NewRecordReader(bytes.NewReader(corrupt))
+	var err error
+	for err == nil {
+		var p Point
+		err = rr.Read(&p)
+	}
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		test.Errorf("Expected a checksum error, got %v", err)
+	}
+}
+
+func TestRecordsLimits(test *testing.T) {
+	_, tdata := testRecords(test)
+	//the first record is 12 bytes long
+	options := DefaultDecoderOptions
+	options.MaxBytes = 8
+	rr := NewRecordReaderWithOptions(bytes.NewReader(tdata), options)
+	var p Point
+	if err, ok := rr.Read(&p).(*LimitError); !ok || err.Limit != "MaxBytes" {
+		test.Errorf("Expected a record over the limit to fail, got %v", err)
+	}
+
+	//a corrupt length is rejected before anything is allocated for it
+	corrupt := append([]byte{tdata[0]}, binary.AppendUvarint(nil, 512<<20)...)
+	rr = NewRecordReader(bytes.NewReader(corrupt))
+	if err, ok := rr.Read(&p).(*LimitError); !ok || err.Max != DefaultDecoderOptions.MaxBytes {
+		test.Errorf("Expected a corrupt record length to fail, got %v", err)
+	}
+}
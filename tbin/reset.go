@@ -0,0 +1,156 @@
+// Copyright 2015 Yahoo Inc.
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package tbin
+
+import (
+	"bufio"
+	"io"
+)
+
+// byteSource is the input of a Decoder: a bufio.Reader, or a sliceReader to decode a byte slice.
+type byteSource interface {
+	io.Reader
+	io.ByteReader
+	Peek(n int) ([]byte, error)
+	Buffered() int
+}
+
+// sliceReader reads a byte slice without copying it. When the slice is truncated to the MaxBytes
+// limit, reading past its end fails with the LimitError rather than io.EOF.
+type sliceReader struct {
+	b     []byte
+	pos   int
+	limit error
+}
+
+func (s *sliceReader) end(err error) error {
+	if s.limit != nil {
+		return s.limit
+	}
+	return err
+}
+
+func (s *sliceReader) Read(p []byte) (int, error) {
+	if s.pos == len(s.b) {
+		if len(p) == 0 {
+			return 0, nil
+		}
+		return 0, s.end(io.EOF)
+	}
+	n := copy(p, s.b[s.pos:])
+	s.pos += n
+	return n, nil
+}
+
+func (s *sliceReader) ReadByte() (byte, error) {
+	if s.pos == len(s.b) {
+		return 0, s.end(io.EOF)
+	}
+	b := s.b[s.pos]
+	s.pos++
+	return b, nil
+}
+
+func (s *sliceReader) Peek(n int) ([]byte, error) {
+	if len(s.b)-s.pos < n {
+		return s.b[s.pos:], s.end(io.EOF)
+	}
+	return s.b[s.pos : s.pos+n], nil
+}
+
+func (s *sliceReader) Buffered() int {
+	return len(s.b) - s.pos
+}
+
+// next returns the next n bytes of the slice itself.
+func (s *sliceReader) next(n uint) ([]byte, error) {
+	if uint(len(s.b)-s.pos) < n {
+		s.pos = len(s.b)
+		return nil, s.end(io.ErrUnexpectedEOF)
+	}
+	b := s.b[s.pos : s.pos+int(n)]
+	s.pos += int(n)
+	return b, nil
+}
+
+// Reset - start decoding from r, reusing the buffers of the decoder. If r starts with a version header,
+// it is a new stream and the type and symbol tables are cleared. Otherwise it is read as a continuation
+// of the stream decoded so far, e.g. the next message of a session written with Encoder.Continue, and
+// the types and symbols already defined are used.
+func (d *Decoder) Reset(r io.Reader) {
+	if d.options.MaxBytes > 0 {
+		d.limit = limitReader{r: r, remaining: d.options.MaxBytes, max: d.options.MaxBytes}
+		r = &d.limit
+	}
+	if d.reader == nil {
+		d.reader = bufio.NewReader(r)
+	} else {
+		d.reader.Reset(r)
+	}
+	d.in = d.reader
+	d.start()
+}
+
+// ResetBytes - start decoding the byte slice, as Reset does, reading it directly rather than through a
+// buffer.
+func (d *Decoder) ResetBytes(b []byte) {
+	d.bytes = sliceReader{b: b}
+	if max := d.options.MaxBytes; max > 0 && int64(len(b)) > max {
+		d.bytes = sliceReader{b: b[:max], limit: &LimitError{Limit: "MaxBytes", Max: max}}
+	}
+	d.in = &d.bytes
+	d.start()
+}
+
+// SetZeroCopy - when decoding a byte slice, return bytes values that refer to the slice instead of
+// copies of them. The slice must then not be modified while they are in use.
+func (d *Decoder) SetZeroCopy(zeroCopy bool) {
+	d.zeroCopy = zeroCopy
+}
+
+// start clears the state of the value being decoded, and reads the header of a new stream, which the
+// first input of the decoder must be.
+func (d *Decoder) start() {
+	d.err = nil
+	d.pendingTag = -1
+	d.stream = d.stream[:0]
+	d.depth = 0
+	d.emptyElements = 0
+	if d.dataVersion != 0 {
+		b, err := d.in.Peek(1)
+		if err != nil || (int(b[0])&VersionTagMask) != VersionTag {
+			return
+		}
+	}
+	d.syms = d.syms[:0]
+	d.readHeader()
+}
+
+// Reset - start a new stream to w, discarding anything not flushed yet and reusing the buffers of the
+// encoder. The type and symbol tables are cleared, since the new stream defines its own.
+func (enc *Encoder) Reset(w io.Writer) {
+	enc.restart(w)
+	clear(enc.tags)
+	clear(enc.syms)
+	enc.nextTag = FirstUserTag
+	enc.nextSymId = 0
+	enc.flushed = false
+	enc.writeHeader()
+}
+
+// Continue - continue the stream to w, discarding anything not flushed yet. The type and symbol tables
+// are kept, and no header is written: what is encoded next can only be decoded by a Decoder that has
+// decoded everything encoded before, and is then Reset to the new input. In a session of messages,
+// this avoids defining the types again in each message.
+func (enc *Encoder) Continue(w io.Writer) {
+	enc.restart(w)
+	enc.flushed = true //the version of the stream can't change anymore
+}
+
+func (enc *Encoder) restart(w io.Writer) {
+	enc.out = w
+	enc.buf.Reset()
+	enc.err = nil
+	enc.tagged = false
+}
@@ -0,0 +1,110 @@
+// Copyright 2015 Yahoo Inc.
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package tbin
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestEncoderReset(test *testing.T) {
+	expected, _ := Marshal(polyline())
+	var out bytes.Buffer
+	enc := NewEncoder(&out)
+	enc.Encode(polyline())
+	enc.Reset(nil)
+	if err := enc.Encode(polyline()); err != nil || !bytes.Equal(enc.Bytes(), expected) {
+		test.Errorf("Expected a reset encoder to start a new stream, got %x, %v", enc.Bytes(), err)
+	}
+	if out.Len() != 0 {
+		test.Errorf("Expected the data not flushed to be discarded by Reset")
+	}
+	if enc.Reset(nil); enc.SetVersion(2) != nil {
+		test.Errorf("Expected the version to be selectable after Reset")
+	}
+}
+
+func TestSession(test *testing.T) {
+	points := []Point{{X: 1, Y: 2}, {X: 3, Y: 4}}
+	enc := NewEncoder(nil)
+	enc.Encode(points[0])
+	first := append([]byte{}, enc.Bytes()...)
+	enc.Continue(nil)
+	enc.Encode(points[1])
+	second := append([]byte{}, enc.Bytes()...)
+	if len(second) != 3 {
+		//the tag of the point type and its two fields, without the header and the type definition
+		test.Errorf("Expected the continued stream to reuse the type definitions: %x", second)
+	}
+
+	dec := NewBytesDecoder(first)
+	for i, msg := range [][]byte{first, second} {
+		dec.ResetBytes(msg)
+		var p Point
+		if err := dec.Decode(&p); err != nil || p != points[i] {
+			test.Errorf("Cannot decode message %d of the session: %v, %v", i, p, err)
+		}
+	}
+	//a new stream clears the types of the session
+	dec.Reset(bytes.NewReader([]byte{CurVersionTag, 0x40, NullTag}))
+	var data interface{}
+	if err := dec.Decode(&data); err == nil {
+		test.Errorf("Expected the types of the session to be cleared by a new stream")
+	}
+	if err := Unmarshal(second, &data); err == nil {
+		test.Errorf("Expected an error decoding a continued stream without its session")
+	}
+}
+
+func TestZeroCopy(test *testing.T) {
+	tdata, _ := Marshal([]byte{1, 2, 3})
+	for _, zeroCopy := range []bool{false, true} {
+		dec := NewBytesDecoder(tdata)
+		dec.SetZeroCopy(zeroCopy)
+		var b []byte
+		if err := dec.Decode(&b); err != nil || !bytes.Equal(b, []byte{1, 2, 3}) {
+			test.Fatalf("Cannot decode bytes: %v, %v", b, err)
+		}
+		shared := &b[0] == &tdata[len(tdata)-3]
+		if shared != zeroCopy {
+			test.Errorf("Expected the decoded bytes to be shared with the input: %v, got %v", zeroCopy, shared)
+		}
+		if cap(b) != len(b) {
+			test.Errorf("Expected appending to the decoded bytes not to modify the input")
+		}
+	}
+}
+
+type reuseTest struct {
+	Ints    []int32          `json:"ints"`
+	Counts  map[string]int32 `json:"counts"`
+	Comment string           `json:"comment" rdl:"optional"`
+	Points  []*Point         `json:"points"`
+}
+
+func TestDecodeIntoExisting(test *testing.T) {
+	tdata, _ := Marshal(reuseTest{Ints: []int32{1, 2}, Counts: map[string]int32{"a": 1}, Points: []*Point{{X: 5}}})
+	shared := &Point{X: 10}
+	target := reuseTest{
+		Ints:    make([]int32, 3, 10),
+		Counts:  map[string]int32{"stale": 2},
+		Comment: "stale",
+		Points:  []*Point{shared},
+	}
+	ints, counts := &target.Ints[:1][0], reflect.ValueOf(target.Counts).Pointer()
+	dec := NewBytesDecoder(tdata)
+	if err := dec.Decode(&target); err != nil {
+		test.Fatalf("Cannot decode: %v", err)
+	}
+	if !reflect.DeepEqual(target, reuseTest{Ints: []int32{1, 2}, Counts: map[string]int32{"a": 1}, Points: []*Point{{X: 5}}}) {
+		test.Errorf("Unexpected decoded value: %+v", target)
+	}
+	if &target.Ints[0] != ints || reflect.ValueOf(target.Counts).Pointer() != counts {
+		test.Errorf("Expected the existing slice and map to be reused")
+	}
+	if shared.X != 10 {
+		test.Errorf("Expected the values pointed to by a reused slice not to be modified")
+	}
+}
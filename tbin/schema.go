@@ -0,0 +1,640 @@
+// Copyright 2015 Yahoo Inc.
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package tbin
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/ardielle/ardielle-go/rdl"
+)
+
+// SchemaSignature - return the Signature of the named type of the schema. Struct fields are named by
+// their JSON names, supertype fields first, as in generated Go models, so the result matches the
+// TypeSignature of the generated type in most cases. Bytes are encoded natively, and structs without
+// fields as Any.
+func SchemaSignature(schema *rdl.Schema, name rdl.TypeName) (*Signature, error) {
+	c := newSchemaCompiler(schema)
+	return c.signature(rdl.TypeRef(name), "", "")
+}
+
+// schemaCompiler converts rdl types to signatures, remembering the names of union variants, which
+// signatures do not have, so that generic union values can be wrapped in the way the rdl validator
+// expects: a map with the variant type name as its single key.
+type schemaCompiler struct {
+	registry rdl.TypeRegistry
+	sigs     map[rdl.TypeName]*Signature
+	pending  map[rdl.TypeName]bool
+	variants map[*Signature][]string
+}
+
+func newSchemaCompiler(schema *rdl.Schema) *schemaCompiler {
+	return &schemaCompiler{
+		registry: rdl.NewTypeRegistry(schema),
+		sigs:     make(map[rdl.TypeName]*Signature),
+		pending:  make(map[rdl.TypeName]bool),
+		variants: make(map[*Signature][]string),
+	}
+}
+
+// resolve follows aliases, i.e. types defined as another user type without adding anything to it.
+func (c *schemaCompiler) resolve(t *rdl.Type) *rdl.Type {
+	for t != nil && t.Variant == rdl.TypeVariantAliasTypeDef {
+		t = c.registry.FindType(t.AliasTypeDef.Type)
+	}
+	return t
+}
+
+func (c *schemaCompiler) signature(ref rdl.TypeRef, items rdl.TypeRef, keys rdl.TypeRef) (*Signature, error) {
+	t := c.registry.FindType(ref)
+	if t == nil {
+		return nil, fmt.Errorf("Unknown type: %s", ref)
+	}
+	switch c.registry.BaseType(t) {
+	case rdl.BaseTypeBool:
+		return Bool, nil
+	case rdl.BaseTypeInt8:
+		return Int8, nil
+	case rdl.BaseTypeInt16:
+		return Int16, nil
+	case rdl.BaseTypeInt32:
+		return Int32, nil
+	case rdl.BaseTypeInt64:
+		return Int64, nil
+	case rdl.BaseTypeFloat32:
+		return Float32, nil
+	case rdl.BaseTypeFloat64:
+		return Float64, nil
+	case rdl.BaseTypeString:
+		return String, nil
+	case rdl.BaseTypeSymbol:
+		return Symbol, nil
+	case rdl.BaseTypeBytes:
+		return Bytes, nil
+	case rdl.BaseTypeTimestamp:
+		return Timestamp, nil
+	case rdl.BaseTypeUUID:
+		return UUID, nil
+	case rdl.BaseTypeAny:
+		return Any, nil
+	}
+	if t.Variant == rdl.TypeVariantBaseType || items != "" || keys != "" {
+		//Array<T> and Map<K,V> are not named, and Struct, Array and Map without parameters are generic
+		return c.container(t, items, keys)
+	}
+	name, _, _ := rdl.TypeInfo(t)
+	if sig, ok := c.sigs[name]; ok {
+		return sig, nil
+	}
+	if c.pending[name] {
+		return nil, fmt.Errorf("Cannot encode recursive type %s in TBin", name)
+	}
+	c.pending[name] = true
+	defer delete(c.pending, name)
+	sig, err := c.container(t, "", "")
+	if err == nil {
+		c.sigs[name] = sig
+	}
+	return sig, err
+}
+
+func (c *schemaCompiler) container(t *rdl.Type, items rdl.TypeRef, keys rdl.TypeRef) (*Signature, error) {
+	switch c.registry.BaseType(t) {
+	case rdl.BaseTypeStruct:
+		return c.structSignature(t)
+	case rdl.BaseTypeArray:
+		if items == "" {
+			items = c.arrayItems(t)
+		}
+		isig, err := c.signature(items, "", "")
+		if err != nil {
+			return nil, err
+		}
+		return Array(isig), nil
+	case rdl.BaseTypeMap:
+		if items == "" && keys == "" {
+			keys, items = c.mapKeysAndItems(t)
+		}
+		if keys == "" {
+			keys = "String"
+		}
+		if items == "" {
+			items = "Any"
+		}
+		ksig, err := c.signature(keys, "", "")
+		if err != nil {
+			return nil, err
+		}
+		isig, err := c.signature(items, "", "")
+		if err != nil {
+			return nil, err
+		}
+		return Map(ksig, isig), nil
+	case rdl.BaseTypeEnum:
+		t = c.resolve(t)
+		var syms []string
+		for _, elem := range t.EnumTypeDef.Elements {
+			syms = append(syms, string(elem.Symbol))
+		}
+		return Enum(syms...), nil
+	case rdl.BaseTypeUnion:
+		t = c.resolve(t)
+		var variants []*Signature
+		var names []string
+		for _, v := range t.UnionTypeDef.Variants {
+			vsig, err := c.signature(v, "", "")
+			if err != nil {
+				return nil, err
+			}
+			variants = append(variants, vsig)
+			names = append(names, string(v))
+		}
+		sig := Union(variants...)
+		c.variants[sig] = names
+		return sig, nil
+	}
+	name, _, _ := rdl.TypeInfo(t)
+	return nil, fmt.Errorf("Cannot encode type %s in TBin", name)
+}
+
+func (c *schemaCompiler) arrayItems(t *rdl.Type) rdl.TypeRef {
+	for t = c.resolve(t); t != nil && t.Variant == rdl.TypeVariantArrayTypeDef; t = c.resolve(c.registry.FindType(t.ArrayTypeDef.Type)) {
+		if t.ArrayTypeDef.Items != "" {
+			return t.ArrayTypeDef.Items
+		}
+	}
+	return "Any"
+}
+
+func (c *schemaCompiler) mapKeysAndItems(t *rdl.Type) (rdl.TypeRef, rdl.TypeRef) {
+	for t = c.resolve(t); t != nil && t.Variant == rdl.TypeVariantMapTypeDef; t = c.resolve(c.registry.FindType(t.MapTypeDef.Type)) {
+		if t.MapTypeDef.Keys != "" || t.MapTypeDef.Items != "" {
+			return t.MapTypeDef.Keys, t.MapTypeDef.Items
+		}
+	}
+	return "", ""
+}
+
+func (c *schemaCompiler) structFields(t *rdl.Type) []*rdl.StructFieldDef {
+	t = c.resolve(t)
+	if t == nil || t.Variant != rdl.TypeVariantStructTypeDef {
+		return nil
+	}
+	var fields []*rdl.StructFieldDef
+	if strings.ToLower(string(t.StructTypeDef.Type)) != "struct" {
+		fields = c.structFields(c.registry.FindType(t.StructTypeDef.Type))
+	}
+	return append(fields, t.StructTypeDef.Fields...)
+}
+
+func (c *schemaCompiler) structSignature(t *rdl.Type) (*Signature, error) {
+	fields := c.structFields(t)
+	if len(fields) == 0 {
+		return Any, nil
+	}
+	var fsigs []*FieldSignature
+	for _, f := range fields {
+		fsig, err := c.signature(f.Type, f.Items, f.Keys)
+		if err != nil {
+			return nil, err
+		}
+		name := string(f.Name)
+		if jsonName, ok := f.Annotations["x_json_name"]; ok {
+			name = jsonName
+		}
+		fsigs = append(fsigs, Field(name, fsig, f.Optional))
+	}
+	return Struct(fsigs...), nil
+}
+
+// SchemaEncoder - an Encoder for generic data, such as the result of a json.Unmarshal into an
+// interface{}, in the shape of a schema type. Structs are maps keyed by field name, enums are symbol
+// strings, unions are maps with the variant type name as their single key, and timestamps, UUIDs and
+// bytes may be strings as they are in JSON. The result is as compact as that of a generated model.
+type SchemaEncoder struct {
+	*Encoder
+	sig      *Signature
+	variants map[*Signature][]string
+}
+
+// NewSchemaEncoder - create and return a new SchemaEncoder for the named type of the schema.
+func NewSchemaEncoder(w io.Writer, schema *rdl.Schema, name rdl.TypeName) (*SchemaEncoder, error) {
+	c := newSchemaCompiler(schema)
+	sig, err := c.signature(rdl.TypeRef(name), "", "")
+	if err != nil {
+		return nil, err
+	}
+	return &SchemaEncoder{Encoder: NewEncoder(w), sig: sig, variants: c.variants}, nil
+}
+
+// Signature - return the signature the encoder writes its data with.
+func (enc *SchemaEncoder) Signature() *Signature {
+	return enc.sig
+}
+
+// Encode - encode the generic data as a value of the encoder's type.
+func (enc *SchemaEncoder) Encode(data interface{}) error {
+	if enc.err != nil {
+		return enc.err
+	}
+	if data == nil {
+		return enc.EncodeNull()
+	}
+	return enc.encodeTagged(enc.sig, data)
+}
+
+func (enc *SchemaEncoder) fail(sig *Signature, data interface{}) error {
+	if enc.err == nil {
+		enc.err = fmt.Errorf("Cannot encode %v as %v", data, sig)
+	}
+	return enc.err
+}
+
+// encodeTagged writes a value preceded by its type, as optional fields and the top level value are.
+func (enc *SchemaEncoder) encodeTagged(sig *Signature, data interface{}) error {
+	switch sig.Tag {
+	case AnyTag:
+		return enc.Encoder.Encode(data)
+	case StringTag:
+		if s, ok := data.(string); ok {
+			return enc.EncodeString(s)
+		}
+	}
+	enc.WriteType(sig)
+	return enc.writeValue(sig, data)
+}
+
+// writeValue writes the packed value of the generic data in the shape of the signature.
+func (enc *SchemaEncoder) writeValue(sig *Signature, data interface{}) error {
+	if enc.err != nil {
+		return enc.err
+	}
+	switch sig.Tag {
+	case AnyTag:
+		return enc.Encoder.Encode(data)
+	case BoolTag:
+		if b, ok := data.(bool); ok {
+			return enc.WriteBool(b)
+		}
+	case Int8Tag, Int16Tag, Int32Tag:
+		if n, ok := genericInt(data); ok && intInRange(sig.Tag, n) {
+			return enc.WriteInt(int(n))
+		}
+	case Int64Tag:
+		if n, ok := genericInt(data); ok {
+			return enc.WriteInt64(n)
+		}
+	case Float32Tag:
+		if n, ok := genericFloat(data); ok {
+			return enc.WriteFloat32(float32(n))
+		}
+	case Float64Tag:
+		if n, ok := genericFloat(data); ok {
+			return enc.WriteFloat64(n)
+		}
+	case StringTag:
+		if s, ok := genericString(data); ok {
+			return enc.WriteString(s)
+		}
+	case SymbolTag:
+		if s, ok := genericString(data); ok {
+			return enc.WriteSymbol(s)
+		}
+	case BytesTag:
+		switch b := data.(type) {
+		case []byte:
+			return enc.WriteBytes(b)
+		case string:
+			decoded, err := base64.StdEncoding.DecodeString(b)
+			if err == nil {
+				return enc.WriteBytes(decoded)
+			}
+		}
+	case TimestampTag:
+		switch ts := data.(type) {
+		case rdl.Timestamp:
+			return enc.WriteTimestamp(ts)
+		case time.Time:
+			return enc.WriteTimestamp(rdl.Timestamp{Time: ts})
+		case string:
+			parsed, err := rdl.TimestampParse(ts)
+			if err == nil {
+				return enc.WriteTimestamp(parsed)
+			}
+		}
+	case UUIDTag:
+		switch u := data.(type) {
+		case rdl.UUID:
+			if u != nil {
+				return enc.WriteUUID(u)
+			}
+		case string:
+			if parsed := rdl.ParseUUID(u); parsed != nil {
+				return enc.WriteUUID(parsed)
+			}
+		}
+	case EnumTag:
+		if s, ok := genericString(data); ok {
+			for i, sym := range sig.Symbols {
+				if sym == s {
+					return enc.WriteInt(i + 1)
+				}
+			}
+		}
+	case UnionTag:
+		return enc.writeUnion(sig, data)
+	case StructTag:
+		return enc.writeStruct(sig, data)
+	case ArrayTag:
+		v := reflect.ValueOf(data)
+		if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+			n := v.Len()
+			enc.WriteSize(n)
+			for i := 0; i < n; i++ {
+				if enc.writeValue(sig.Items, v.Index(i).Interface()) != nil {
+					break
+				}
+			}
+			return enc.err
+		}
+	case MapTag:
+		v := reflect.ValueOf(data)
+		if v.Kind() == reflect.Map {
+			enc.WriteSize(v.Len())
+			for _, k := range v.MapKeys() {
+				if enc.writeValue(sig.Keys, k.Interface()) != nil || enc.writeValue(sig.Items, v.MapIndex(k).Interface()) != nil {
+					break
+				}
+			}
+			return enc.err
+		}
+	}
+	return enc.fail(sig, data)
+}
+
+func (enc *SchemaEncoder) writeStruct(sig *Signature, data interface{}) error {
+	fields, ok := genericFields(data)
+	if !ok {
+		return enc.fail(sig, data)
+	}
+	for _, f := range sig.Fields {
+		val, present := fields[f.Name]
+		if f.optional {
+			if !present || val == nil {
+				enc.EncodeNull()
+			} else {
+				enc.encodeTagged(f.Type, val)
+			}
+		} else if !present || val == nil {
+			if enc.err == nil {
+				enc.err = fmt.Errorf("Missing required field '%s' in %v", f.Name, data)
+			}
+		} else {
+			enc.writeValue(f.Type, val)
+		}
+		if enc.err != nil {
+			break
+		}
+	}
+	return enc.err
+}
+
+func (enc *SchemaEncoder) writeUnion(sig *Signature, data interface{}) error {
+	fields, ok := genericFields(data)
+	if ok && len(fields) == 1 {
+		for i, name := range enc.variants[sig] {
+			if val, ok := fields[name]; ok {
+				enc.WriteUnsigned(i + 1)
+				return enc.writeValue(sig.Variants[i], val)
+			}
+		}
+	}
+	return enc.fail(sig, data)
+}
+
+// genericFields returns the fields of a generic struct, or of a union wrapper, which may be a
+// map[string]interface{}, an rdl.Struct, or any other map keyed by strings.
+func genericFields(data interface{}) (map[string]interface{}, bool) {
+	switch m := data.(type) {
+	case map[string]interface{}:
+		return m, true
+	case rdl.Struct:
+		fields := make(map[string]interface{}, len(m))
+		for k, v := range m {
+			fields[string(k)] = v
+		}
+		return fields, true
+	}
+	v := reflect.ValueOf(data)
+	if v.Kind() != reflect.Map || v.Type().Key().Kind() != reflect.String {
+		return nil, false
+	}
+	fields := make(map[string]interface{}, v.Len())
+	for _, k := range v.MapKeys() {
+		fields[k.String()] = v.MapIndex(k).Interface()
+	}
+	return fields, true
+}
+
+func genericString(data interface{}) (string, bool) {
+	v := reflect.ValueOf(data)
+	if v.Kind() == reflect.String {
+		return v.String(), true
+	}
+	return "", false
+}
+
+func genericInt(data interface{}) (int64, bool) {
+	if n, ok := data.(json.Number); ok {
+		i, err := n.Int64()
+		return i, err == nil
+	}
+	v := reflect.ValueOf(data)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		return int64(f), f == float64(int64(f))
+	}
+	return 0, false
+}
+
+// intInRange returns whether the integer fits the size of the tag.
+func intInRange(tag int, n int64) bool {
+	switch tag {
+	case Int8Tag:
+		return n >= math.MinInt8 && n <= math.MaxInt8
+	case Int16Tag:
+		return n >= math.MinInt16 && n <= math.MaxInt16
+	}
+	return n >= math.MinInt32 && n <= math.MaxInt32
+}
+
+func genericFloat(data interface{}) (float64, bool) {
+	if n, ok := data.(json.Number); ok {
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	v := reflect.ValueOf(data)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+	return 0, false
+}
+
+// SchemaDecoder - a Decoder for generic data of a schema type, the counterpart of SchemaEncoder.
+// Structs decode to map[string]interface{} without the absent optional fields, enums to their symbol
+// strings, and unions to a map with the variant type name as its single key. Data that was not
+// written with the signature of the schema type is decoded as Decode would into an interface{}.
+type SchemaDecoder struct {
+	*Decoder
+	sig      *Signature
+	variants map[*Signature][]string
+}
+
+// NewSchemaDecoder - create and return a new SchemaDecoder for the named type of the schema.
+func NewSchemaDecoder(r io.Reader, schema *rdl.Schema, name rdl.TypeName) (*SchemaDecoder, error) {
+	c := newSchemaCompiler(schema)
+	sig, err := c.signature(rdl.TypeRef(name), "", "")
+	if err != nil {
+		return nil, err
+	}
+	return &SchemaDecoder{Decoder: NewDecoder(r), sig: sig, variants: c.variants}, nil
+}
+
+// Signature - return the signature the decoder expects its data to have.
+func (d *SchemaDecoder) Signature() *Signature {
+	return d.sig
+}
+
+// Decode - decode the next value in the stream.
+func (d *SchemaDecoder) Decode() (interface{}, error) {
+	if d.err != nil {
+		return nil, d.err
+	}
+	return d.decodeTagged(d.sig)
+}
+
+// decodeTagged reads a value preceded by its type, as optional fields and the top level value are.
+func (d *SchemaDecoder) decodeTagged(sig *Signature) (interface{}, error) {
+	switch sig.Tag {
+	case StructTag, ArrayTag, MapTag, EnumTag, UnionTag:
+		if d.ReadNull() {
+			return nil, d.err
+		}
+		b, err := d.in.Peek(1)
+		if err != nil {
+			d.err = err
+			return nil, err
+		}
+		if b[0] < FirstUserTag {
+			return d.decode()
+		}
+		wire, err := d.ReadType()
+		if err != nil {
+			return nil, err
+		}
+		if !sig.matches(wire) {
+			return d.decodeType(wire)
+		}
+		return d.readValue(sig)
+	}
+	return d.decode()
+}
+
+// readValue reads the packed value of a signature.
+func (d *SchemaDecoder) readValue(sig *Signature) (interface{}, error) {
+	switch sig.Tag {
+	case StructTag:
+		result := make(map[string]interface{}, len(sig.Fields))
+		for _, f := range sig.Fields {
+			var val interface{}
+			var err error
+			if f.optional {
+				val, err = d.decodeTagged(f.Type)
+			} else {
+				val, err = d.readValue(f.Type)
+			}
+			if err != nil {
+				return nil, err
+			}
+			if val != nil {
+				result[f.Name] = val
+			}
+		}
+		return result, d.err
+	case ArrayTag:
+		n := d.ReadSize()
+		if !d.checkEmptyElements(sig.Items, n) {
+			return nil, d.err
+		}
+		result := make([]interface{}, 0, capHint(n))
+		for i := 0; i < n && d.err == nil; i++ {
+			item, err := d.readValue(sig.Items)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, item)
+		}
+		return result, d.err
+	case MapTag:
+		n := d.ReadSize()
+		result := make(map[string]interface{}, capHint(n))
+		for i := 0; i < n && d.err == nil; i++ {
+			key, err := d.readValue(sig.Keys)
+			if err != nil {
+				return nil, err
+			}
+			skey, ok := key.(string)
+			if !ok {
+				d.err = fmt.Errorf("cannot decode Map from tbin: key is non-string derived: %v", key)
+				return nil, d.err
+			}
+			item, err := d.readValue(sig.Items)
+			if err != nil {
+				return nil, err
+			}
+			result[skey] = item
+		}
+		return result, d.err
+	case EnumTag:
+		n := d.ParseInt()
+		if d.err == nil && (n < 1 || n > len(sig.Symbols)) {
+			d.err = fmt.Errorf("Bad enum value %d for %v", n, sig)
+		}
+		if d.err != nil {
+			return nil, d.err
+		}
+		return sig.Symbols[n-1], nil
+	case UnionTag:
+		n := d.ReadUnsigned()
+		names := d.variants[sig]
+		if d.err == nil && (n < 1 || n > len(names)) {
+			d.err = fmt.Errorf("Bad variant %d for %v", n, sig)
+		}
+		if d.err != nil {
+			return nil, d.err
+		}
+		val, err := d.readValue(sig.Variants[n-1])
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{names[n-1]: val}, nil
+	}
+	return d.decodeType(sig)
+}
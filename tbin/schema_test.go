@@ -0,0 +1,168 @@
+// Copyright 2015 Yahoo Inc.
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package tbin
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/ardielle/ardielle-go/rdl"
+)
+
+func loadSchema(test *testing.T, name string) *rdl.Schema {
+	schema, err := rdl.ParseRDLFile("../testdata/"+name, false, false, false)
+	if err != nil {
+		test.Fatalf("Cannot parse %s: %v", name, err)
+	}
+	return schema
+}
+
+// normalized round trips the generic data through JSON, so that values with different Go types but the
+// same JSON representation compare equal.
+func normalized(test *testing.T, data interface{}) interface{} {
+	j, err := json.Marshal(data)
+	if err != nil {
+		test.Fatalf("Cannot marshal %v to JSON: %v", data, err)
+	}
+	var result interface{}
+	json.Unmarshal(j, &result)
+	return result
+}
+
+func schemaRoundTrip(test *testing.T, schema *rdl.Schema, name rdl.TypeName, data interface{}) []byte {
+	enc, err := NewSchemaEncoder(nil, schema, name)
+	if err != nil {
+		test.Fatalf("Cannot create encoder for %s: %v", name, err)
+	}
+	if err := enc.Encode(data); err != nil {
+		test.Fatalf("Cannot encode %s: %v", name, err)
+	}
+	tdata := enc.Bytes()
+	dec, err := NewSchemaDecoder(bytes.NewReader(tdata), schema, name)
+	if err != nil {
+		test.Fatalf("Cannot create decoder for %s: %v", name, err)
+	}
+	decoded, err := dec.Decode()
+	if err != nil {
+		test.Fatalf("Cannot decode %s: %v", name, err)
+	}
+	if !reflect.DeepEqual(normalized(test, data), normalized(test, decoded)) {
+		test.Errorf("Decoded %s doesn't match the original:\n got %s\nwant %s", name, Pretty(decoded), Pretty(data))
+	}
+	return tdata
+}
+
+func TestSchemaSignature(test *testing.T) {
+	schema := loadSchema(test, "bigtest.rdl")
+	for name, val := range map[rdl.TypeName]interface{}{"BigTest": BigTest{}, "MapArrayTest": MapArrayTest{}, "StringTest": StringTest{}} {
+		sig, err := SchemaSignature(schema, name)
+		if err != nil {
+			test.Fatalf("Cannot get signature of %s: %v", name, err)
+		}
+		if expected := TypeSignature(val); sig.String() != expected.String() {
+			test.Errorf("Signature of %s doesn't match the Go type:\n got %v\nwant %v", name, sig, expected)
+		}
+	}
+	if _, err := SchemaSignature(schema, "NoSuchType"); err == nil {
+		test.Errorf("Expected an error for an unknown type")
+	}
+	if _, err := SchemaSignature(loadSchema(test, "recursive.rdl"), "RouteRule"); err == nil {
+		test.Errorf("Expected an error for a recursive type")
+	}
+}
+
+func TestSchemaEncodeGeneric(test *testing.T) {
+	j, err := os.ReadFile("../testdata/bigtest.json")
+	if err != nil {
+		test.Fatalf("Cannot read JSON file: %v", err)
+	}
+	var generic interface{}
+	if err := json.Unmarshal(j, &generic); err != nil {
+		test.Fatalf("Cannot parse JSON: %v", err)
+	}
+	tdata := schemaRoundTrip(test, loadSchema(test, "bigtest.rdl"), "BigTest", generic)
+
+	//the generic data is as compact as the Go model, and much more compact than without the schema
+	var bt BigTest
+	json.Unmarshal(j, &bt)
+	enc := NewEncoder(nil)
+	enc.EncodeReflect(bt)
+	if len(tdata) != len(enc.Bytes()) {
+		test.Errorf("Expected %d bytes of schema encoded data, the size of the Go model encoding, got %d", len(enc.Bytes()), len(tdata))
+	}
+	gdata, _ := Marshal(generic)
+	if len(tdata) >= len(gdata) {
+		test.Errorf("Expected schema encoded data (%d bytes) to be smaller than generic data (%d bytes)", len(tdata), len(gdata))
+	}
+}
+
+func TestSchemaEncodeShapes(test *testing.T) {
+	schema := loadSchema(test, "shapes.rdl")
+	circle := map[string]interface{}{"Circle": map[string]interface{}{"center": map[string]interface{}{"x": 1, "y": 2}, "radius": 3}}
+	schemaRoundTrip(test, schema, "Drawing", map[string]interface{}{
+		"shapes": []interface{}{circle, map[string]interface{}{"Color": "BLUE"}},
+	})
+	schemaRoundTrip(test, schema, "Drawing", rdl.Struct{
+		"shapes":     []interface{}{},
+		"focus":      circle,
+		"legend":     map[string]interface{}{"sea": "BLUE", "grass": "GREEN"},
+		"background": "RED",
+		"zoom":       2,
+		"created":    "2015-05-14T19:53:06.000Z",
+		"icon":       "AAECAw==",
+		"extra":      []interface{}{"anything", 1.5},
+	})
+	schemaRoundTrip(test, schema, "Color", "GREEN")
+
+	enc, _ := NewSchemaEncoder(nil, schema, "Drawing")
+	for _, bad := range []interface{}{
+		map[string]interface{}{},
+		map[string]interface{}{"shapes": []interface{}{map[string]interface{}{"Square": 1}}},
+		map[string]interface{}{"shapes": []interface{}{}, "background": "PURPLE"},
+		map[string]interface{}{"shapes": []interface{}{}, "zoom": 1.5},
+		map[string]interface{}{"shapes": []interface{}{}, "zoom": int64(1) << 31},
+		"drawing",
+	} {
+		enc.err = nil
+		if err := enc.Encode(bad); err == nil {
+			test.Errorf("Expected an error encoding %v", bad)
+		}
+	}
+}
+
+func TestSchemaEncodeIntRange(test *testing.T) {
+	//integers must fit the size of their type
+	schema := loadSchema(test, "bigtest.rdl")
+	schemaRoundTrip(test, schema, "TinyInt", -128)
+	schemaRoundTrip(test, schema, "SmallInt", 32767)
+	for name, bad := range map[rdl.TypeName]interface{}{"TinyInt": 300, "SmallInt": -32769} {
+		enc, _ := NewSchemaEncoder(nil, schema, name)
+		if err := enc.Encode(bad); err == nil {
+			test.Errorf("Expected an error encoding %v as %s", bad, name)
+		}
+	}
+}
+
+func TestSchemaDecodeUntyped(test *testing.T) {
+	//data written without the schema signature is decoded generically
+	schema := loadSchema(test, "polyline.rdl")
+	generic := map[string]interface{}{"points": []interface{}{map[string]interface{}{"x": 1, "y": 2}}}
+	tdata, _ := Marshal(polyline())
+	dec, _ := NewSchemaDecoder(bytes.NewReader(tdata), schema, "Polyline")
+	if decoded, err := dec.Decode(); err != nil {
+		test.Errorf("Cannot decode Polyline data: %v", err)
+	} else if !reflect.DeepEqual(normalized(test, decoded), normalized(test, polyline())) {
+		test.Errorf("Decoded Polyline doesn't match the original: %v", decoded)
+	}
+	tdata, _ = Marshal(generic)
+	dec, _ = NewSchemaDecoder(bytes.NewReader(tdata), schema, "Polyline")
+	if decoded, err := dec.Decode(); err != nil {
+		test.Errorf("Cannot decode generic data: %v", err)
+	} else if !reflect.DeepEqual(normalized(test, decoded), normalized(test, generic)) {
+		test.Errorf("Decoded generic data doesn't match the original: %v", decoded)
+	}
+}
@@ -0,0 +1,278 @@
+// Copyright 2015 Yahoo Inc.
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package tbin
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+)
+
+// TokenKind identifies the kind of a Token.
+type TokenKind int
+
+const (
+	// Value - a complete scalar value: a primitive, an enum symbol, or null.
+	Value TokenKind = iota
+	// StartArray - the start of an array, its elements follow, then an End token.
+	StartArray
+	// StartMap - the start of a map, its keys and items follow alternately, then an End token.
+	StartMap
+	// StartStruct - the start of a struct, a FieldName and value follow for each field, then an End token.
+	StartStruct
+	// FieldName - the name of the struct field whose value follows.
+	FieldName
+	// End - the end of the innermost array, map or struct.
+	End
+)
+
+var tokenKindNames = []string{"Value", "StartArray", "StartMap", "StartStruct", "FieldName", "End"}
+
+func (k TokenKind) String() string {
+	if k >= 0 && int(k) < len(tokenKindNames) {
+		return tokenKindNames[k]
+	}
+	return fmt.Sprintf("TokenKind(%d)", int(k))
+}
+
+// Token - an item of a TBin stream returned by Decoder.Next. Sig is the type of the value, or of the
+// array, map or struct the token starts or ends. Generic containers have the signatures Array(Any),
+// Map(Any, Any) and a Struct without fields, and union values have the signature of their variant.
+type Token struct {
+	Kind  TokenKind
+	Sig   *Signature
+	Name  string      // the name of a FieldName token
+	Value interface{} // the value of a Value token, as decoded generically
+	Count int         // the number of elements, entries or fields of a Start token
+}
+
+// streamFrame is the state of an array, map or struct being read by Next.
+type streamFrame struct {
+	sig       *Signature
+	index     int
+	count     int  // the number of values, twice the number of entries for a map
+	named     bool // the name of the current struct field has been returned
+	fieldName string
+}
+
+// elementType returns the type of the next value in the container, Any for a tagged value.
+func (f *streamFrame) elementType() *Signature {
+	switch f.sig.Tag {
+	case StructTag:
+		if f.sig.Fields != nil {
+			return f.sig.Fields[f.index].Type
+		}
+	case ArrayTag:
+		return f.sig.Items
+	case MapTag:
+		if f.index%2 == 0 {
+			return f.sig.Keys
+		}
+		return f.sig.Items
+	}
+	return Any
+}
+
+// Next - read the next token of the stream, without materializing arrays, maps and structs. This is
+// an alternative to Decode for values too large to hold in memory, the two can be mixed between top
+// level values. At the end of the stream, Next returns io.EOF.
+func (d *Decoder) Next() (*Token, error) {
+	if d.err != nil {
+		return nil, d.err
+	}
+	n := len(d.stream)
+	if n == 0 {
+		if _, err := d.in.Peek(1); err == io.EOF {
+			return nil, io.EOF
+		}
+		return d.nextTagged()
+	}
+	frame := d.stream[n-1]
+	if frame.index == frame.count {
+		d.stream = d.stream[:n-1]
+		return &Token{Kind: End, Sig: frame.sig}, nil
+	}
+	if frame.sig.Tag == StructTag && !frame.named {
+		if frame.sig.Fields != nil {
+			frame.fieldName = frame.sig.Fields[frame.index].Name
+		} else if frame.fieldName, d.err = d.ParseSymbol(); d.err != nil {
+			return nil, d.err
+		}
+		frame.named = true
+		return &Token{Kind: FieldName, Sig: frame.elementType(), Name: frame.fieldName}, nil
+	}
+	sig := frame.elementType()
+	frame.index++
+	frame.named = false
+	if sig.Tag == AnyTag {
+		return d.nextTagged()
+	}
+	return d.nextPacked(sig)
+}
+
+// nextTagged reads the first token of a tagged value, and the type definitions that precede it.
+func (d *Decoder) nextTagged() (*Token, error) {
+again:
+	start := d.ParseUnsigned()
+	if d.err != nil {
+		return nil, d.err
+	}
+	tag := int(start)
+	if tag >= FirstUserTag {
+		idx := tag - FirstUserTag
+		if idx < len(d.types) {
+			return d.nextPacked(d.types[idx])
+		}
+		if !d.defineType(start) {
+			return nil, d.err
+		}
+		goto again
+	}
+	if (tag & TinyStrTagMask) == TinyStrTag {
+		tinybuf := make([]byte, tag&TinyStrDataMask)
+		d.readBytes(tinybuf)
+		return &Token{Kind: Value, Sig: String, Value: string(tinybuf)}, d.err
+	}
+	switch tag {
+	case NullTag:
+		return &Token{Kind: Value, Sig: Null}, nil
+	case StructTag:
+		return d.startContainer(StartStruct, &Signature{Tag: StructTag}, d.ReadSize())
+	case ArrayTag:
+		return d.startContainer(StartArray, Array(Any), d.ReadSize())
+	case MapTag:
+		return d.startContainer(StartMap, Map(Any, Any), d.ReadSize())
+	case BoolTag, Int8Tag, Int16Tag, Int32Tag, Int64Tag, Float32Tag, Float64Tag, BytesTag, StringTag, TimestampTag, SymbolTag, UUIDTag:
+		return d.nextPacked(&Signature{Tag: tag})
+	}
+	d.err = fmt.Errorf("Unexpected tag value: 0x%02x", tag)
+	return nil, d.err
+}
+
+// nextPacked reads the first token of a packed value of the given type.
+func (d *Decoder) nextPacked(sig *Signature) (*Token, error) {
+	switch sig.Tag {
+	case StructTag:
+		return d.startContainer(StartStruct, sig, len(sig.Fields))
+	case ArrayTag:
+		n := d.ReadSize()
+		d.checkEmptyElements(sig.Items, n)
+		return d.startContainer(StartArray, sig, n)
+	case MapTag:
+		return d.startContainer(StartMap, sig, d.ReadSize())
+	case UnionTag:
+		n := d.ReadUnsigned()
+		if d.err == nil && (n < 1 || n > len(sig.Variants)) {
+			d.err = fmt.Errorf("Variant id out of range for %v: %d", sig, n)
+		}
+		if d.err != nil {
+			return nil, d.err
+		}
+		return d.nextPacked(sig.Variants[n-1])
+	case AnyTag:
+		return d.nextTagged()
+	case EnumTag:
+		n := d.ParseInt()
+		if d.err == nil && (n < 0 || n >= len(sig.Symbols)) {
+			d.err = fmt.Errorf("Enum value out of range for %v: %d", sig, n)
+		}
+		if d.err != nil {
+			return nil, d.err
+		}
+		return &Token{Kind: Value, Sig: sig, Value: sig.Symbols[n]}, nil
+	}
+	val, err := d.decodeType(sig)
+	if err != nil {
+		return nil, err
+	}
+	return &Token{Kind: Value, Sig: sig, Value: val}, nil
+}
+
+func (d *Decoder) startContainer(kind TokenKind, sig *Signature, count int) (*Token, error) {
+	if d.err != nil {
+		return nil, d.err
+	}
+	if max := d.options.MaxDepth; max > 0 && len(d.stream) >= max {
+		d.err = &LimitError{Limit: "MaxDepth", Max: int64(max)}
+		return nil, d.err
+	}
+	values := count
+	if kind == StartMap {
+		values *= 2
+	}
+	d.stream = append(d.stream, &streamFrame{sig: sig, count: values})
+	return &Token{Kind: kind, Sig: sig, Count: count}, nil
+}
+
+// Elements - an iterator over the elements of an array, as returned by Decoder.Elements.
+type Elements struct {
+	dec       *Decoder
+	items     *Signature
+	remaining int
+}
+
+// Elements - start reading the array that is the next top level value of the stream, one element at
+// a time. This decodes arbitrarily large arrays, such as exports of many records, in constant memory.
+func (d *Decoder) Elements() (*Elements, error) {
+	if len(d.stream) > 0 {
+		d.err = fmt.Errorf("Elements must be called between top level values")
+		return nil, d.err
+	}
+	tok, err := d.nextTagged()
+	if err != nil {
+		return nil, err
+	}
+	if tok.Kind != StartArray {
+		d.err = fmt.Errorf("Expected an array, found %v", tok.Sig)
+		return nil, d.err
+	}
+	//the elements are read here rather than by Next
+	d.stream = d.stream[:0]
+	return &Elements{dec: d, items: tok.Sig.Items, remaining: tok.Count}, nil
+}
+
+// Len - return the number of elements not read yet.
+func (e *Elements) Len() int {
+	return e.remaining
+}
+
+// More - report whether there are elements left to read.
+func (e *Elements) More() bool {
+	return e.remaining > 0 && e.dec.err == nil
+}
+
+// Items - return the signature of the elements, Any if they are tagged.
+func (e *Elements) Items() *Signature {
+	return e.items
+}
+
+// Decode - decode the next element into data, which must be a pointer, as Decoder.Decode does.
+func (e *Elements) Decode(data interface{}) error {
+	d := e.dec
+	if d.err != nil {
+		return d.err
+	}
+	if e.remaining == 0 {
+		return io.EOF
+	}
+	e.remaining--
+	if e.items.Tag == AnyTag {
+		return d.Decode(data)
+	}
+	rv := reflect.ValueOf(data)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		d.err = fmt.Errorf("Cannot decode into this: %v", data)
+		return d.err
+	}
+	v := rv.Elem()
+	if v.Kind() == reflect.Interface && v.NumMethod() == 0 {
+		val, err := d.decodeType(e.items)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(val))
+		return nil
+	}
+	return d.decodeTypeReflect(e.items, v)
+}
@@ -0,0 +1,166 @@
+// Copyright 2015 Yahoo Inc.
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package tbin
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+)
+
+// buildValue reassembles the generic value that starts with the token.
+func buildValue(test *testing.T, dec *Decoder, tok *Token) interface{} {
+	switch tok.Kind {
+	case Value:
+		return tok.Value
+	case StartArray:
+		result := make([]interface{}, 0, tok.Count)
+		for {
+			item := nextToken(test, dec)
+			if item.Kind == End {
+				return result
+			}
+			result = append(result, buildValue(test, dec, item))
+		}
+	case StartMap, StartStruct:
+		result := make(map[string]interface{}, tok.Count)
+		for {
+			key := nextToken(test, dec)
+			if key.Kind == End {
+				return result
+			}
+			if tok.Kind == StartStruct {
+				if key.Kind != FieldName {
+					test.Fatalf("Expected a field name, got %v", key.Kind)
+				}
+				result[key.Name] = buildValue(test, dec, nextToken(test, dec))
+			} else {
+				result[buildValue(test, dec, key).(string)] = buildValue(test, dec, nextToken(test, dec))
+			}
+		}
+	}
+	test.Fatalf("Unexpected token %v", tok.Kind)
+	return nil
+}
+
+func nextToken(test *testing.T, dec *Decoder) *Token {
+	tok, err := dec.Next()
+	if err != nil {
+		test.Fatalf("Cannot read token: %v", err)
+	}
+	return tok
+}
+
+func expectTokens(test *testing.T, tdata []byte) {
+	var expected []interface{}
+	dec := NewDecoder(bytes.NewReader(tdata))
+	for {
+		var data interface{}
+		if _, err := dec.in.Peek(1); err == io.EOF {
+			break
+		}
+		if err := dec.Decode(&data); err != nil {
+			test.Fatalf("Cannot decode: %v", err)
+		}
+		expected = append(expected, data)
+	}
+	var got []interface{}
+	dec = NewDecoder(bytes.NewReader(tdata))
+	for {
+		tok, err := dec.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			test.Fatalf("Cannot read token: %v", err)
+		}
+		got = append(got, buildValue(test, dec, tok))
+	}
+	if !reflect.DeepEqual(normalized(test, got), normalized(test, expected)) {
+		test.Errorf("Values read from tokens don't match the decoded ones:\n got %s\nwant %s", Pretty(got), Pretty(expected))
+	}
+}
+
+func TestNextTokens(test *testing.T) {
+	for _, name := range []string{"test.tbin", "test_generic.tbin", "rdl_schema.tbin"} {
+		expectTokens(test, readTestFile(test, name))
+	}
+
+	//unions, enums and optional fields, followed by a second value in the same stream
+	enc, _ := NewSchemaEncoder(nil, loadSchema(test, "shapes.rdl"), "Drawing")
+	circle := map[string]interface{}{"Circle": map[string]interface{}{"center": map[string]interface{}{"x": 1, "y": 2}, "radius": 3}}
+	enc.Encode(map[string]interface{}{"shapes": []interface{}{circle, map[string]interface{}{"Color": "BLUE"}}, "zoom": 2})
+	enc.Encode(map[string]interface{}{"shapes": []interface{}{}, "legend": map[string]interface{}{"sea": "BLUE"}})
+	if enc.Error() != nil {
+		test.Fatalf("Cannot encode drawings: %v", enc.Error())
+	}
+	expectTokens(test, enc.Bytes())
+
+	dec := NewDecoder(bytes.NewReader(enc.Bytes()))
+	var kinds []TokenKind
+	for i := 0; i < 8; i++ {
+		kinds = append(kinds, nextToken(test, dec).Kind)
+	}
+	expected := []TokenKind{StartStruct, FieldName, StartArray, StartStruct, FieldName, StartStruct, FieldName, Value}
+	if !reflect.DeepEqual(kinds, expected) {
+		test.Errorf("Unexpected tokens:\n got %v\nwant %v", kinds, expected)
+	}
+}
+
+func TestElements(test *testing.T) {
+	points := make([]Point, 1000)
+	for i := range points {
+		points[i] = Point{X: int32(i), Y: int32(-i)}
+	}
+	enc := NewEncoder(nil)
+	enc.EncodeReflect(points)
+	enc.Encode(Point{X: 5, Y: 6})
+	tdata := enc.Bytes()
+
+	dec := NewDecoder(bytes.NewReader(tdata))
+	elements, err := dec.Elements()
+	if err != nil {
+		test.Fatalf("Cannot read array: %v", err)
+	}
+	if elements.Len() != len(points) {
+		test.Errorf("Expected %d elements, got %d", len(points), elements.Len())
+	}
+	for i := 0; elements.More(); i++ {
+		var p Point
+		if err := elements.Decode(&p); err != nil {
+			test.Fatalf("Cannot decode element %d: %v", i, err)
+		}
+		if p != points[i] {
+			test.Fatalf("Element %d doesn't match: %v", i, p)
+		}
+	}
+	//the decoder continues with the next value, using the same type definitions
+	var p Point
+	if err := dec.Decode(&p); err != nil || p.X != 5 || p.Y != 6 {
+		test.Errorf("Cannot decode the value after the array: %v, %v", p, err)
+	}
+
+	tdata, _ = Marshal([]interface{}{"a", int32(1), map[string]interface{}{"b": true}})
+	elements, err = NewDecoder(bytes.NewReader(tdata)).Elements()
+	if err != nil {
+		test.Fatalf("Cannot read generic array: %v", err)
+	}
+	var items []interface{}
+	for elements.More() {
+		var item interface{}
+		if err := elements.Decode(&item); err != nil {
+			test.Fatalf("Cannot decode generic element: %v", err)
+		}
+		items = append(items, item)
+	}
+	if !reflect.DeepEqual(items, []interface{}{"a", int32(1), map[string]interface{}{"b": true}}) {
+		test.Errorf("Generic elements don't match: %v", items)
+	}
+
+	tdata, _ = Marshal(polyline())
+	if _, err := NewDecoder(bytes.NewReader(tdata)).Elements(); err == nil {
+		test.Errorf("Expected an error reading the elements of a struct")
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright 2015 Yahoo Inc.
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package tbin
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ardielle/ardielle-go/rdl"
+)
+
+func encodeVersion(test *testing.T, version int, data interface{}) []byte {
+	enc := NewEncoder(nil)
+	if err := enc.SetVersion(version); err != nil {
+		test.Fatalf("Cannot set version %d: %v", version, err)
+	}
+	if err := enc.Encode(data); err != nil {
+		test.Fatalf("Cannot encode %v: %v", data, err)
+	}
+	return enc.Bytes()
+}
+
+func TestTimestampVersions(test *testing.T) {
+	ts := rdl.Timestamp{Time: time.Unix(1700000000, 123456789).UTC()}
+	for _, data := range []interface{}{ts, TimestampTest{Mytime: ts}} {
+		v1 := encodeVersion(test, 1, data)
+		v2 := encodeVersion(test, 2, data)
+		if v1[0] != VersionTag || v2[0] != VersionTag+1 {
+			test.Errorf("Unexpected headers: 0x%02x, 0x%02x", v1[0], v2[0])
+		}
+		var t1, t2 TimestampTest
+		if _, ok := data.(rdl.Timestamp); ok {
+			var g1, g2 interface{}
+			if err1, err2 := Unmarshal(v1, &g1), Unmarshal(v2, &g2); err1 != nil || err2 != nil {
+				test.Fatalf("Cannot decode timestamps: %v, %v", err1, err2)
+			}
+			t1.Mytime, t2.Mytime = g1.(rdl.Timestamp), g2.(rdl.Timestamp)
+		} else if err1, err2 := Unmarshal(v1, &t1), Unmarshal(v2, &t2); err1 != nil || err2 != nil {
+			test.Fatalf("Cannot decode structs: %v, %v", err1, err2)
+		}
+		if !t2.Mytime.Time.Equal(ts.Time) {
+			test.Errorf("Version 2 timestamp doesn't match: %v", t2.Mytime)
+		}
+		if t1.Mytime.Time.Equal(ts.Time) || t1.Mytime.Time.Sub(ts.Time).Abs() > time.Microsecond {
+			test.Errorf("Expected version 1 timestamp to be rounded to the microsecond: %v", t1.Mytime)
+		}
+	}
+
+	before := rdl.Timestamp{Time: time.Unix(-1000, 1).UTC()}
+	dec := NewDecoder(bytes.NewReader(encodeVersion(test, 2, before)))
+	var decoded rdl.Timestamp
+	if err := dec.Decode(&decoded); err != nil || !decoded.Time.Equal(before.Time) {
+		test.Errorf("Cannot decode a timestamp before epoch: %v, %v", decoded, err)
+	}
+	if dec.Version() != 2 {
+		test.Errorf("Expected the decoder to report version 2, got %d", dec.Version())
+	}
+
+	var out bytes.Buffer
+	if err := Dump(bytes.NewReader(encodeVersion(test, 2, ts)), &out); err != nil {
+		test.Errorf("Cannot dump version 2 data: %v", err)
+	} else if !strings.Contains(out.String(), "timestamp 2023-11-14T22:13:20.123Z") {
+		test.Errorf("Unexpected dump of version 2 data:\n%s", out.String())
+	}
+}
+
+func TestVersionSelection(test *testing.T) {
+	enc := NewEncoder(nil)
+	if enc.Version() != CurrentVersion {
+		test.Errorf("Expected encoders to write version %d by default", CurrentVersion)
+	}
+	enc.Encode(int32(1))
+	if enc.SetVersion(2) == nil {
+		test.Errorf("Expected an error setting the version after encoding")
+	}
+	if NewEncoder(nil).SetVersion(LatestVersion+1) == nil {
+		test.Errorf("Expected an error setting an unsupported version")
+	}
+	var data interface{}
+	if err := Unmarshal([]byte{VersionTag + LatestVersion, NullTag}, &data); err == nil {
+		test.Errorf("Expected an error decoding an unsupported version")
+	}
+
+	//the version of a record stream is selected with its encoder
+	var buf bytes.Buffer
+	rw := NewRecordWriter(&buf)
+	rw.Encoder().SetVersion(2)
+	ts := rdl.Timestamp{Time: time.Unix(1, 2).UTC()}
+	rw.Write(ts)
+	rw.Write(ts)
+	if err := rw.Flush(); err != nil {
+		test.Fatalf("Cannot write records: %v", err)
+	}
+	rr := NewRecordReader(&buf)
+	for i := 0; i < 2; i++ {
+		var decoded rdl.Timestamp
+		if err := rr.Read(&decoded); err != nil || !decoded.Time.Equal(ts.Time) {
+			test.Errorf("Cannot read version 2 record: %v, %v", decoded, err)
+		}
+	}
+	if rr.Decoder().Version() != 2 {
+		test.Errorf("Expected a version 2 record stream, got %d", rr.Decoder().Version())
+	}
+}